@@ -0,0 +1,93 @@
+package conformance_test
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/raja.aiml/llm-fast-wrapper/api/handler"
+	"github.com/raja.aiml/llm-fast-wrapper/internal/auditlog/prompt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// auditRecorder is a prompt.Logger that keeps LogRequest records.
+type auditRecorder struct {
+	mu      sync.Mutex
+	entries []prompt.ResponseEntry
+}
+
+func (r *auditRecorder) LogPrompt(string, string, time.Time) error { return nil }
+
+func (r *auditRecorder) LogResponse(string, string, string, time.Time) error { return nil }
+
+func (r *auditRecorder) LogRequest(p, resp, token string, status int, usage prompt.Usage, ts time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries = append(r.entries, prompt.ResponseEntry{Prompt: p, Response: resp, Token: token, Status: status, Usage: usage, Timestamp: ts})
+	return nil
+}
+
+// next waits for the next record.
+func (r *auditRecorder) next(t *testing.T) prompt.ResponseEntry {
+	t.Helper()
+	var entry prompt.ResponseEntry
+	require.Eventually(t, func() bool {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		if len(r.entries) == 0 {
+			return false
+		}
+		entry, r.entries = r.entries[0], r.entries[1:]
+		return true
+	}, time.Second, 5*time.Millisecond)
+	return entry
+}
+
+func TestAuditLog(t *testing.T) {
+	for _, stream := range []bool{false, true} {
+		body := `{"model":"echo","messages":[{"role":"user","content":"hi"}]}`
+		if stream {
+			body = strings.Replace(body, `{`, `{"stream":true,`, 1)
+		}
+		rec := &auditRecorder{}
+		eachFramework(t, func(t *testing.T, srv target) {
+			resp := request(t, http.MethodPost, srv.url+"/v1/chat/completions", "", body)
+			_, _ = io.ReadAll(resp.Body)
+			entry := rec.next(t)
+			assert.Equal(t, body, entry.Prompt)
+			assert.Equal(t, "Hello world", entry.Response)
+			assert.Equal(t, http.StatusOK, entry.Status)
+			assert.Empty(t, entry.Token)
+		}, handler.WithAuditLog(rec))
+	}
+}
+
+func TestAuditLogRecordsKeysAndFailures(t *testing.T) {
+	store, open, _, _ := keyStore(t)
+	rec := &auditRecorder{}
+	eachFramework(t, func(t *testing.T, srv target) {
+		resp := request(t, http.MethodPost, srv.url+"/v1/chat/completions", "", askBody("hi"))
+		_, _ = io.ReadAll(resp.Body)
+		entry := rec.next(t)
+		assert.Equal(t, http.StatusUnauthorized, entry.Status, "requests rejected by auth are recorded")
+		assert.Equal(t, "invalid or missing API key", entry.Response)
+
+		resp = request(t, http.MethodPost, srv.url+"/v1/chat/completions", open,
+			`{"model":"midfail","stream":true,"messages":[{"role":"user","content":"hi"}]}`)
+		_, _ = io.ReadAll(resp.Body)
+		entry = rec.next(t)
+		assert.Equal(t, http.StatusInternalServerError, entry.Status)
+		assert.Equal(t, "Hello", entry.Response)
+		assert.Regexp(t, `^acme/key:\d+$`, entry.Token)
+
+		resp = request(t, http.MethodGet, srv.url+"/v1/models", open, "")
+		_, _ = io.ReadAll(resp.Body)
+		entry = rec.next(t)
+		assert.Equal(t, "/v1/models", entry.Prompt)
+		assert.Equal(t, http.StatusOK, entry.Status)
+	}, handler.WithAuth(store), handler.WithAuditLog(rec))
+}
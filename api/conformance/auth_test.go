@@ -0,0 +1,130 @@
+package conformance_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	openai "github.com/openai/openai-go"
+	"github.com/openai/openai-go/option"
+	"github.com/raja.aiml/llm-fast-wrapper/api/handler"
+	"github.com/raja.aiml/llm-fast-wrapper/internal/auth"
+	"github.com/raja.aiml/llm-fast-wrapper/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// keyStore creates a memory store holding an unrestricted key, a key limited
+// to "echo" and a revoked key, and returns their tokens.
+func keyStore(t *testing.T) (store *auth.MemoryStore, open, limited, revoked string) {
+	t.Helper()
+	store = auth.NewMemoryStore()
+	create := func(tenant string, models []string) (string, *auth.Key) {
+		token, key, err := auth.NewKey(tenant, "test", models)
+		require.NoError(t, err)
+		require.NoError(t, store.Create(context.Background(), key))
+		return token, key
+	}
+	open, _ = create("acme", nil)
+	limited, _ = create("globex", []string{"echo"})
+	revoked, key := create("initech", nil)
+	require.NoError(t, store.Revoke(context.Background(), key.ID))
+	return store, open, limited, revoked
+}
+
+func request(t *testing.T, method, url, token, body string) *http.Response {
+	t.Helper()
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+const chatBody = `{"model":"%s","messages":[{"role":"user","content":"hi"}]}`
+
+func TestAuthRejectsMissingAndInvalidKeys(t *testing.T) {
+	store, _, _, revoked := keyStore(t)
+	eachFramework(t, func(t *testing.T, srv target) {
+		for _, token := range []string{"", "lfw-unknown", revoked} {
+			resp := request(t, http.MethodPost, srv.url+"/v1/chat/completions", token, strings.Replace(chatBody, "%s", "echo", 1))
+			assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+			assert.Equal(t, "Bearer", resp.Header.Get("WWW-Authenticate"))
+			assert.Equal(t, "invalid or missing API key", decodeError(t, resp))
+		}
+		resp := request(t, http.MethodGet, srv.url+"/v1/models", "", "")
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+		assert.Nil(t, srv.fake.last)
+	}, handler.WithAuth(store))
+}
+
+func TestAuthAttachesKeyToContext(t *testing.T) {
+	store, open, _, _ := keyStore(t)
+	eachFramework(t, func(t *testing.T, srv target) {
+		client := openai.NewClient(option.WithBaseURL(srv.url+"/v1/"), option.WithAPIKey(open))
+		stream := client.Chat.Completions.NewStreaming(context.Background(), openai.ChatCompletionNewParams{
+			Model:    "echo",
+			Messages: []openai.ChatCompletionMessageParamUnion{openai.UserMessage("hi")},
+		})
+		for stream.Next() {
+		}
+		require.NoError(t, stream.Err())
+		require.NotNil(t, srv.fake.lastKey)
+		assert.Equal(t, "acme", srv.fake.lastKey.Tenant)
+	}, handler.WithAuth(store))
+}
+
+func TestAuthEnforcesAllowedModels(t *testing.T) {
+	store, open, limited, _ := keyStore(t)
+	reg, err := models.NewRegistry([]models.Model{{ID: "echo", Backend: "mock"}, {ID: "other", Backend: "mock"}})
+	require.NoError(t, err)
+	eachFramework(t, func(t *testing.T, srv target) {
+		resp := request(t, http.MethodPost, srv.url+"/v1/chat/completions", limited, strings.Replace(chatBody, "%s", "other", 1))
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		assert.Equal(t, "API key is not allowed to use model 'other'", decodeError(t, resp))
+
+		resp = request(t, http.MethodPost, srv.url+"/v1/chat/completions", limited, strings.Replace(chatBody, "%s", "echo", 1))
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		list := func(token string) []string {
+			resp := request(t, http.MethodGet, srv.url+"/v1/models", token, "")
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+			var body models.List
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+			var ids []string
+			for _, m := range body.Data {
+				ids = append(ids, m.ID)
+			}
+			return ids
+		}
+		assert.Equal(t, []string{"echo"}, list(limited))
+		assert.Equal(t, []string{"echo", "other"}, list(open))
+
+		resp = request(t, http.MethodGet, srv.url+"/v1/models/other", limited, "")
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	}, handler.WithAuth(store), handler.WithModels(reg))
+}
+
+// brokenStore is a key store whose database is unreachable.
+type brokenStore struct{ auth.Store }
+
+func (brokenStore) Lookup(context.Context, string) (*auth.Key, error) {
+	return nil, errors.New(`dial tcp 10.0.0.5:5432: connect: connection refused (user "llm")`)
+}
+
+func TestAuthStoreFailure(t *testing.T) {
+	eachFramework(t, func(t *testing.T, srv target) {
+		resp := request(t, http.MethodPost, srv.url+"/v1/chat/completions", "lfw-any", strings.Replace(chatBody, "%s", "echo", 1))
+		assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+		assert.Equal(t, "API key verification is temporarily unavailable", decodeError(t, resp), "database details stay in the log")
+		assert.Nil(t, srv.fake.last)
+	}, handler.WithAuth(brokenStore{}))
+}
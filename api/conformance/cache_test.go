@@ -0,0 +1,95 @@
+package conformance_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/raja.aiml/llm-fast-wrapper/api/handler"
+	"github.com/raja.aiml/llm-fast-wrapper/internal/llm"
+	"github.com/raja.aiml/llm-fast-wrapper/internal/responsecache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func withCache() []handler.Option {
+	return []handler.Option{handler.WithResponseCache(responsecache.New(responsecache.NewMemoryStore(0), time.Minute))}
+}
+
+func TestResponseCacheReplaysSSE(t *testing.T) {
+	const body = `{"model":"echo","stream":true,"messages":[{"role":"user","content":"hi"}]}`
+	eachFrameworkFresh(t, withCache, func(t *testing.T, srv target) {
+		miss := post(t, context.Background(), srv.url, body)
+		require.Equal(t, http.StatusOK, miss.StatusCode)
+		assert.Equal(t, "MISS", miss.Header.Get("X-Cache"))
+		first, err := io.ReadAll(miss.Body)
+		require.NoError(t, err)
+
+		var hit *http.Response
+		require.Eventually(t, func() bool {
+			srv.fake.last = nil
+			hit = post(t, context.Background(), srv.url, body)
+			return hit.Header.Get("X-Cache") == "HIT"
+		}, time.Second, 10*time.Millisecond)
+		assert.Equal(t, "text/event-stream", hit.Header.Get("Content-Type"))
+		replayed, err := io.ReadAll(hit.Body)
+		require.NoError(t, err)
+		assert.Equal(t, string(first), string(replayed))
+		assert.Nil(t, srv.fake.last, "hit does not reach the upstream")
+
+		// the same request without streaming is served from the same entry
+		plain := post(t, context.Background(), srv.url, strings.Replace(body, `"stream":true,`, "", 1))
+		assert.Equal(t, "HIT", plain.Header.Get("X-Cache"))
+		var completion llm.ChatCompletion
+		require.NoError(t, json.NewDecoder(plain.Body).Decode(&completion))
+		assert.Equal(t, "Hello world", completion.Choices[0].Message.Content)
+	})
+}
+
+func TestResponseCacheKeyIncludesParams(t *testing.T) {
+	eachFrameworkFresh(t, withCache, func(t *testing.T, srv target) {
+		resp := post(t, context.Background(), srv.url, `{"model":"echo","messages":[{"role":"user","content":"hi"}]}`)
+		assert.Equal(t, "MISS", resp.Header.Get("X-Cache"))
+		time.Sleep(20 * time.Millisecond)
+		resp = post(t, context.Background(), srv.url, `{"model":"echo","temperature":0.3,"messages":[{"role":"user","content":"hi"}]}`)
+		assert.Equal(t, "MISS", resp.Header.Get("X-Cache"))
+	})
+}
+
+func TestResponseCacheSkipsFailures(t *testing.T) {
+	const body = `{"model":"midfail","stream":true,"messages":[{"role":"user","content":"hi"}]}`
+	eachFrameworkFresh(t, withCache, func(t *testing.T, srv target) {
+		resp := post(t, context.Background(), srv.url, body)
+		_, _ = io.ReadAll(resp.Body)
+		time.Sleep(20 * time.Millisecond)
+		resp = post(t, context.Background(), srv.url, body)
+		assert.Equal(t, "MISS", resp.Header.Get("X-Cache"))
+	})
+}
+
+func TestResponseCacheControl(t *testing.T) {
+	const body = `{"model":"echo","messages":[{"role":"user","content":"hi"}]}`
+	eachFrameworkFresh(t, withCache, func(t *testing.T, srv target) {
+		send := func(cacheControl string) *http.Response {
+			req, err := http.NewRequest(http.MethodPost, srv.url+"/v1/chat/completions", strings.NewReader(body))
+			require.NoError(t, err)
+			req.Header.Set("Content-Type", "application/json")
+			if cacheControl != "" {
+				req.Header.Set("Cache-Control", cacheControl)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			t.Cleanup(func() { resp.Body.Close() })
+			return resp
+		}
+		send("no-store")
+		time.Sleep(20 * time.Millisecond)
+		assert.Equal(t, "MISS", send("").Header.Get("X-Cache"), "no-store kept the first response out")
+		require.Eventually(t, func() bool { return send("").Header.Get("X-Cache") == "HIT" }, time.Second, 10*time.Millisecond)
+		assert.Empty(t, send("no-cache").Header.Get("X-Cache"), "no-cache bypasses the lookup")
+	})
+}
@@ -0,0 +1,91 @@
+package conformance_test
+
+import (
+	"context"
+	"testing"
+
+	openai "github.com/openai/openai-go"
+	"github.com/openai/openai-go/option"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var wantReasons = []string{"stop", "length", "stop"}
+
+func TestChoices_Chat(t *testing.T) {
+	eachFramework(t, func(t *testing.T, srv target) {
+		client := openai.NewClient(option.WithBaseURL(srv.url+"/v1/"), option.WithAPIKey("test"))
+		params := openai.ChatCompletionNewParams{
+			Model:    "choices",
+			Messages: []openai.ChatCompletionMessageParamUnion{openai.UserMessage("hi")},
+			N:        openai.Int(3),
+		}
+
+		completion, err := client.Chat.Completions.New(context.Background(), params)
+		require.NoError(t, err)
+		require.Len(t, completion.Choices, 3)
+		for i, c := range completion.Choices {
+			assert.Equal(t, int64(i), c.Index)
+			assert.Equal(t, "Hello world", c.Message.Content)
+			assert.Equal(t, wantReasons[i], c.FinishReason)
+		}
+		srv.fake.mu.Lock()
+		n := srv.fake.last.N
+		srv.fake.mu.Unlock()
+		require.NotNil(t, n, "n is forwarded upstream")
+		assert.Equal(t, int64(3), *n)
+
+		stream := client.Chat.Completions.NewStreaming(context.Background(), params)
+		var acc openai.ChatCompletionAccumulator
+		indexes := make(map[int64]bool)
+		for stream.Next() {
+			chunk := stream.Current()
+			for _, c := range chunk.Choices {
+				indexes[c.Index] = true
+			}
+			acc.AddChunk(chunk)
+		}
+		require.NoError(t, stream.Err())
+		assert.Len(t, indexes, 3)
+		require.Len(t, acc.Choices, 3)
+		for i, c := range acc.Choices {
+			assert.Equal(t, "Hello world", c.Message.Content)
+			assert.Equal(t, wantReasons[i], c.FinishReason)
+		}
+	})
+}
+
+func TestChoices_Completions(t *testing.T) {
+	eachFramework(t, func(t *testing.T, srv target) {
+		client := openai.NewClient(option.WithBaseURL(srv.url+"/v1/"), option.WithAPIKey("test"))
+		params := openai.CompletionNewParams{
+			Model:  "choices",
+			Prompt: openai.CompletionNewParamsPromptUnion{OfString: openai.String("Say hi")},
+			N:      openai.Int(2),
+		}
+
+		resp, err := client.Completions.New(context.Background(), params)
+		require.NoError(t, err)
+		require.Len(t, resp.Choices, 2)
+		for i, c := range resp.Choices {
+			assert.Equal(t, int64(i), c.Index)
+			assert.Equal(t, "Hello world", c.Text)
+			assert.Equal(t, wantReasons[i], string(c.FinishReason))
+		}
+
+		stream := client.Completions.NewStreaming(context.Background(), params)
+		texts := make(map[int64]string)
+		finish := make(map[int64]string)
+		for stream.Next() {
+			for _, c := range stream.Current().Choices {
+				texts[c.Index] += c.Text
+				if c.FinishReason != "" {
+					finish[c.Index] = string(c.FinishReason)
+				}
+			}
+		}
+		require.NoError(t, stream.Err())
+		assert.Equal(t, map[int64]string{0: "Hello world", 1: "Hello world"}, texts)
+		assert.Equal(t, map[int64]string{0: "stop", 1: "length"}, finish)
+	})
+}
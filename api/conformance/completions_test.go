@@ -0,0 +1,107 @@
+package conformance_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	openai "github.com/openai/openai-go"
+	"github.com/openai/openai-go/option"
+	"github.com/raja.aiml/llm-fast-wrapper/internal/llm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompletions_OpenAIClient(t *testing.T) {
+	eachFramework(t, func(t *testing.T, srv target) {
+		client := openai.NewClient(option.WithBaseURL(srv.url+"/v1/"), option.WithAPIKey("test"))
+		params := openai.CompletionNewParams{
+			Model:  "echo",
+			Prompt: openai.CompletionNewParamsPromptUnion{OfString: openai.String("Say hi")},
+		}
+
+		resp, err := client.Completions.New(context.Background(), params)
+		require.NoError(t, err)
+		assert.Equal(t, "text_completion", string(resp.Object))
+		require.Len(t, resp.Choices, 1)
+		assert.Equal(t, "Hello world", resp.Choices[0].Text)
+		assert.Equal(t, "stop", string(resp.Choices[0].FinishReason))
+		assert.Positive(t, resp.Usage.TotalTokens)
+
+		// the prompt goes upstream as a user message with the legacy
+		// max_tokens default
+		srv.fake.mu.Lock()
+		last := srv.fake.last
+		srv.fake.mu.Unlock()
+		assert.Equal(t, []llm.Message{{Role: "user", Content: "Say hi"}}, last.Messages)
+		require.NotNil(t, last.MaxTokens)
+		assert.Equal(t, int64(llm.DefaultTextMaxTokens), *last.MaxTokens)
+
+		stream := client.Completions.NewStreaming(context.Background(), params)
+		var text strings.Builder
+		var finish string
+		for stream.Next() {
+			chunk := stream.Current()
+			assert.Equal(t, "text_completion", string(chunk.Object))
+			for _, choice := range chunk.Choices {
+				text.WriteString(choice.Text)
+				if choice.FinishReason != "" {
+					finish = string(choice.FinishReason)
+				}
+			}
+		}
+		require.NoError(t, stream.Err())
+		assert.Equal(t, "Hello world", text.String())
+		assert.Equal(t, "stop", finish)
+	})
+}
+
+func TestCompletions_EchoAndStop(t *testing.T) {
+	eachFramework(t, func(t *testing.T, srv target) {
+		resp := request(t, http.MethodPost, srv.url+"/v1/completions", "",
+			`{"model":"echo","prompt":["Say hi. "],"echo":true,"stop":"wor","max_tokens":5}`)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var completion llm.TextCompletion
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&completion))
+		require.Len(t, completion.Choices, 1)
+		assert.Equal(t, "Say hi. Hello ", completion.Choices[0].Text)
+		assert.Equal(t, "stop", *completion.Choices[0].FinishReason)
+		srv.fake.mu.Lock()
+		assert.Equal(t, int64(5), *srv.fake.last.MaxTokens)
+		assert.Equal(t, llm.StopSequences{"wor"}, srv.fake.last.Stop)
+		srv.fake.mu.Unlock()
+
+		resp = request(t, http.MethodPost, srv.url+"/v1/completions", "",
+			`{"model":"echo","prompt":"Say hi. ","echo":true,"stop":["wor"],"stream":true}`)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		events := strings.Split(strings.TrimSpace(string(body)), "\n\n")
+		require.Equal(t, "data: [DONE]", events[len(events)-1])
+		var text strings.Builder
+		for _, event := range events[:len(events)-1] {
+			var chunk llm.TextCompletion
+			require.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(event, "data: ")), &chunk))
+			assert.Equal(t, "text_completion", chunk.Object)
+			for _, choice := range chunk.Choices {
+				text.WriteString(choice.Text)
+			}
+		}
+		assert.Equal(t, "Say hi. Hello ", text.String())
+	})
+}
+
+func TestCompletions_Errors(t *testing.T) {
+	eachFramework(t, func(t *testing.T, srv target) {
+		resp := request(t, http.MethodPost, srv.url+"/v1/completions", "", `{"model":"echo","prompt":["a","b"]}`)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		assert.Contains(t, decodeError(t, resp), "exactly one")
+
+		resp = request(t, http.MethodPost, srv.url+"/v1/completions", "", `{"model":"unrouted","prompt":"hi"}`)
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+}
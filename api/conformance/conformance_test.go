@@ -0,0 +1,422 @@
+package conformance_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	openai "github.com/openai/openai-go"
+	"github.com/openai/openai-go/option"
+	fiberapi "github.com/raja.aiml/llm-fast-wrapper/api/fiber"
+	ginapi "github.com/raja.aiml/llm-fast-wrapper/api/gin"
+	"github.com/raja.aiml/llm-fast-wrapper/api/handler"
+	"github.com/raja.aiml/llm-fast-wrapper/internal/auth"
+	"github.com/raja.aiml/llm-fast-wrapper/internal/llm"
+	"github.com/raja.aiml/llm-fast-wrapper/internal/routing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeStreamer scripts upstream behaviour by model name.
+type fakeStreamer struct {
+	canceled   chan struct{}
+	cancelOnce sync.Once
+	mu         sync.Mutex
+	last       *llm.ChatCompletionRequest
+	lastKey    *auth.Key
+}
+
+// markCanceled closes canceled once the upstream context has ended.
+func (f *fakeStreamer) markCanceled() { f.cancelOnce.Do(func() { close(f.canceled) }) }
+
+func newFakeStreamer() *fakeStreamer {
+	return &fakeStreamer{canceled: make(chan struct{})}
+}
+
+func scriptedChunks() []llm.ChatCompletionChunk {
+	stop := "stop"
+	base := llm.ChatCompletionChunk{ID: "chatcmpl-test", Object: "chat.completion.chunk", Created: 1, Model: "echo"}
+	first, second, last := base, base, base
+	first.Choices = []llm.ChatCompletionChoice{{Delta: llm.Delta{Content: "Hello"}}}
+	second.Choices = []llm.ChatCompletionChoice{{Delta: llm.Delta{Content: " world"}}}
+	last.Choices = []llm.ChatCompletionChoice{{FinishReason: &stop}}
+	return []llm.ChatCompletionChunk{first, second, last}
+}
+
+func (f *fakeStreamer) Stream(ctx context.Context, req *llm.ChatCompletionRequest) (<-chan llm.ChatCompletionChunk, error) {
+	f.mu.Lock()
+	f.last = req
+	f.lastKey, _ = auth.FromContext(ctx)
+	f.mu.Unlock()
+	switch req.Model {
+	case "fail":
+		return nil, fmt.Errorf("backend \"default\": %w", &url.Error{
+			Op: "Post", URL: "http://upstream.internal/v1/chat/completions",
+			Err: errors.New("dial tcp 10.0.0.7:443: connect: connection refused"),
+		})
+	case "tripped":
+		return nil, fmt.Errorf("backend \"default\": %w", routing.ErrCircuitOpen)
+	case "unrouted":
+		return nil, fmt.Errorf("%w: no route", llm.ErrModelNotFound)
+	case "rejected":
+		return nil, upstreamAPIError(http.StatusBadRequest, "context_length_exceeded", "messages", "too many tokens")
+	case "overloaded":
+		return nil, upstreamAPIError(http.StatusServiceUnavailable, "", "", "engine overloaded")
+	case "slow":
+		ch := make(chan llm.ChatCompletionChunk)
+		go func() {
+			defer close(ch)
+			defer f.markCanceled()
+			chunk := scriptedChunks()[0]
+			for {
+				select {
+				case ch <- chunk:
+				case <-ctx.Done():
+					return
+				}
+				select {
+				case <-time.After(10 * time.Millisecond):
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+		return ch, nil
+	case "paced":
+		ch := make(chan llm.ChatCompletionChunk)
+		go func() {
+			defer close(ch)
+			for _, chunk := range scriptedChunks() {
+				select {
+				case <-time.After(50 * time.Millisecond):
+				case <-ctx.Done():
+					return
+				}
+				select {
+				case ch <- chunk:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+		return ch, nil
+	case "hang":
+		// never starts, like a router waiting for a first token
+		<-ctx.Done()
+		f.markCanceled()
+		return nil, ctx.Err()
+	case "slowstart":
+		select {
+		case <-time.After(100 * time.Millisecond):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		return replayChunks(scriptedChunks()), nil
+	case "stall":
+		// sends one chunk, then nothing until canceled
+		ch := make(chan llm.ChatCompletionChunk, 1)
+		ch <- scriptedChunks()[0]
+		go func() {
+			defer close(ch)
+			<-ctx.Done()
+			f.markCanceled()
+		}()
+		return ch, nil
+	case "tools":
+		return replayChunks(toolCallChunks()), nil
+	case "metered":
+		return replayChunks(append(scriptedChunks(), upstreamUsageChunk())), nil
+	case "choices":
+		return replayChunks(choiceChunks(req.Choices())), nil
+	case "midfail":
+		ch := make(chan llm.ChatCompletionChunk, 2)
+		ch <- scriptedChunks()[0]
+		ch <- llm.ChatCompletionChunk{Err: errors.New("connection reset")}
+		close(ch)
+		return ch, nil
+	default:
+		return replayChunks(scriptedChunks()), nil
+	}
+}
+
+// upstreamAPIError is the error openai-go returns for an upstream that
+// answered with status.
+func upstreamAPIError(status int, code, param, message string) error {
+	return fmt.Errorf("backend \"default\": %w", &openai.Error{
+		StatusCode: status,
+		Code:       code,
+		Param:      param,
+		Message:    message,
+		Type:       "invalid_request_error",
+		Request:    httptest.NewRequest(http.MethodPost, "http://upstream.internal/v1/chat/completions", nil),
+		Response:   &http.Response{StatusCode: status},
+	})
+}
+
+// upstreamUsage is what the "metered" model reports in its final chunk.
+var upstreamUsage = llm.Usage{PromptTokens: 11, CompletionTokens: 2, TotalTokens: 13}
+
+// upstreamUsageChunk is the usage-only chunk an upstream sends last.
+func upstreamUsageChunk() llm.ChatCompletionChunk {
+	usage := upstreamUsage
+	return llm.ChatCompletionChunk{ID: "chatcmpl-test", Object: "chat.completion.chunk", Created: 1, Model: "echo",
+		Choices: []llm.ChatCompletionChoice{}, Usage: &usage}
+}
+
+// choiceChunks interleaves the scripted chunks of n choices, as an upstream
+// honouring n does. Odd choices finish with "length".
+func choiceChunks(n int) []llm.ChatCompletionChunk {
+	var chunks []llm.ChatCompletionChunk
+	for _, c := range scriptedChunks() {
+		scripted := c.Choices[0]
+		for i := range n {
+			choice := scripted
+			choice.Index = i
+			if choice.FinishReason != nil && i%2 == 1 {
+				length := "length"
+				choice.FinishReason = &length
+			}
+			c.Choices = []llm.ChatCompletionChoice{choice}
+			chunks = append(chunks, c)
+		}
+	}
+	return chunks
+}
+
+// toolCallChunks streams a get_weather call whose arguments arrive in two
+// fragments.
+func toolCallChunks() []llm.ChatCompletionChunk {
+	reason, index := "tool_calls", 0
+	base := llm.ChatCompletionChunk{ID: "chatcmpl-tools", Object: "chat.completion.chunk", Created: 1, Model: "tools"}
+	first, second, last := base, base, base
+	first.Choices = []llm.ChatCompletionChoice{{Delta: llm.Delta{Role: "assistant", ToolCalls: []llm.ToolCall{{
+		Index: &index, ID: "call_1", Type: "function", Function: llm.FunctionCall{Name: "get_weather"},
+	}}}}}
+	second.Choices = []llm.ChatCompletionChoice{{Delta: llm.Delta{ToolCalls: []llm.ToolCall{{
+		Index: &index, Function: llm.FunctionCall{Arguments: `{"city":`},
+	}}}}}
+	last.Choices = []llm.ChatCompletionChoice{{Delta: llm.Delta{ToolCalls: []llm.ToolCall{{
+		Index: &index, Function: llm.FunctionCall{Arguments: `"Paris"}`},
+	}}}, FinishReason: &reason}}
+	return []llm.ChatCompletionChunk{first, second, last}
+}
+
+func replayChunks(chunks []llm.ChatCompletionChunk) <-chan llm.ChatCompletionChunk {
+	ch := make(chan llm.ChatCompletionChunk, len(chunks))
+	for _, c := range chunks {
+		ch <- c
+	}
+	close(ch)
+	return ch
+}
+
+type target struct {
+	name string
+	url  string
+	fake *fakeStreamer
+}
+
+// upstream builds the streamer a test server hands requests to from the
+// server's fakeStreamer.
+type upstream func(fake *fakeStreamer) llm.Streamer
+
+// direct hands requests straight to the fakeStreamer.
+func direct(fake *fakeStreamer) llm.Streamer { return fake }
+
+func startGin(t *testing.T, up upstream, opts ...handler.Option) target {
+	fake := newFakeStreamer()
+	r, err := ginapi.NewRouter(handler.New(up(fake), opts...))
+	require.NoError(t, err)
+	srv := httptest.NewServer(r)
+	t.Cleanup(srv.Close)
+	return target{name: "gin", url: srv.URL, fake: fake}
+}
+
+func startFiber(t *testing.T, up upstream, opts ...handler.Option) target {
+	fake := newFakeStreamer()
+	app := fiberapi.NewApp(handler.New(up(fake), opts...), fiber.Config{DisableStartupMessage: true})
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go func() { _ = app.Listener(ln) }()
+	t.Cleanup(func() { _ = app.ShutdownWithTimeout(time.Second) })
+	return target{name: "fiber", url: "http://" + ln.Addr().String(), fake: fake}
+}
+
+// eachFramework runs fn as a subtest against a fresh Gin and Fiber server
+// whose handler is built with opts.
+func eachFramework(t *testing.T, fn func(t *testing.T, srv target), opts ...handler.Option) {
+	eachFrameworkWith(t, direct, fn, opts...)
+}
+
+// eachFrameworkWith is eachFramework for servers whose requests go through
+// up, e.g. a router in front of the fakeStreamer.
+func eachFrameworkWith(t *testing.T, up upstream, fn func(t *testing.T, srv target), opts ...handler.Option) {
+	for _, start := range []func(*testing.T, upstream, ...handler.Option) target{startGin, startFiber} {
+		srv := start(t, up, opts...)
+		t.Run(srv.name, func(t *testing.T) { fn(t, srv) })
+	}
+}
+
+// eachFrameworkFresh is eachFramework for stateful options (e.g. a rate
+// limiter): newOpts is called once per server so they share no state.
+func eachFrameworkFresh(t *testing.T, newOpts func() []handler.Option, fn func(t *testing.T, srv target)) {
+	for _, start := range []func(*testing.T, upstream, ...handler.Option) target{startGin, startFiber} {
+		srv := start(t, direct, newOpts()...)
+		t.Run(srv.name, func(t *testing.T) { fn(t, srv) })
+	}
+}
+
+func post(t *testing.T, ctx context.Context, url, body string) *http.Response {
+	t.Helper()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url+"/v1/chat/completions", strings.NewReader(body))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+func decodeError(t *testing.T, resp *http.Response) string {
+	t.Helper()
+	assert.Contains(t, resp.Header.Get("Content-Type"), "application/json")
+	var body struct {
+		Error struct {
+			Message string `json:"message"`
+			Type    string `json:"type"`
+		} `json:"error"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	assert.NotEmpty(t, body.Error.Type)
+	return body.Error.Message
+}
+
+func TestStreamingFraming(t *testing.T) {
+	var want strings.Builder
+	for _, c := range scriptedChunks() {
+		data, err := json.Marshal(c)
+		require.NoError(t, err)
+		want.WriteString("data: " + string(data) + "\n\n")
+	}
+	want.WriteString("data: [DONE]\n\n")
+
+	eachFramework(t, func(t *testing.T, srv target) {
+		resp := post(t, context.Background(), srv.url, `{"model":"echo","stream":true,"messages":[{"role":"user","content":"hi"}]}`)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		assert.Equal(t, want.String(), string(body))
+	})
+}
+
+func TestStreamingWithSDK(t *testing.T) {
+	eachFramework(t, func(t *testing.T, srv target) {
+		client := openai.NewClient(option.WithBaseURL(srv.url+"/v1/"), option.WithAPIKey("test"))
+		stream := client.Chat.Completions.NewStreaming(context.Background(), openai.ChatCompletionNewParams{
+			Model:    "echo",
+			Messages: []openai.ChatCompletionMessageParamUnion{openai.UserMessage("hi")},
+		})
+		var acc openai.ChatCompletionAccumulator
+		for stream.Next() {
+			acc.AddChunk(stream.Current())
+		}
+		require.NoError(t, stream.Err())
+		require.Len(t, acc.Choices, 1)
+		assert.Equal(t, "Hello world", acc.Choices[0].Message.Content)
+		assert.Equal(t, "stop", acc.Choices[0].FinishReason)
+	})
+}
+
+func TestNonStreamingWithSDK(t *testing.T) {
+	eachFramework(t, func(t *testing.T, srv target) {
+		client := openai.NewClient(option.WithBaseURL(srv.url+"/v1/"), option.WithAPIKey("test"))
+		resp, err := client.Chat.Completions.New(context.Background(), openai.ChatCompletionNewParams{
+			Model: "echo",
+			Messages: []openai.ChatCompletionMessageParamUnion{
+				openai.SystemMessage("be terse"),
+				openai.UserMessage("hi"),
+			},
+			Temperature: openai.Float(0.5),
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "chat.completion", string(resp.Object))
+		require.Len(t, resp.Choices, 1)
+		assert.Equal(t, "Hello world", resp.Choices[0].Message.Content)
+		assert.Equal(t, "stop", resp.Choices[0].FinishReason)
+		assert.Positive(t, resp.Usage.TotalTokens)
+
+		require.NotNil(t, srv.fake.last)
+		assert.Equal(t, []llm.Message{{Role: "system", Content: "be terse"}, {Role: "user", Content: "hi"}}, srv.fake.last.Messages)
+		require.NotNil(t, srv.fake.last.Temperature)
+		assert.Equal(t, 0.5, *srv.fake.last.Temperature)
+	})
+}
+
+func TestUpstreamError(t *testing.T) {
+	eachFramework(t, func(t *testing.T, srv target) {
+		resp := post(t, context.Background(), srv.url, `{"model":"fail","stream":true,"messages":[{"role":"user","content":"hi"}]}`)
+		assert.Equal(t, http.StatusBadGateway, resp.StatusCode)
+		e := decodeEnvelope(t, resp)
+		assert.Equal(t, "upstream request failed", e.Error.Message, "hosts stay in the log")
+		require.NotNil(t, e.Error.Code)
+		assert.Equal(t, "upstream_error", *e.Error.Code)
+
+		resp = post(t, context.Background(), srv.url, `{"model":"tripped","stream":true,"messages":[{"role":"user","content":"hi"}]}`)
+		assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+		assert.Equal(t, "upstream temporarily unavailable, retry later", decodeError(t, resp))
+	})
+}
+
+func TestMidStreamErrorEndsStream(t *testing.T) {
+	eachFramework(t, func(t *testing.T, srv target) {
+		resp := post(t, context.Background(), srv.url, `{"model":"midfail","stream":true,"messages":[{"role":"user","content":"hi"}]}`)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		assert.Contains(t, string(body), `"content":"Hello"`)
+		assert.NotContains(t, string(body), "[DONE]")
+		message, _ := lastEventError(t, string(body))
+		assert.Equal(t, "connection reset", message)
+	})
+}
+
+func TestBadJSON(t *testing.T) {
+	eachFramework(t, func(t *testing.T, srv target) {
+		resp := post(t, context.Background(), srv.url, `{"model":`)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		assert.NotEmpty(t, decodeError(t, resp))
+	})
+}
+
+func TestClientCancellation(t *testing.T) {
+	eachFramework(t, func(t *testing.T, srv target) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		resp := post(t, ctx, srv.url, `{"model":"slow","stream":true,"messages":[{"role":"user","content":"hi"}]}`)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		line, err := bufio.NewReader(resp.Body).ReadString('\n')
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(line, "data: "))
+
+		cancel()
+		select {
+		case <-srv.fake.canceled:
+		case <-time.After(5 * time.Second):
+			t.Fatal("upstream context was not canceled after client disconnect")
+		}
+	})
+}
@@ -0,0 +1,5 @@
+// Package conformance runs the same HTTP scenarios against the Gin and
+// Fiber servers to keep both adapters of the shared handler core in lockstep.
+//
+//	go test ./api/conformance
+package conformance
@@ -0,0 +1,130 @@
+package conformance_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	openai "github.com/openai/openai-go"
+	"github.com/openai/openai-go/option"
+	"github.com/raja.aiml/llm-fast-wrapper/api/handler"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeEmbedder maps each text to [len(text), 1] with embeddingModel and
+// records every batch.
+type fakeEmbedder struct {
+	mu      sync.Mutex
+	batches [][]string
+}
+
+const embeddingModel = "text-embedding-3-small"
+
+func (f *fakeEmbedder) Model() string { return embeddingModel }
+
+func (f *fakeEmbedder) GetBatch(_ context.Context, texts []string) (map[string][]float32, error) {
+	f.mu.Lock()
+	f.batches = append(f.batches, texts)
+	f.mu.Unlock()
+	out := make(map[string][]float32, len(texts))
+	for _, text := range texts {
+		switch text {
+		case "fail":
+			return nil, errors.New("provider down")
+		case "too long":
+			return nil, upstreamAPIError(http.StatusBadRequest, "", "input", "input is too long")
+		}
+		if text == "skip" {
+			continue
+		}
+		out[text] = []float32{float32(len(text)), 1}
+	}
+	return out, nil
+}
+
+func postEmbeddings(t *testing.T, url, body string) *http.Response {
+	t.Helper()
+	resp, err := http.Post(url+"/v1/embeddings", "application/json", strings.NewReader(body))
+	require.NoError(t, err)
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+func TestEmbeddingsWithSDK(t *testing.T) {
+	fake := &fakeEmbedder{}
+	eachFramework(t, func(t *testing.T, srv target) {
+		client := openai.NewClient(option.WithBaseURL(srv.url+"/v1/"), option.WithAPIKey("test"))
+
+		single, err := client.Embeddings.New(context.Background(), openai.EmbeddingNewParams{
+			Model: "text-embedding-3-small",
+			Input: openai.EmbeddingNewParamsInputUnion{OfString: openai.String("hi")},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "list", string(single.Object))
+		assert.Equal(t, "text-embedding-3-small", single.Model)
+		require.Len(t, single.Data, 1)
+		assert.Equal(t, []float64{2, 1}, single.Data[0].Embedding)
+		assert.Positive(t, single.Usage.PromptTokens)
+
+		batch, err := client.Embeddings.New(context.Background(), openai.EmbeddingNewParams{
+			Model: "text-embedding-3-small",
+			Input: openai.EmbeddingNewParamsInputUnion{OfArrayOfStrings: []string{"a", "abc"}},
+		})
+		require.NoError(t, err)
+		require.Len(t, batch.Data, 2)
+		assert.Equal(t, int64(1), batch.Data[1].Index)
+		assert.Equal(t, []float64{3, 1}, batch.Data[1].Embedding)
+	}, handler.WithEmbeddings(fake))
+	assert.Contains(t, fake.batches, []string{"a", "abc"}, "inputs are embedded in one batch")
+}
+
+func TestEmbeddingsBase64(t *testing.T) {
+	eachFramework(t, func(t *testing.T, srv target) {
+		resp := postEmbeddings(t, srv.url, `{"input":"hi","encoding_format":"base64"}`)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var body struct {
+			Data []struct {
+				Embedding string `json:"embedding"`
+			} `json:"data"`
+		}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		require.Len(t, body.Data, 1)
+		// float32 2.0 and 1.0, little endian
+		assert.Equal(t, "AAAAQAAAgD8=", body.Data[0].Embedding)
+	}, handler.WithEmbeddings(&fakeEmbedder{}))
+}
+
+func TestEmbeddingsErrors(t *testing.T) {
+	eachFramework(t, func(t *testing.T, srv target) {
+		cases := []struct {
+			body   string
+			status int
+			msg    string
+		}{
+			{`{"model":"m","input":[]}`, http.StatusBadRequest, "input must not be empty"},
+			{`{"model":"m","input":[1,2]}`, http.StatusBadRequest, "input must be a string or an array of strings"},
+			{`{"model":"m","input":"hi","encoding_format":"int8"}`, http.StatusBadRequest, `unsupported encoding_format "int8"`},
+			{`{"model":"text-embedding-ada-002","input":"hi"}`, http.StatusBadRequest, "this server embeds with model 'text-embedding-3-small', not 'text-embedding-ada-002'"},
+			{`{"input":"fail"}`, http.StatusInternalServerError, "provider down"},
+			{`{"input":"too long"}`, http.StatusBadRequest, "input is too long"},
+			{`{"input":["ok","skip"]}`, http.StatusInternalServerError, "no embedding returned for input 1"},
+		}
+		for _, tc := range cases {
+			resp := postEmbeddings(t, srv.url, tc.body)
+			assert.Equal(t, tc.status, resp.StatusCode, tc.body)
+			assert.Equal(t, tc.msg, decodeError(t, resp), tc.body)
+		}
+	}, handler.WithEmbeddings(&fakeEmbedder{}))
+}
+
+func TestEmbeddingsNotConfigured(t *testing.T) {
+	eachFramework(t, func(t *testing.T, srv target) {
+		resp := postEmbeddings(t, srv.url, `{"model":"m","input":"hi"}`)
+		assert.Equal(t, http.StatusNotImplemented, resp.StatusCode)
+	})
+}
@@ -0,0 +1,111 @@
+package conformance_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	openai "github.com/openai/openai-go"
+	"github.com/openai/openai-go/option"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// envelope decodes an OpenAI error body with every field.
+type envelope struct {
+	Error struct {
+		Message string  `json:"message"`
+		Type    string  `json:"type"`
+		Param   *string `json:"param"`
+		Code    *string `json:"code"`
+	} `json:"error"`
+}
+
+func decodeEnvelope(t *testing.T, resp *http.Response) envelope {
+	t.Helper()
+	assert.Contains(t, resp.Header.Get("Content-Type"), "application/json")
+	var e envelope
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&e))
+	return e
+}
+
+func TestErrorEnvelope_Fields(t *testing.T) {
+	eachFramework(t, func(t *testing.T, srv target) {
+		resp := post(t, context.Background(), srv.url, `{"model":`)
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		e := decodeEnvelope(t, resp)
+		assert.Equal(t, "invalid_request_error", e.Error.Type)
+		assert.NotEmpty(t, e.Error.Message)
+		// unset param and code are null, not missing
+		assert.Nil(t, e.Error.Param)
+		assert.Nil(t, e.Error.Code)
+	})
+}
+
+func TestErrorEnvelope_Routes(t *testing.T) {
+	eachFramework(t, func(t *testing.T, srv target) {
+		resp := request(t, http.MethodGet, srv.url+"/v1/nope", "", "")
+		require.Equal(t, http.StatusNotFound, resp.StatusCode)
+		e := decodeEnvelope(t, resp)
+		assert.Equal(t, "Invalid URL (/v1/nope)", e.Error.Message)
+		require.NotNil(t, e.Error.Code)
+		assert.Equal(t, "unknown_url", *e.Error.Code)
+
+		resp = request(t, http.MethodGet, srv.url+"/v1/chat/completions", "", "")
+		require.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+		assert.Equal(t, "invalid_request_error", decodeEnvelope(t, resp).Error.Type)
+	})
+}
+
+func TestErrorEnvelope_Upstream(t *testing.T) {
+	eachFramework(t, func(t *testing.T, srv target) {
+		// the client's fault: status, param and code pass through
+		resp := post(t, context.Background(), srv.url, fmt.Sprintf(chatBody, "rejected"))
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		e := decodeEnvelope(t, resp)
+		assert.Equal(t, "too many tokens", e.Error.Message)
+		require.NotNil(t, e.Error.Param)
+		assert.Equal(t, "messages", *e.Error.Param)
+		require.NotNil(t, e.Error.Code)
+		assert.Equal(t, "context_length_exceeded", *e.Error.Code)
+
+		// the upstream's fault: a bad gateway that does not leak its URL
+		resp = post(t, context.Background(), srv.url, fmt.Sprintf(chatBody, "overloaded"))
+		require.Equal(t, http.StatusBadGateway, resp.StatusCode)
+		e = decodeEnvelope(t, resp)
+		assert.Equal(t, "server_error", e.Error.Type)
+		assert.Contains(t, e.Error.Message, "engine overloaded")
+		assert.NotContains(t, e.Error.Message, "upstream.internal")
+	})
+}
+
+func TestErrorEnvelope_SDK(t *testing.T) {
+	eachFramework(t, func(t *testing.T, srv target) {
+		client := openai.NewClient(option.WithBaseURL(srv.url+"/v1/"), option.WithAPIKey("test"), option.WithMaxRetries(0))
+		_, err := client.Chat.Completions.New(context.Background(), openai.ChatCompletionNewParams{
+			Model:    "rejected",
+			Messages: []openai.ChatCompletionMessageParamUnion{openai.UserMessage("hi")},
+		})
+		var apiErr *openai.Error
+		require.True(t, errors.As(err, &apiErr), "%v", err)
+		assert.Equal(t, http.StatusBadRequest, apiErr.StatusCode)
+		assert.Equal(t, "context_length_exceeded", apiErr.Code)
+		assert.Equal(t, "messages", apiErr.Param)
+
+		// a failure after the stream started surfaces as a stream error
+		stream := client.Chat.Completions.NewStreaming(context.Background(), openai.ChatCompletionNewParams{
+			Model:    "midfail",
+			Messages: []openai.ChatCompletionMessageParamUnion{openai.UserMessage("hi")},
+		})
+		chunks := 0
+		for stream.Next() {
+			chunks++
+		}
+		assert.Equal(t, 1, chunks)
+		require.Error(t, stream.Err())
+		assert.Contains(t, stream.Err().Error(), "connection reset")
+	})
+}
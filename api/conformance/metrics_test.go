@@ -0,0 +1,30 @@
+package conformance_test
+
+import (
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/raja.aiml/llm-fast-wrapper/api/handler"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetrics(t *testing.T) {
+	store, open, _, _ := keyStore(t)
+	eachFramework(t, func(t *testing.T, srv target) {
+		resp := request(t, http.MethodPost, srv.url+"/v1/chat/completions", open,
+			`{"model":"echo","stream":true,"messages":[{"role":"user","content":"hi"}]}`)
+		_, _ = io.ReadAll(resp.Body)
+
+		resp = request(t, http.MethodGet, srv.url+"/metrics", "", "")
+		require.Equal(t, http.StatusOK, resp.StatusCode, "scrapes need no API key")
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		text := string(body)
+		assert.Contains(t, text, `llm_fast_wrapper_requests_total{backend="default",endpoint="/v1/chat/completions",model="echo",status="200"}`)
+		assert.Contains(t, text, "llm_fast_wrapper_active_streams")
+		assert.Contains(t, text, "llm_fast_wrapper_time_to_first_token_seconds_bucket")
+		assert.Contains(t, text, "llm_fast_wrapper_stream_duration_seconds_count")
+	}, handler.WithAuth(store))
+}
@@ -0,0 +1,146 @@
+package conformance_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	openai "github.com/openai/openai-go"
+	"github.com/openai/openai-go/option"
+	"github.com/raja.aiml/llm-fast-wrapper/api/handler"
+	"github.com/raja.aiml/llm-fast-wrapper/internal/config"
+	"github.com/raja.aiml/llm-fast-wrapper/internal/llm"
+	"github.com/raja.aiml/llm-fast-wrapper/internal/models"
+	"github.com/raja.aiml/llm-fast-wrapper/internal/routing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func withRegistry(t *testing.T) handler.Option {
+	t.Helper()
+	reg, err := models.NewRegistry([]models.Model{
+		{ID: "echo", Backend: "mock", ContextWindow: 4096, Capabilities: []string{"chat"}, Created: 1700000000},
+		{ID: "org/echo-large", Backend: "mock", Created: 1700000000},
+	})
+	require.NoError(t, err)
+	return handler.WithModels(reg)
+}
+
+func TestListModelsWithSDK(t *testing.T) {
+	eachFramework(t, func(t *testing.T, srv target) {
+		client := openai.NewClient(option.WithBaseURL(srv.url+"/v1/"), option.WithAPIKey("test"))
+		page, err := client.Models.List(context.Background())
+		require.NoError(t, err)
+		require.Len(t, page.Data, 2)
+		assert.Equal(t, "echo", page.Data[0].ID)
+		assert.Equal(t, "model", string(page.Data[0].Object))
+		assert.Equal(t, "mock", page.Data[0].OwnedBy)
+		assert.Equal(t, int64(1700000000), page.Data[0].Created)
+		assert.Equal(t, "org/echo-large", page.Data[1].ID)
+	}, withRegistry(t))
+}
+
+func TestGetModel(t *testing.T) {
+	eachFramework(t, func(t *testing.T, srv target) {
+		client := openai.NewClient(option.WithBaseURL(srv.url+"/v1/"), option.WithAPIKey("test"))
+		m, err := client.Models.Get(context.Background(), "echo")
+		require.NoError(t, err)
+		assert.Equal(t, "echo", m.ID)
+
+		var raw map[string]any
+		require.NoError(t, json.Unmarshal([]byte(m.RawJSON()), &raw))
+		assert.Equal(t, float64(4096), raw["context_window"])
+		assert.Equal(t, []any{"chat"}, raw["capabilities"])
+
+		resp, err := http.Get(srv.url + "/v1/models/org/echo-large")
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		missing, err := http.Get(srv.url + "/v1/models/nope")
+		require.NoError(t, err)
+		defer missing.Body.Close()
+		assert.Equal(t, http.StatusNotFound, missing.StatusCode)
+		assert.Equal(t, "The model 'nope' does not exist", decodeError(t, missing))
+	}, withRegistry(t))
+}
+
+func TestUnknownModelRejected(t *testing.T) {
+	eachFramework(t, func(t *testing.T, srv target) {
+		resp := post(t, context.Background(), srv.url, `{"model":"nope","messages":[{"role":"user","content":"hi"}]}`)
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+		assert.Equal(t, "The model 'nope' does not exist", decodeError(t, resp))
+		assert.Nil(t, srv.fake.last)
+
+		ok := post(t, context.Background(), srv.url, `{"model":"echo","messages":[{"role":"user","content":"hi"}]}`)
+		assert.Equal(t, http.StatusOK, ok.StatusCode)
+	}, withRegistry(t))
+}
+
+func TestEmptyRegistryAcceptsAnyModel(t *testing.T) {
+	eachFramework(t, func(t *testing.T, srv target) {
+		resp := post(t, context.Background(), srv.url, `{"model":"anything","messages":[{"role":"user","content":"hi"}]}`)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		list, err := http.Get(srv.url + "/v1/models")
+		require.NoError(t, err)
+		defer list.Body.Close()
+		var body models.List
+		require.NoError(t, json.NewDecoder(list.Body).Decode(&body))
+		assert.Equal(t, "list", body.Object)
+		assert.Empty(t, body.Data)
+	})
+}
+
+func TestUnroutableModel(t *testing.T) {
+	eachFramework(t, func(t *testing.T, srv target) {
+		resp := post(t, context.Background(), srv.url, `{"model":"unrouted","messages":[{"role":"user","content":"hi"}]}`)
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+		assert.Equal(t, "The model 'unrouted' does not exist", decodeError(t, resp))
+	})
+}
+
+// routed puts a router with routes and defaultBackend in front of the
+// fakeStreamer, which serves as backend "fake". Registered models are routed
+// to it as FromConfig would.
+func routed(t *testing.T, defaultBackend string, routes ...config.RouteConfig) upstream {
+	return func(fake *fakeStreamer) llm.Streamer {
+		routes := append([]config.RouteConfig{{Model: "echo", Backend: "fake"}}, routes...)
+		r, err := routing.New(map[string]llm.Streamer{"fake": fake}, routes, defaultBackend)
+		require.NoError(t, err)
+		return r
+	}
+}
+
+func TestRegistryWithRoutes(t *testing.T) {
+	up := routed(t, "",
+		config.RouteConfig{Model: "llama-70b", Backend: "fake", Rewrite: "meta-llama/Meta-Llama-3-70B-Instruct"},
+		config.RouteConfig{Prefix: "ollama/", Backend: "fake", StripPrefix: true},
+		config.RouteConfig{Glob: "mistral-*", Backend: "fake"},
+	)
+	eachFrameworkWith(t, up, func(t *testing.T, srv target) {
+		for model, upstream := range map[string]string{
+			"echo":          "echo",
+			"llama-70b":     "meta-llama/Meta-Llama-3-70B-Instruct",
+			"ollama/llama3": "llama3",
+			"mistral-7b":    "mistral-7b",
+		} {
+			resp := post(t, context.Background(), srv.url, `{"model":"`+model+`","messages":[{"role":"user","content":"hi"}]}`)
+			assert.Equal(t, http.StatusOK, resp.StatusCode, "unregistered but routed: %s", model)
+			require.NotNil(t, srv.fake.last)
+			assert.Equal(t, upstream, srv.fake.last.Model)
+		}
+
+		resp := post(t, context.Background(), srv.url, `{"model":"nope","messages":[{"role":"user","content":"hi"}]}`)
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+		assert.Equal(t, "The model 'nope' does not exist", decodeError(t, resp))
+	}, withRegistry(t))
+}
+
+func TestRegistryWithDefaultBackend(t *testing.T) {
+	eachFrameworkWith(t, routed(t, "fake"), func(t *testing.T, srv target) {
+		resp := post(t, context.Background(), srv.url, `{"model":"nope","messages":[{"role":"user","content":"hi"}]}`)
+		assert.Equal(t, http.StatusOK, resp.StatusCode, "default_backend catches unregistered models")
+	}, withRegistry(t))
+}
@@ -0,0 +1,84 @@
+package conformance_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	openai "github.com/openai/openai-go"
+	"github.com/openai/openai-go/option"
+	"github.com/raja.aiml/llm-fast-wrapper/api/handler"
+	"github.com/raja.aiml/llm-fast-wrapper/internal/config"
+	"github.com/raja.aiml/llm-fast-wrapper/internal/ratelimit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimitPerIP(t *testing.T) {
+	limits := config.RateLimitConfig{Default: config.LimitConfig{RequestsPerMinute: 2, TokensPerMinute: 1000}}
+	eachFrameworkFresh(t, func() []handler.Option {
+		return []handler.Option{handler.WithRateLimit(ratelimit.New(), limits)}
+	}, func(t *testing.T, srv target) {
+		body := strings.Replace(chatBody, "%s", "echo", 1)
+		for i := range 2 {
+			resp := request(t, http.MethodPost, srv.url+"/v1/chat/completions", "", body)
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+			assert.Equal(t, "2", resp.Header.Get("x-ratelimit-limit-requests"))
+			assert.Equal(t, []string{"1", "0"}[i], resp.Header.Get("x-ratelimit-remaining-requests"))
+			assert.Equal(t, "1000", resp.Header.Get("x-ratelimit-limit-tokens"))
+			assert.NotEmpty(t, resp.Header.Get("x-ratelimit-reset-tokens"))
+		}
+
+		resp := request(t, http.MethodPost, srv.url+"/v1/chat/completions", "", body)
+		assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+		assert.Equal(t, "30", resp.Header.Get("Retry-After"))
+		assert.Equal(t, "0", resp.Header.Get("x-ratelimit-remaining-requests"))
+		assert.Contains(t, decodeError(t, resp), "Rate limit reached")
+
+		client := openai.NewClient(option.WithBaseURL(srv.url+"/v1/"), option.WithAPIKey("test"), option.WithMaxRetries(0))
+		_, err := client.Chat.Completions.New(context.Background(), openai.ChatCompletionNewParams{
+			Model:    "echo",
+			Messages: []openai.ChatCompletionMessageParamUnion{openai.UserMessage("hi")},
+		})
+		var apiErr *openai.Error
+		require.True(t, errors.As(err, &apiErr))
+		assert.Equal(t, http.StatusTooManyRequests, apiErr.StatusCode)
+		assert.Equal(t, "rate_limit_exceeded", apiErr.Code)
+		assert.Equal(t, "rate_limit_error", apiErr.Type)
+	})
+}
+
+func TestRateLimitTooLarge(t *testing.T) {
+	limits := config.RateLimitConfig{Default: config.LimitConfig{TokensPerMinute: 10}}
+	eachFramework(t, func(t *testing.T, srv target) {
+		resp := request(t, http.MethodPost, srv.url+"/v1/chat/completions", "",
+			`{"model":"echo","max_tokens":100,"messages":[{"role":"user","content":"hi"}]}`)
+		assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+		assert.Empty(t, resp.Header.Get("Retry-After"))
+		assert.Contains(t, decodeError(t, resp), "Request too large")
+	}, handler.WithRateLimit(ratelimit.New(), limits))
+}
+
+func TestRateLimitPerTenantKey(t *testing.T) {
+	store, open, limited, _ := keyStore(t)
+	limits := config.RateLimitConfig{
+		Default: config.LimitConfig{RequestsPerMinute: 1},
+		Tenants: map[string]config.LimitConfig{"acme": {RequestsPerMinute: 3}},
+	}
+	eachFrameworkFresh(t, func() []handler.Option {
+		return []handler.Option{handler.WithAuth(store), handler.WithRateLimit(ratelimit.New(), limits)}
+	}, func(t *testing.T, srv target) {
+		body := strings.Replace(chatBody, "%s", "echo", 1)
+		statuses := func(token string, n int) []int {
+			var out []int
+			for range n {
+				out = append(out, request(t, http.MethodPost, srv.url+"/v1/chat/completions", token, body).StatusCode)
+			}
+			return out
+		}
+		assert.Equal(t, []int{200, 200, 200, 429}, statuses(open, 4), "acme override")
+		assert.Equal(t, []int{200, 429}, statuses(limited, 2), "default for globex, separate bucket")
+	})
+}
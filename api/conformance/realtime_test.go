@@ -0,0 +1,208 @@
+package conformance_test
+
+import (
+	"net"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/raja.aiml/llm-fast-wrapper/api/handler"
+	"github.com/raja.aiml/llm-fast-wrapper/internal/config"
+	"github.com/raja.aiml/llm-fast-wrapper/internal/llm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type frame struct {
+	Type   string                   `json:"type"`
+	ID     string                   `json:"id"`
+	Chunk  *llm.ChatCompletionChunk `json:"chunk"`
+	Status int                      `json:"status"`
+	Error  *struct {
+		Message string `json:"message"`
+		Type    string `json:"type"`
+	} `json:"error"`
+}
+
+// dial opens /v1/realtime, offering subprotocols when given.
+func dial(t *testing.T, srv target, subprotocols ...string) *websocket.Conn {
+	t.Helper()
+	dialer := websocket.Dialer{Subprotocols: subprotocols, HandshakeTimeout: 5 * time.Second}
+	conn, resp, err := dialer.Dial("ws"+strings.TrimPrefix(srv.url, "http")+"/v1/realtime", nil)
+	require.NoError(t, err)
+	resp.Body.Close()
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func sendFrame(t *testing.T, conn *websocket.Conn, v any) {
+	t.Helper()
+	require.NoError(t, conn.WriteJSON(v))
+}
+
+func readFrame(t *testing.T, conn *websocket.Conn) frame {
+	t.Helper()
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	var f frame
+	require.NoError(t, conn.ReadJSON(&f))
+	return f
+}
+
+func chatFrame(id, model string) map[string]any {
+	return map[string]any{"type": "request", "id": id, "request": map[string]any{
+		"model":    model,
+		"messages": []map[string]string{{"role": "user", "content": "hi"}},
+	}}
+}
+
+func TestRealtimeConcurrentRequests(t *testing.T) {
+	eachFramework(t, func(t *testing.T, srv target) {
+		conn := dial(t, srv)
+		sendFrame(t, conn, chatFrame("a", "paced"))
+		sendFrame(t, conn, chatFrame("b", "echo"))
+
+		content := map[string]string{}
+		done := map[string]bool{}
+		for len(done) < 2 {
+			f := readFrame(t, conn)
+			switch f.Type {
+			case "chunk":
+				require.NotNil(t, f.Chunk)
+				assert.Equal(t, "chat.completion.chunk", f.Chunk.Object)
+				assert.False(t, done[f.ID], "chunk after done")
+				content[f.ID] += f.Chunk.Choices[0].Delta.Content
+			case "done":
+				done[f.ID] = true
+			default:
+				t.Fatalf("unexpected frame %+v", f)
+			}
+		}
+		assert.Equal(t, map[string]string{"a": "Hello world", "b": "Hello world"}, content)
+
+		sendFrame(t, conn, map[string]string{"type": "ping", "id": "p1"})
+		assert.Equal(t, frame{Type: "pong", ID: "p1"}, readFrame(t, conn))
+	})
+}
+
+func TestRealtimeCancel(t *testing.T) {
+	eachFramework(t, func(t *testing.T, srv target) {
+		conn := dial(t, srv)
+		sendFrame(t, conn, chatFrame("s", "slow"))
+		f := readFrame(t, conn)
+		require.Equal(t, "chunk", f.Type)
+
+		sendFrame(t, conn, map[string]string{"type": "cancel", "id": "s"})
+		for f.Type == "chunk" {
+			f = readFrame(t, conn)
+		}
+		assert.Equal(t, frame{Type: "canceled", ID: "s"}, f)
+		select {
+		case <-srv.fake.canceled:
+		case <-time.After(5 * time.Second):
+			t.Fatal("upstream context was not canceled")
+		}
+
+		// nothing more arrives for the canceled request
+		sendFrame(t, conn, map[string]string{"type": "ping"})
+		assert.Equal(t, frame{Type: "pong"}, readFrame(t, conn))
+		sendFrame(t, conn, map[string]string{"type": "cancel", "id": "s"})
+		f = readFrame(t, conn)
+		assert.Equal(t, "error", f.Type)
+		assert.Equal(t, http.StatusNotFound, f.Status)
+	})
+}
+
+func TestRealtimeErrors(t *testing.T) {
+	eachFramework(t, func(t *testing.T, srv target) {
+		conn := dial(t, srv)
+
+		sendFrame(t, conn, chatFrame("u", "unrouted"))
+		f := readFrame(t, conn)
+		assert.Equal(t, "error", f.Type)
+		assert.Equal(t, "u", f.ID)
+		assert.Equal(t, http.StatusNotFound, f.Status)
+
+		sendFrame(t, conn, chatFrame("m", "midfail"))
+		f = readFrame(t, conn)
+		assert.Equal(t, "chunk", f.Type)
+		f = readFrame(t, conn)
+		assert.Equal(t, "error", f.Type)
+		assert.Equal(t, "m", f.ID)
+		assert.Equal(t, "connection reset", f.Error.Message)
+
+		sendFrame(t, conn, map[string]string{"type": "request"})
+		f = readFrame(t, conn)
+		assert.Equal(t, "error", f.Type)
+		assert.Contains(t, f.Error.Message, "need an id")
+
+		sendFrame(t, conn, map[string]string{"type": "bogus", "id": "x"})
+		f = readFrame(t, conn)
+		assert.Equal(t, "error", f.Type)
+		assert.Equal(t, http.StatusBadRequest, f.Status)
+		assert.Equal(t, "invalid_request_error", f.Error.Type)
+
+		// the connection survives failed requests
+		sendFrame(t, conn, chatFrame("ok", "echo"))
+		for f = readFrame(t, conn); f.Type == "chunk"; f = readFrame(t, conn) {
+		}
+		assert.Equal(t, frame{Type: "done", ID: "ok"}, f)
+	})
+}
+
+func TestRealtimeAuth(t *testing.T) {
+	store, open, _, _ := keyStore(t)
+	eachFramework(t, func(t *testing.T, srv target) {
+		url := "ws" + strings.TrimPrefix(srv.url, "http") + "/v1/realtime"
+		_, resp, err := websocket.DefaultDialer.Dial(url, nil)
+		require.Error(t, err)
+		require.NotNil(t, resp)
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+		resp.Body.Close()
+
+		// browsers send their key as a subprotocol
+		conn := dial(t, srv, handler.RealtimeSubprotocol, "bearer."+open)
+		assert.Equal(t, handler.RealtimeSubprotocol, conn.Subprotocol())
+		sendFrame(t, conn, chatFrame("a", "echo"))
+		assert.Equal(t, "chunk", readFrame(t, conn).Type)
+		srv.fake.mu.Lock()
+		defer srv.fake.mu.Unlock()
+		require.NotNil(t, srv.fake.lastKey)
+		assert.Equal(t, "acme", srv.fake.lastKey.Tenant)
+	}, handler.WithAuth(store))
+}
+
+func TestRealtimeKeepalive(t *testing.T) {
+	eachFramework(t, func(t *testing.T, srv target) {
+		conn := dial(t, srv)
+		var pings atomic.Int32
+		conn.SetPingHandler(func(data string) error {
+			pings.Add(1)
+			return conn.WriteControl(websocket.PongMessage, []byte(data), time.Now().Add(time.Second))
+		})
+		// reading runs the ping handler; the server keeps a ponging client
+		// connected well past its read deadline
+		require.NoError(t, conn.SetReadDeadline(time.Now().Add(200*time.Millisecond)))
+		_, _, err := conn.ReadMessage()
+		var netErr net.Error
+		require.ErrorAs(t, err, &netErr)
+		assert.True(t, netErr.Timeout(), "connection closed early: %v", err)
+		assert.GreaterOrEqual(t, pings.Load(), int32(3))
+	}, handler.WithStreaming(config.StreamConfig{HeartbeatInterval: 20 * time.Millisecond}))
+}
+
+func TestRealtimeDropsSilentClients(t *testing.T) {
+	eachFramework(t, func(t *testing.T, srv target) {
+		conn := dial(t, srv)
+		sendFrame(t, conn, chatFrame("h", "hang"))
+		// a client that never reads never answers pings, so the server
+		// closes the connection and cancels its requests
+		select {
+		case <-srv.fake.canceled:
+		case <-time.After(5 * time.Second):
+			t.Fatal("requests of a silent client were not canceled")
+		}
+	}, handler.WithStreaming(config.StreamConfig{HeartbeatInterval: 20 * time.Millisecond}))
+}
@@ -0,0 +1,81 @@
+package conformance_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/raja.aiml/llm-fast-wrapper/api/handler"
+	"github.com/raja.aiml/llm-fast-wrapper/internal/config"
+	"github.com/raja.aiml/llm-fast-wrapper/internal/embeddings/storage/memory"
+	"github.com/raja.aiml/llm-fast-wrapper/internal/responsecache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// promptVectors embeds a fixed vocabulary of prompts.
+type promptVectors map[string][]float32
+
+func (v promptVectors) Get(_ context.Context, text string) ([]float32, error) {
+	if vec, ok := v[text]; ok {
+		return vec, nil
+	}
+	return nil, errors.New("unknown prompt")
+}
+
+func withSemanticCache() []handler.Option {
+	vectors := promptVectors{
+		"what is the capital of france": {1, 0},
+		"capital of france?":            {0.99, 0.14},
+		"how tall is everest":           {0, 1},
+	}
+	cfg := config.SemanticCacheConfig{TTL: time.Minute, Threshold: 0.9}
+	return []handler.Option{handler.WithSemanticCache(
+		responsecache.NewSemantic(vectors, memory.NewMemoryStore(), responsecache.NewMemoryStore(0), cfg))}
+}
+
+func askBody(prompt string) string {
+	return `{"model":"echo","messages":[{"role":"user","content":"` + prompt + `"}]}`
+}
+
+func TestSemanticCache(t *testing.T) {
+	eachFrameworkFresh(t, withSemanticCache, func(t *testing.T, srv target) {
+		resp := post(t, context.Background(), srv.url, askBody("what is the capital of france"))
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "MISS", resp.Header.Get("X-Cache"))
+
+		require.Eventually(t, func() bool {
+			srv.fake.last = nil
+			resp = post(t, context.Background(), srv.url, askBody("capital of france?"))
+			return resp.Header.Get("X-Cache") == "HIT"
+		}, time.Second, 10*time.Millisecond)
+		assert.Equal(t, "semantic", resp.Header.Get("X-Cache-Source"))
+		assert.Nil(t, srv.fake.last, "hit does not reach the upstream")
+
+		resp = post(t, context.Background(), srv.url, askBody("how tall is everest"))
+		assert.Equal(t, "MISS", resp.Header.Get("X-Cache"))
+	})
+}
+
+func TestSemanticCacheOptOut(t *testing.T) {
+	eachFrameworkFresh(t, withSemanticCache, func(t *testing.T, srv target) {
+		post(t, context.Background(), srv.url, askBody("what is the capital of france"))
+		require.Eventually(t, func() bool {
+			return post(t, context.Background(), srv.url, askBody("capital of france?")).Header.Get("X-Cache") == "HIT"
+		}, time.Second, 10*time.Millisecond)
+
+		srv.fake.last = nil
+		req, err := http.NewRequest(http.MethodPost, srv.url+"/v1/chat/completions", strings.NewReader(askBody("capital of france?")))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Semantic-Cache", "off")
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, "MISS", resp.Header.Get("X-Cache"))
+		assert.NotNil(t, srv.fake.last, "opted-out request reaches the upstream")
+	})
+}
@@ -0,0 +1,164 @@
+package conformance_test
+
+import (
+	"bufio"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	fiberapi "github.com/raja.aiml/llm-fast-wrapper/api/fiber"
+	ginapi "github.com/raja.aiml/llm-fast-wrapper/api/gin"
+	"github.com/raja.aiml/llm-fast-wrapper/api/handler"
+	"github.com/raja.aiml/llm-fast-wrapper/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type serveFunc func(ctx context.Context, ln net.Listener, cfg config.ListenConfig, h *handler.Handler) error
+
+var servers = []struct {
+	name  string
+	serve serveFunc
+}{
+	{"gin", ginapi.Serve},
+	{"fiber", fiberapi.Serve},
+}
+
+// runServer starts serve on ln in the background and returns a channel that
+// receives its result.
+func runServer(ctx context.Context, serve serveFunc, ln net.Listener, cfg config.ListenConfig, fake *fakeStreamer) <-chan error {
+	done := make(chan error, 1)
+	go func() { done <- serve(ctx, ln, cfg, handler.New(fake)) }()
+	return done
+}
+
+func TestGracefulShutdownDrainsStreams(t *testing.T) {
+	for _, s := range servers {
+		t.Run(s.name, func(t *testing.T) {
+			ln, err := net.Listen("tcp", "127.0.0.1:0")
+			require.NoError(t, err)
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			done := runServer(ctx, s.serve, ln, config.ListenConfig{ShutdownTimeout: 5 * time.Second}, newFakeStreamer())
+
+			resp := post(t, context.Background(), "http://"+ln.Addr().String(), `{"model":"paced","stream":true,"messages":[{"role":"user","content":"hi"}]}`)
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+			reader := bufio.NewReader(resp.Body)
+			first, err := reader.ReadString('\n')
+			require.NoError(t, err)
+			assert.True(t, strings.HasPrefix(first, "data: "))
+
+			cancel()
+			assert.Eventually(t, func() bool {
+				conn, err := net.Dial("tcp", ln.Addr().String())
+				if err == nil {
+					conn.Close()
+				}
+				return err != nil
+			}, 2*time.Second, 10*time.Millisecond, "listener should stop accepting connections")
+
+			rest, err := io.ReadAll(reader)
+			require.NoError(t, err)
+			assert.True(t, strings.HasSuffix(string(rest), "data: [DONE]\n\n"), "in-flight stream should complete")
+
+			select {
+			case err := <-done:
+				assert.NoError(t, err)
+			case <-time.After(5 * time.Second):
+				t.Fatal("server did not stop")
+			}
+		})
+	}
+}
+
+func TestShutdownDeadlineExceeded(t *testing.T) {
+	for _, s := range servers {
+		t.Run(s.name, func(t *testing.T) {
+			ln, err := net.Listen("tcp", "127.0.0.1:0")
+			require.NoError(t, err)
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			done := runServer(ctx, s.serve, ln, config.ListenConfig{ShutdownTimeout: 100 * time.Millisecond}, newFakeStreamer())
+
+			reqCtx, reqCancel := context.WithCancel(context.Background())
+			defer reqCancel()
+			resp := post(t, reqCtx, "http://"+ln.Addr().String(), `{"model":"slow","stream":true,"messages":[{"role":"user","content":"hi"}]}`)
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+			_, err = bufio.NewReader(resp.Body).ReadString('\n')
+			require.NoError(t, err)
+
+			cancel()
+			select {
+			case err := <-done:
+				assert.Error(t, err)
+			case <-time.After(5 * time.Second):
+				t.Fatal("server did not give up after the drain deadline")
+			}
+		})
+	}
+}
+
+func TestServeTLS(t *testing.T) {
+	certFile, keyFile := writeSelfSignedCert(t)
+	for _, s := range servers {
+		t.Run(s.name, func(t *testing.T) {
+			cfg := config.ListenConfig{Addr: "127.0.0.1:0", TLSCertFile: certFile, TLSKeyFile: keyFile, ShutdownTimeout: time.Second}
+			ln, err := cfg.Listen()
+			require.NoError(t, err)
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			runServer(ctx, s.serve, ln, cfg, newFakeStreamer())
+
+			client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}}
+			resp, err := client.Post("https://"+ln.Addr().String()+"/v1/chat/completions", "application/json",
+				strings.NewReader(`{"model":"echo","messages":[{"role":"user","content":"hi"}]}`))
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			assert.Equal(t, http.StatusOK, resp.StatusCode)
+			require.NotNil(t, resp.TLS)
+		})
+	}
+}
+
+func TestListenRequiresCertAndKey(t *testing.T) {
+	_, err := config.ListenConfig{Addr: "127.0.0.1:0", TLSCertFile: "cert.pem"}.Listen()
+	assert.Error(t, err)
+}
+
+func writeSelfSignedCert(t *testing.T) (string, string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "127.0.0.1"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600))
+	return certFile, keyFile
+}
@@ -0,0 +1,52 @@
+package conformance_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/raja.aiml/llm-fast-wrapper/api/handler"
+	"github.com/raja.aiml/llm-fast-wrapper/internal/intent"
+	"github.com/raja.aiml/llm-fast-wrapper/internal/llm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// keywordMatcher matches queries mentioning "code"; "boom" fails.
+type keywordMatcher struct{}
+
+func (keywordMatcher) Match(_ context.Context, query string) (*intent.MatchResult, error) {
+	switch {
+	case strings.Contains(query, "boom"):
+		return nil, errors.New("embedding failed")
+	case strings.Contains(query, "code"):
+		return &intent.MatchResult{Name: "Code Review", Score: 0.8765, Content: "Review the code carefully."}, nil
+	}
+	return nil, nil
+}
+
+func TestStrategyInjection(t *testing.T) {
+	eachFramework(t, func(t *testing.T, srv target) {
+		resp := post(t, context.Background(), srv.url,
+			`{"model":"echo","messages":[{"role":"system","content":"be brief"},{"role":"user","content":"check my code"}]}`)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "Code Review", resp.Header.Get("X-Prompt-Strategy"))
+		assert.Equal(t, "0.8765", resp.Header.Get("X-Prompt-Strategy-Score"))
+		require.Len(t, srv.fake.last.Messages, 3)
+		assert.Equal(t, llm.Message{Role: "system", Content: "Review the code carefully."}, srv.fake.last.Messages[0])
+		assert.Equal(t, "be brief", srv.fake.last.Messages[1].Content)
+	}, handler.WithStrategies(keywordMatcher{}))
+}
+
+func TestStrategyNoMatch(t *testing.T) {
+	eachFramework(t, func(t *testing.T, srv target) {
+		for _, prompt := range []string{"write a poem", "boom"} {
+			resp := post(t, context.Background(), srv.url, askBody(prompt))
+			require.Equal(t, http.StatusOK, resp.StatusCode, "match failures do not fail the request")
+			assert.Empty(t, resp.Header.Get("X-Prompt-Strategy"))
+			assert.Len(t, srv.fake.last.Messages, 1)
+		}
+	}, handler.WithStrategies(keywordMatcher{}))
+}
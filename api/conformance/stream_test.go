@@ -0,0 +1,117 @@
+package conformance_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/raja.aiml/llm-fast-wrapper/api/handler"
+	"github.com/raja.aiml/llm-fast-wrapper/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const keepAlive = ": keep-alive\n\n"
+
+func streamBody(model string) string {
+	return `{"model":"` + model + `","stream":true,"messages":[{"role":"user","content":"hi"}]}`
+}
+
+// lastEventError decodes the error of the final SSE event in body.
+func lastEventError(t *testing.T, body string) (message, code string) {
+	t.Helper()
+	events := strings.Split(strings.TrimSpace(body), "\n\n")
+	last := strings.TrimPrefix(events[len(events)-1], "data: ")
+	var e struct {
+		Error struct {
+			Message string `json:"message"`
+			Code    string `json:"code"`
+		} `json:"error"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(last), &e), last)
+	return e.Error.Message, e.Error.Code
+}
+
+func TestHeartbeats(t *testing.T) {
+	eachFramework(t, func(t *testing.T, srv target) {
+		// the upstream takes several intervals to start: the stream is
+		// committed and kept alive meanwhile
+		resp := post(t, context.Background(), srv.url, streamBody("slowstart"))
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(string(body), keepAlive), string(body))
+		assert.True(t, strings.HasSuffix(string(body), "data: [DONE]\n\n"))
+
+		// and between chunks arriving slower than the interval
+		resp = post(t, context.Background(), srv.url, streamBody("paced"))
+		body, err = io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		assert.Contains(t, string(body), "}\n\n"+keepAlive)
+	}, handler.WithStreaming(config.StreamConfig{HeartbeatInterval: 20 * time.Millisecond}))
+}
+
+func TestHeartbeatsDetectDisconnect(t *testing.T) {
+	for _, model := range []string{"hang", "stall"} {
+		t.Run(model, func(t *testing.T) {
+			eachFramework(t, func(t *testing.T, srv target) {
+				ctx, cancel := context.WithCancel(context.Background())
+				defer cancel()
+				resp := post(t, ctx, srv.url, streamBody(model))
+				require.Equal(t, http.StatusOK, resp.StatusCode)
+				_, err := bufio.NewReader(resp.Body).ReadString('\n')
+				require.NoError(t, err)
+
+				cancel()
+				select {
+				case <-srv.fake.canceled:
+				case <-time.After(5 * time.Second):
+					t.Fatal("upstream context was not canceled after client disconnect")
+				}
+			}, handler.WithStreaming(config.StreamConfig{HeartbeatInterval: 20 * time.Millisecond}))
+		})
+	}
+}
+
+func TestFirstTokenTimeout(t *testing.T) {
+	eachFramework(t, func(t *testing.T, srv target) {
+		resp := post(t, context.Background(), srv.url, `{"model":"hang","messages":[{"role":"user","content":"hi"}]}`)
+		assert.Equal(t, http.StatusGatewayTimeout, resp.StatusCode)
+		assert.Contains(t, decodeError(t, resp), "first-token timeout")
+	}, handler.WithStreaming(config.StreamConfig{FirstTokenTimeout: 50 * time.Millisecond}))
+
+	// once a stream is committed the timeout ends it with an error event
+	eachFramework(t, func(t *testing.T, srv target) {
+		resp := post(t, context.Background(), srv.url, streamBody("hang"))
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		message, code := lastEventError(t, string(body))
+		assert.Equal(t, "timeout", code)
+		assert.Contains(t, message, "first-token timeout")
+	}, handler.WithStreaming(config.StreamConfig{HeartbeatInterval: 20 * time.Millisecond, FirstTokenTimeout: 100 * time.Millisecond}))
+}
+
+func TestStreamTimeout(t *testing.T) {
+	eachFramework(t, func(t *testing.T, srv target) {
+		resp := post(t, context.Background(), srv.url, streamBody("stall"))
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		assert.Contains(t, string(body), `"content":"Hello"`)
+		assert.NotContains(t, string(body), "[DONE]")
+		message, code := lastEventError(t, string(body))
+		assert.Equal(t, "timeout", code)
+		assert.Contains(t, message, "stream timeout")
+
+		resp = post(t, context.Background(), srv.url, `{"model":"stall","messages":[{"role":"user","content":"hi"}]}`)
+		assert.Equal(t, http.StatusGatewayTimeout, resp.StatusCode)
+		assert.Contains(t, decodeError(t, resp), "stream timeout")
+	}, handler.WithStreaming(config.StreamConfig{Timeout: 100 * time.Millisecond, FirstTokenTimeout: 50 * time.Millisecond}))
+}
@@ -0,0 +1,86 @@
+package conformance_test
+
+import (
+	"context"
+	"testing"
+
+	openai "github.com/openai/openai-go"
+	"github.com/openai/openai-go/option"
+	"github.com/raja.aiml/llm-fast-wrapper/internal/llm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func toolParams() openai.ChatCompletionNewParams {
+	return openai.ChatCompletionNewParams{
+		Model: "tools",
+		Messages: []openai.ChatCompletionMessageParamUnion{
+			openai.UserMessage("weather in Paris?"),
+			{OfAssistant: &openai.ChatCompletionAssistantMessageParam{
+				ToolCalls: []openai.ChatCompletionMessageToolCallParam{{
+					ID:       "call_0",
+					Function: openai.ChatCompletionMessageToolCallFunctionParam{Name: "get_weather", Arguments: `{"city":"Lyon"}`},
+				}},
+			}},
+			openai.ToolMessage("sunny", "call_0"),
+		},
+		Tools: []openai.ChatCompletionToolParam{{Function: openai.FunctionDefinitionParam{
+			Name:       "get_weather",
+			Parameters: openai.FunctionParameters{"type": "object", "properties": map[string]any{"city": map[string]any{"type": "string"}}},
+		}}},
+		ToolChoice: openai.ChatCompletionToolChoiceOptionUnionParam{OfAuto: openai.String("required")},
+	}
+}
+
+func TestToolCalls_PassThrough(t *testing.T) {
+	eachFramework(t, func(t *testing.T, srv target) {
+		client := openai.NewClient(option.WithBaseURL(srv.url+"/v1/"), option.WithAPIKey("test"))
+		resp, err := client.Chat.Completions.New(context.Background(), toolParams())
+		require.NoError(t, err)
+		require.Len(t, resp.Choices, 1)
+		assert.Equal(t, "tool_calls", resp.Choices[0].FinishReason)
+		require.Len(t, resp.Choices[0].Message.ToolCalls, 1)
+		call := resp.Choices[0].Message.ToolCalls[0]
+		assert.Equal(t, "call_1", call.ID)
+		assert.Equal(t, "get_weather", call.Function.Name)
+		assert.Equal(t, `{"city":"Paris"}`, call.Function.Arguments)
+
+		// tools, tool_choice and the tool conversation reach the upstream
+		srv.fake.mu.Lock()
+		last := srv.fake.last
+		srv.fake.mu.Unlock()
+		require.Len(t, last.Tools, 1)
+		assert.Equal(t, "get_weather", last.Tools[0].Function.Name)
+		assert.JSONEq(t, `{"type":"object","properties":{"city":{"type":"string"}}}`, string(last.Tools[0].Function.Parameters))
+		assert.Equal(t, &llm.ToolChoice{Mode: "required"}, last.ToolChoice)
+		require.Len(t, last.Messages, 3)
+		assert.Equal(t, `{"city":"Lyon"}`, last.Messages[1].ToolCalls[0].Function.Arguments)
+		assert.Equal(t, llm.Message{Role: "tool", Content: "sunny", ToolCallID: "call_0"}, last.Messages[2])
+	})
+}
+
+func TestToolCalls_Streaming(t *testing.T) {
+	eachFramework(t, func(t *testing.T, srv target) {
+		client := openai.NewClient(option.WithBaseURL(srv.url+"/v1/"), option.WithAPIKey("test"))
+		stream := client.Chat.Completions.NewStreaming(context.Background(), toolParams())
+		var acc openai.ChatCompletionAccumulator
+		var fragments int
+		for stream.Next() {
+			chunk := stream.Current()
+			acc.AddChunk(chunk)
+			for _, choice := range chunk.Choices {
+				fragments += len(choice.Delta.ToolCalls)
+			}
+		}
+		require.NoError(t, stream.Err())
+		assert.Equal(t, 3, fragments, "argument fragments are streamed as they arrive")
+
+		require.Len(t, acc.Choices, 1)
+		assert.Equal(t, "tool_calls", acc.Choices[0].FinishReason)
+		require.Len(t, acc.Choices[0].Message.ToolCalls, 1)
+		call := acc.Choices[0].Message.ToolCalls[0]
+		assert.Equal(t, "call_1", call.ID)
+		assert.Equal(t, "get_weather", call.Function.Name)
+		assert.Equal(t, `{"city":"Paris"}`, call.Function.Arguments)
+	})
+}
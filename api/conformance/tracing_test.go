@@ -0,0 +1,71 @@
+package conformance_test
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// recordSpans installs a tracer provider that keeps ended spans in memory.
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+	rec := tracetest.NewSpanRecorder()
+	prevProvider, prevPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(prevProvider)
+		otel.SetTextMapPropagator(prevPropagator)
+	})
+	return rec
+}
+
+func TestTracingContinuesIncomingTrace(t *testing.T) {
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	for _, stream := range []bool{false, true} {
+		body := `{"model":"echo","messages":[{"role":"user","content":"hi"}]}`
+		if stream {
+			body = strings.Replace(body, `{`, `{"stream":true,`, 1)
+		}
+		eachFramework(t, func(t *testing.T, srv target) {
+			rec := recordSpans(t)
+			req, err := http.NewRequest(http.MethodPost, srv.url+"/v1/chat/completions", strings.NewReader(body))
+			require.NoError(t, err)
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			_, _ = io.ReadAll(resp.Body)
+			resp.Body.Close()
+
+			var span sdktrace.ReadOnlySpan
+			require.Eventually(t, func() bool {
+				for _, s := range rec.Ended() {
+					if s.Name() == "/v1/chat/completions" {
+						span = s
+						return true
+					}
+				}
+				return false
+			}, time.Second, 5*time.Millisecond)
+			assert.Equal(t, traceID, span.SpanContext().TraceID().String())
+			assert.Equal(t, "00f067aa0ba902b7", span.Parent().SpanID().String())
+			assert.Equal(t, trace.SpanKindServer, span.SpanKind())
+			var events []string
+			for _, e := range span.Events() {
+				events = append(events, e.Name)
+			}
+			assert.Contains(t, events, "first_token")
+		})
+	}
+}
@@ -0,0 +1,144 @@
+package conformance_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	openai "github.com/openai/openai-go"
+	"github.com/openai/openai-go/option"
+	"github.com/raja.aiml/llm-fast-wrapper/api/handler"
+	"github.com/raja.aiml/llm-fast-wrapper/internal/llm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// usageStreamBody is a streaming chat request for model that asks for the
+// usage chunk.
+func usageStreamBody(model string) string {
+	return `{"model":"` + model + `","stream":true,"stream_options":{"include_usage":true},` +
+		`"messages":[{"role":"user","content":"hi"}]}`
+}
+
+// sseEvents reads a complete SSE response and returns its data payloads.
+func sseEvents(t *testing.T, resp *http.Response) []string {
+	t.Helper()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	var events []string
+	for _, event := range strings.Split(strings.TrimSpace(string(body)), "\n\n") {
+		events = append(events, strings.TrimPrefix(event, "data: "))
+	}
+	return events
+}
+
+func TestUsage_CountedLocally(t *testing.T) {
+	eachFramework(t, func(t *testing.T, srv target) {
+		resp := request(t, http.MethodPost, srv.url+"/v1/chat/completions", "", askBody("hi"))
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var completion llm.ChatCompletion
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&completion))
+		assert.Positive(t, completion.Usage.PromptTokens)
+		assert.Positive(t, completion.Usage.CompletionTokens)
+		assert.Equal(t, completion.Usage.PromptTokens+completion.Usage.CompletionTokens, completion.Usage.TotalTokens)
+
+		// the usage chunk comes last, before [DONE], and has no choices
+		events := sseEvents(t, request(t, http.MethodPost, srv.url+"/v1/chat/completions", "", usageStreamBody("echo")))
+		require.Len(t, events, len(scriptedChunks())+2)
+		assert.Equal(t, "[DONE]", events[len(events)-1])
+		assert.Contains(t, events[len(events)-2], `"choices":[]`)
+		var chunk llm.ChatCompletionChunk
+		require.NoError(t, json.Unmarshal([]byte(events[len(events)-2]), &chunk))
+		require.NotNil(t, chunk.Usage)
+		assert.Equal(t, completion.Usage, *chunk.Usage)
+		assert.Equal(t, "chatcmpl-test", chunk.ID)
+		for _, event := range events[:len(events)-2] {
+			assert.NotContains(t, event, `"usage"`)
+		}
+
+		// without include_usage the stream carries no usage
+		events = sseEvents(t, request(t, http.MethodPost, srv.url+"/v1/chat/completions", "", streamBody("echo")))
+		for _, event := range events {
+			assert.NotContains(t, event, `"usage"`)
+		}
+	})
+}
+
+func TestUsage_FromUpstream(t *testing.T) {
+	eachFramework(t, func(t *testing.T, srv target) {
+		resp := request(t, http.MethodPost, srv.url+"/v1/chat/completions", "", fmt.Sprintf(chatBody, "metered"))
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var completion llm.ChatCompletion
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&completion))
+		assert.Equal(t, upstreamUsage, completion.Usage)
+
+		// the upstream's usage chunk is only passed on when asked for
+		events := sseEvents(t, request(t, http.MethodPost, srv.url+"/v1/chat/completions", "", streamBody("metered")))
+		require.Len(t, events, len(scriptedChunks())+1)
+		for _, event := range events {
+			assert.NotContains(t, event, `"usage"`)
+		}
+
+		client := openai.NewClient(option.WithBaseURL(srv.url+"/v1/"), option.WithAPIKey("test"))
+		stream := client.Chat.Completions.NewStreaming(context.Background(), openai.ChatCompletionNewParams{
+			Model:         "metered",
+			Messages:      []openai.ChatCompletionMessageParamUnion{openai.UserMessage("hi")},
+			StreamOptions: openai.ChatCompletionStreamOptionsParam{IncludeUsage: openai.Bool(true)},
+		})
+		var acc openai.ChatCompletionAccumulator
+		for stream.Next() {
+			acc.AddChunk(stream.Current())
+		}
+		require.NoError(t, stream.Err())
+		assert.Equal(t, "Hello world", acc.Choices[0].Message.Content)
+		assert.Equal(t, int64(upstreamUsage.PromptTokens), acc.Usage.PromptTokens)
+		assert.Equal(t, int64(upstreamUsage.CompletionTokens), acc.Usage.CompletionTokens)
+		assert.Equal(t, int64(upstreamUsage.TotalTokens), acc.Usage.TotalTokens)
+	})
+}
+
+func TestUsage_Completions(t *testing.T) {
+	eachFramework(t, func(t *testing.T, srv target) {
+		events := sseEvents(t, request(t, http.MethodPost, srv.url+"/v1/completions", "",
+			`{"model":"metered","prompt":"hi","stream":true,"stream_options":{"include_usage":true}}`))
+		require.GreaterOrEqual(t, len(events), 2)
+		var chunk llm.TextCompletion
+		require.NoError(t, json.Unmarshal([]byte(events[len(events)-2]), &chunk))
+		assert.Equal(t, "text_completion", chunk.Object)
+		assert.Empty(t, chunk.Choices)
+		assert.Equal(t, &upstreamUsage, chunk.Usage)
+	})
+}
+
+func TestUsage_AuditAndMetrics(t *testing.T) {
+	store, open, _, _ := keyStore(t)
+	rec := &auditRecorder{}
+	eachFramework(t, func(t *testing.T, srv target) {
+		resp := request(t, http.MethodPost, srv.url+"/v1/chat/completions", open, fmt.Sprintf(chatBody, "metered"))
+		_, _ = io.ReadAll(resp.Body)
+		entry := rec.next(t)
+		assert.Equal(t, upstreamUsage.TotalTokens, entry.Usage.TotalTokens)
+		assert.Equal(t, upstreamUsage.PromptTokens, entry.Usage.PromptTokens)
+
+		// streams are metered whether or not the client asked for usage
+		resp = request(t, http.MethodPost, srv.url+"/v1/chat/completions", open, streamBody("metered"))
+		_, _ = io.ReadAll(resp.Body)
+		entry = rec.next(t)
+		assert.Equal(t, upstreamUsage.CompletionTokens, entry.Usage.CompletionTokens)
+
+		resp = request(t, http.MethodGet, srv.url+"/v1/models", open, "")
+		_, _ = io.ReadAll(resp.Body)
+		assert.Zero(t, rec.next(t).Usage, "requests that reach no model use no tokens")
+
+		resp = request(t, http.MethodGet, srv.url+"/metrics", "", "")
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		text := string(body)
+		assert.Contains(t, text, `llm_fast_wrapper_tokens_total{backend="default",model="metered",tenant="acme",type="prompt"}`)
+		assert.Contains(t, text, `llm_fast_wrapper_tokens_total{backend="default",model="metered",tenant="acme",type="completion"}`)
+	}, handler.WithAuth(store), handler.WithAuditLog(rec))
+}
@@ -0,0 +1,130 @@
+package conformance_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/raja.aiml/llm-fast-wrapper/api/handler"
+	"github.com/raja.aiml/llm-fast-wrapper/internal/config"
+	"github.com/raja.aiml/llm-fast-wrapper/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// invalidParam checks that resp is a 400 naming param.
+func invalidParam(t *testing.T, resp *http.Response, param string) {
+	t.Helper()
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	e := decodeEnvelope(t, resp)
+	assert.Equal(t, "invalid_request_error", e.Error.Type)
+	require.NotNil(t, e.Error.Param, e.Error.Message)
+	assert.Equal(t, param, *e.Error.Param, e.Error.Message)
+}
+
+func TestValidation_Params(t *testing.T) {
+	tests := []struct {
+		name, path, body, param string
+	}{
+		{"no messages", "/v1/chat/completions", `{"model":"echo","messages":[]}`, "messages"},
+		{"unknown role", "/v1/chat/completions", `{"model":"echo","messages":[{"role":"user","content":"a"},{"role":"bot","content":"b"}]}`, "messages[1].role"},
+		{"temperature range", "/v1/chat/completions", fmt.Sprintf(`{"model":"echo","temperature":3,"messages":%s}`, hiMessages), "temperature"},
+		{"temperature type", "/v1/chat/completions", fmt.Sprintf(`{"model":"echo","temperature":"hot","messages":%s}`, hiMessages), "temperature"},
+		{"n range", "/v1/chat/completions", fmt.Sprintf(`{"model":"echo","n":0,"messages":%s}`, hiMessages), "n"},
+		{"content type", "/v1/chat/completions", `{"model":"echo","messages":[{"role":"user","content":7}]}`, "messages[0].content"},
+		{"image part", "/v1/chat/completions", `{"model":"echo","messages":[{"role":"user","content":[{"type":"image_url","image_url":{"url":"https://example.com/a.png"}}]}]}`, "messages[0].content[0].type"},
+		{"completions max_tokens", "/v1/completions", `{"model":"echo","prompt":"hi","max_tokens":-1}`, "max_tokens"},
+		{"embeddings input", "/v1/embeddings", `{"model":"m","input":[]}`, "input"},
+		{"embeddings model", "/v1/embeddings", `{"model":"m","input":"hi"}`, "model"},
+	}
+	eachFramework(t, func(t *testing.T, srv target) {
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				invalidParam(t, request(t, http.MethodPost, srv.url+tt.path, "", tt.body), tt.param)
+			})
+		}
+		assert.Nil(t, srv.fake.last, "invalid requests never reach the upstream")
+	}, handler.WithEmbeddings(&fakeEmbedder{}))
+}
+
+func TestValidation_ContentParts(t *testing.T) {
+	eachFramework(t, func(t *testing.T, srv target) {
+		resp := request(t, http.MethodPost, srv.url+"/v1/chat/completions", "",
+			`{"model":"echo","messages":[{"role":"user","content":[{"type":"text","text":"Hello"},{"type":"text","text":" world"}]}]}`)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		srv.fake.mu.Lock()
+		defer srv.fake.mu.Unlock()
+		require.Len(t, srv.fake.last.Messages, 1)
+		assert.Equal(t, "Hello world", srv.fake.last.Messages[0].Content, "text parts are joined")
+	})
+}
+
+const hiMessages = `[{"role":"user","content":"hi"}]`
+
+func TestValidation_Limits(t *testing.T) {
+	cfg := config.ValidationConfig{MaxBodyBytes: 1024, MaxMessages: 2, MaxPromptTokens: 50}
+	eachFramework(t, func(t *testing.T, srv target) {
+		resp := request(t, http.MethodPost, srv.url+"/v1/chat/completions", "", fmt.Sprintf(chatBody, "echo"))
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		resp = request(t, http.MethodPost, srv.url+"/v1/chat/completions", "",
+			`{"model":"echo","messages":[{"role":"user","content":"a"},{"role":"assistant","content":"b"},{"role":"user","content":"c"}]}`)
+		invalidParam(t, resp, "messages")
+
+		long := strings.Repeat("word ", 100)
+		resp = request(t, http.MethodPost, srv.url+"/v1/chat/completions", "",
+			`{"model":"echo","messages":[{"role":"user","content":"`+long+`"}]}`)
+		invalidParam(t, resp, "messages")
+
+		big := `{"model":"echo","messages":[{"role":"user","content":"` + strings.Repeat("x", 2048) + `"}]}`
+		resp = request(t, http.MethodPost, srv.url+"/v1/chat/completions", "", big)
+		require.Equal(t, http.StatusRequestEntityTooLarge, resp.StatusCode)
+		e := decodeEnvelope(t, resp)
+		require.NotNil(t, e.Error.Code)
+		assert.Equal(t, "request_too_large", *e.Error.Code)
+
+		// bodies without a Content-Length are cut off while reading
+		req, err := http.NewRequest(http.MethodPost, srv.url+"/v1/chat/completions", io.MultiReader(strings.NewReader(big)))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		resp, err = http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusRequestEntityTooLarge, resp.StatusCode)
+	}, handler.WithValidation(cfg))
+}
+
+func TestValidation_TenantModels(t *testing.T) {
+	store, open, limited, _ := keyStore(t)
+	reg, err := models.NewRegistry([]models.Model{{ID: "echo", Backend: "mock"}, {ID: "other", Backend: "mock"}})
+	require.NoError(t, err)
+	cfg := config.ValidationConfig{AllowedModels: map[string][]string{"acme": {"echo"}}}
+	eachFramework(t, func(t *testing.T, srv target) {
+		resp := request(t, http.MethodPost, srv.url+"/v1/chat/completions", open, fmt.Sprintf(chatBody, "echo"))
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		resp = request(t, http.MethodPost, srv.url+"/v1/chat/completions", open, fmt.Sprintf(chatBody, "other"))
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		e := decodeEnvelope(t, resp)
+		assert.Equal(t, "invalid_request_error", e.Error.Type)
+		assert.Equal(t, "tenant 'acme' is not allowed to use model 'other'", e.Error.Message)
+		require.NotNil(t, e.Error.Param)
+		assert.Equal(t, "model", *e.Error.Param)
+
+		resp = request(t, http.MethodGet, srv.url+"/v1/models", open, "")
+		var list models.List
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&list))
+		require.Len(t, list.Data, 1)
+		assert.Equal(t, "echo", list.Data[0].ID)
+
+		resp = request(t, http.MethodGet, srv.url+"/v1/models/other", open, "")
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+		// other tenants are not restricted
+		resp = request(t, http.MethodPost, srv.url+"/v1/chat/completions", limited, fmt.Sprintf(chatBody, "echo"))
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	}, handler.WithAuth(store), handler.WithModels(reg), handler.WithValidation(cfg))
+}
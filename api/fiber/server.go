@@ -1,66 +1,99 @@
 package fiberapi
 
 import (
-	"bufio"
-	"encoding/json"
+	"context"
+	"errors"
+	"fmt"
 	"log"
+	"net"
 
 	"github.com/gofiber/fiber/v2"
-	"github.com/raja.aiml/llm-fast-wrapper/internal/llm"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
+	"github.com/gofiber/fiber/v2/middleware/recover"
+	"github.com/gofiber/fiber/v2/utils"
+	"github.com/raja.aiml/llm-fast-wrapper/api/handler"
+	"github.com/raja.aiml/llm-fast-wrapper/internal/config"
+	"github.com/raja.aiml/llm-fast-wrapper/internal/metrics"
 )
 
-func Start() error {
-	app := fiber.New()
+// NewApp builds the Fiber app serving the API routes of h. Unless cfg sets
+// its own ErrorHandler, errors raised by Fiber itself, such as unknown routes
+// or oversized bodies, are answered with OpenAI error bodies. cfg.BodyLimit
+// defaults to the limit of h.
+func NewApp(h *handler.Handler, cfg fiber.Config) *fiber.App {
+	if cfg.ErrorHandler == nil {
+		cfg.ErrorHandler = errorHandler(h)
+	}
+	if cfg.BodyLimit == 0 {
+		cfg.BodyLimit = int(h.MaxBodyBytes())
+	}
+	app := fiber.New(cfg)
+	app.Use(recover.New())
 
-	client := llm.NewOpenAIStreamer()
+	app.Post("/v1/chat/completions", Handle(h.Wrap(h.ChatCompletions)))
+	app.Post("/v1/completions", Handle(h.Wrap(h.Completions)))
+	app.Post("/v1/embeddings", Handle(h.Wrap(h.Embeddings)))
+	app.Get("/v1/models", Handle(h.Wrap(h.ListModels)))
+	app.Get("/v1/models/+", Handle(h.Wrap(h.GetModel)))
+	app.Get("/v1/realtime", Handle(h.Wrap(h.Realtime)))
+	// scraped by Prometheus, so served without auth or rate limits
+	app.Get("/metrics", adaptor.HTTPHandler(metrics.Handler()))
+	return app
+}
 
-	app.Post("/v1/chat/completions", func(c *fiber.Ctx) error {
-		var req struct {
-			Model    string `json:"model"`
-			Messages []struct {
-				Role    string `json:"role"`
-				Content string `json:"content"`
-			} `json:"messages"`
-			Stream bool `json:"stream"`
-		}
-		if err := c.BodyParser(&req); err != nil {
-			return fiber.NewError(fiber.StatusBadRequest, err.Error())
-		}
-		if !req.Stream {
-			return fiber.NewError(fiber.StatusBadRequest, "stream must be true")
+// errorHandler writes the errors returned by Fiber handlers and middleware.
+// Panics recovered by the recover middleware are reported without their
+// value.
+func errorHandler(h *handler.Handler) fiber.ErrorHandler {
+	return func(c *fiber.Ctx, err error) error {
+		t := &transport{c: c}
+		var fe *fiber.Error
+		switch {
+		case errors.As(err, &fe) && fe.Code == fiber.StatusNotFound:
+			handler.NotFound(t)
+		case errors.As(err, &fe) && fe.Code == fiber.StatusRequestEntityTooLarge:
+			h.BodyTooLarge(t)
+		case errors.As(err, &fe):
+			handler.WriteError(t, handler.NewError(fe.Code, "", fe.Message))
+		default:
+			handler.WriteError(t, handler.NewError(fiber.StatusInternalServerError, "", utils.StatusMessage(fiber.StatusInternalServerError)))
 		}
+		return nil
+	}
+}
 
-		var prompt string
-		for _, m := range req.Messages {
-			if m.Role == "user" {
-				prompt += m.Content + " "
-			}
-		}
+// Start listens on the configured address and serves until ctx is canceled.
+func Start(ctx context.Context, cfg config.ListenConfig, h *handler.Handler) error {
+	ln, err := cfg.Listen()
+	if err != nil {
+		return err
+	}
+	log.Printf("[INFO] Fiber server listening on %s (tls=%v)", ln.Addr(), cfg.TLSEnabled())
+	return Serve(ctx, ln, cfg, h)
+}
 
-		ch, err := client.Stream(c.Context(), prompt)
-		if err != nil {
-			return fiber.NewError(fiber.StatusInternalServerError, err.Error())
-		}
+// Serve serves h on ln. When ctx is canceled it stops accepting connections
+// and waits up to cfg.ShutdownTimeout for in-flight requests to finish.
+func Serve(ctx context.Context, ln net.Listener, cfg config.ListenConfig, h *handler.Handler) error {
+	app := NewApp(h, fiber.Config{
+		ReadTimeout:           cfg.ReadTimeout,
+		WriteTimeout:          cfg.WriteTimeout,
+		IdleTimeout:           cfg.IdleTimeout,
+		DisableStartupMessage: true,
+	})
 
-		c.Set("Content-Type", "text/event-stream")
-		c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
-			enc := json.NewEncoder(w)
-			for chunk := range ch {
-				w.WriteString("data: ")
-				if err := enc.Encode(chunk); err != nil {
-					log.Println("encode error:", err)
-					return
-				}
-				w.WriteString("\n\n")
-				w.Flush()
-			}
-			w.WriteString("data: [DONE]\n\n")
-			w.Flush()
-		})
+	errCh := make(chan error, 1)
+	go func() { errCh <- app.Listener(ln) }()
 
-		return nil
-	})
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+	}
 
-	log.Println("[INFO] Fiber server listening on :8080")
-	return app.Listen(":8080")
+	log.Printf("[INFO] Fiber server draining connections (deadline %s)", cfg.ShutdownTimeout)
+	if err := app.ShutdownWithTimeout(cfg.ShutdownTimeout); err != nil {
+		return fmt.Errorf("drain incomplete: %w", err)
+	}
+	return nil
 }
@@ -0,0 +1,74 @@
+package fiberapi
+
+import (
+	"bufio"
+	"context"
+	"net/url"
+
+	"github.com/fasthttp/websocket"
+	"github.com/gofiber/fiber/v2"
+	"github.com/raja.aiml/llm-fast-wrapper/api/handler"
+	"github.com/valyala/fasthttp"
+)
+
+// upgrader accepts any origin: sockets authenticate with a key, not cookies,
+// so cross-site pages cannot ride on a user's session.
+var upgrader = websocket.FastHTTPUpgrader{
+	Subprotocols: []string{handler.RealtimeSubprotocol},
+	CheckOrigin:  func(*fasthttp.RequestCtx) bool { return true },
+}
+
+// Handle adapts a transport-neutral handler to Fiber.
+func Handle(fn handler.Func) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		fn(&transport{c: c})
+		return nil
+	}
+}
+
+// transport implements handler.Transport on top of a Fiber context.
+type transport struct {
+	c *fiber.Ctx
+}
+
+func (t *transport) Context() context.Context { return t.c.UserContext() }
+
+func (t *transport) SetContext(ctx context.Context) { t.c.SetUserContext(ctx) }
+
+// Path unescapes the raw path Fiber reports so both adapters agree.
+func (t *transport) Path() string {
+	p, err := url.PathUnescape(t.c.Path())
+	if err != nil {
+		return t.c.Path()
+	}
+	return p
+}
+
+func (t *transport) ClientIP() string { return t.c.IP() }
+
+func (t *transport) Header(name string) string { return t.c.Get(name) }
+
+func (t *transport) Body() ([]byte, error) { return t.c.Body(), nil }
+
+func (t *transport) SetHeader(name, value string) { t.c.Set(name, value) }
+
+func (t *transport) JSON(status int, v any) {
+	_ = t.c.Status(status).JSON(v)
+}
+
+// Stream hands fn to fasthttp's body stream writer, which runs after the
+// Fiber handler has returned and the Ctx has been released.
+func (t *transport) Stream(fn func(w handler.StreamWriter)) {
+	t.c.Status(fiber.StatusOK)
+	t.c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		fn(w)
+	})
+}
+
+// Upgrade hijacks the fasthttp connection; fn runs after the Fiber handler
+// has returned, and fasthttp closes the connection when fn returns.
+func (t *transport) Upgrade(fn func(conn handler.Conn)) error {
+	return upgrader.Upgrade(t.c.Context(), func(conn *websocket.Conn) {
+		fn(conn)
+	})
+}
@@ -1,80 +1,106 @@
 package ginapi
 
 import (
-	"encoding/json"
+	"context"
+	"fmt"
 	"log"
+	"net"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
-	"github.com/raja.aiml/llm-fast-wrapper/internal/llm"
+	"github.com/raja.aiml/llm-fast-wrapper/api/handler"
+	"github.com/raja.aiml/llm-fast-wrapper/internal/config"
+	"github.com/raja.aiml/llm-fast-wrapper/internal/metrics"
 )
 
-func Start() error {
+// NewRouter builds the Gin engine serving the API routes of h.
+func NewRouter(h *handler.Handler) (*gin.Engine, error) {
 	// set Gin to release mode to disable debug logs and warnings in production
 	gin.SetMode(gin.ReleaseMode)
 	// create a new Gin engine and attach Logger and Recovery middleware
 	r := gin.New()
-	r.Use(gin.Logger(), gin.Recovery())
+	r.Use(gin.Logger(), gin.CustomRecovery(recovered), limitBody(h.MaxBodyBytes()))
 	// disable trusting all proxies by default; configure as needed for your deployment
 	if err := r.SetTrustedProxies(nil); err != nil {
-		return err
+		return nil, err
 	}
-	client := llm.NewOpenAIStreamer()
 
-	r.POST("/v1/chat/completions", func(c *gin.Context) {
-		var req struct {
-			Model    string `json:"model"`
-			Messages []struct {
-				Role    string `json:"role"`
-				Content string `json:"content"`
-			} `json:"messages"`
-			Stream bool `json:"stream"`
-		}
-		if err := c.BindJSON(&req); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-			return
-		}
-		if !req.Stream {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "stream must be true"})
-			return
-		}
+	r.POST("/v1/chat/completions", Handle(h.Wrap(h.ChatCompletions)))
+	r.POST("/v1/completions", Handle(h.Wrap(h.Completions)))
+	r.POST("/v1/embeddings", Handle(h.Wrap(h.Embeddings)))
+	r.GET("/v1/models", Handle(h.Wrap(h.ListModels)))
+	r.GET("/v1/models/*id", Handle(h.Wrap(h.GetModel)))
+	r.GET("/v1/realtime", Handle(h.Wrap(h.Realtime)))
+	// scraped by Prometheus, so served without auth or rate limits
+	r.GET("/metrics", gin.WrapH(metrics.Handler()))
+
+	// answer unknown routes and methods with OpenAI error bodies, as Fiber does
+	r.HandleMethodNotAllowed = true
+	r.NoRoute(Handle(handler.NotFound))
+	r.NoMethod(Handle(func(t handler.Transport) {
+		handler.WriteError(t, handler.NewError(http.StatusMethodNotAllowed, "", http.StatusText(http.StatusMethodNotAllowed)))
+	}))
+	return r, nil
+}
 
-		var prompt string
-		for _, m := range req.Messages {
-			if m.Role == "user" {
-				prompt += m.Content + " "
-			}
-		}
+// limitBody stops reading request bodies after limit bytes; the handlers
+// answer the read error with a 413.
+func limitBody(limit int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit)
+		c.Next()
+	}
+}
 
-		ch, err := client.Stream(c.Request.Context(), prompt)
-		if err != nil {
-			c.String(http.StatusInternalServerError, err.Error())
-			return
-		}
+// recovered answers a request whose handler panicked, unless the response
+// is already under way.
+func recovered(c *gin.Context, _ any) {
+	if !c.Writer.Written() {
+		handler.WriteError(&transport{c: c}, handler.NewError(http.StatusInternalServerError, "", http.StatusText(http.StatusInternalServerError)))
+	}
+	c.Abort()
+}
 
-		c.Writer.Header().Set("Content-Type", "text/event-stream")
-		c.Writer.WriteHeader(http.StatusOK)
-		c.Writer.Flush()
-		enc := json.NewEncoder(c.Writer)
-		for chunk := range ch {
-			if _, err := c.Writer.Write([]byte("data: ")); err != nil {
-				log.Println("write error", err)
-				return
-			}
-			if err := enc.Encode(chunk); err != nil {
-				log.Println("encode error", err)
-				return
-			}
-			if _, err := c.Writer.Write([]byte("\n")); err != nil {
-				log.Println("write error", err)
-				return
-			}
-			c.Writer.Write([]byte("\n"))
-			c.Writer.Flush()
-		}
-		c.Writer.Write([]byte("data: [DONE]\n\n"))
-		c.Writer.Flush()
-	})
+// Start listens on the configured address and serves until ctx is canceled.
+func Start(ctx context.Context, cfg config.ListenConfig, h *handler.Handler) error {
+	ln, err := cfg.Listen()
+	if err != nil {
+		return err
+	}
+	log.Printf("[INFO] Gin server listening on %s (tls=%v)", ln.Addr(), cfg.TLSEnabled())
+	return Serve(ctx, ln, cfg, h)
+}
 
-	return r.Run(":8080")
+// Serve serves h on ln. When ctx is canceled it stops accepting connections
+// and waits up to cfg.ShutdownTimeout for in-flight requests to finish before
+// closing the remaining connections.
+func Serve(ctx context.Context, ln net.Listener, cfg config.ListenConfig, h *handler.Handler) error {
+	r, err := NewRouter(h)
+	if err != nil {
+		return err
+	}
+	srv := &http.Server{
+		Handler:      r,
+		ReadTimeout:  cfg.ReadTimeout,
+		WriteTimeout: cfg.WriteTimeout,
+		IdleTimeout:  cfg.IdleTimeout,
+	}
+
+	errCh := make(chan error, 1)
+	go func() { errCh <- srv.Serve(ln) }()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+	}
+
+	log.Printf("[INFO] Gin server draining connections (deadline %s)", cfg.ShutdownTimeout)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		_ = srv.Close()
+		return fmt.Errorf("drain incomplete: %w", err)
+	}
+	return nil
 }
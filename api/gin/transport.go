@@ -0,0 +1,89 @@
+package ginapi
+
+import (
+	"context"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/raja.aiml/llm-fast-wrapper/api/handler"
+)
+
+// upgrader accepts any origin: sockets authenticate with a key, not cookies,
+// so cross-site pages cannot ride on a user's session.
+var upgrader = websocket.Upgrader{
+	Subprotocols: []string{handler.RealtimeSubprotocol},
+	CheckOrigin:  func(*http.Request) bool { return true },
+}
+
+// Handle adapts a transport-neutral handler to Gin.
+func Handle(fn handler.Func) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		fn(&transport{c: c})
+	}
+}
+
+// transport implements handler.Transport on top of a Gin context.
+type transport struct {
+	c    *gin.Context
+	body []byte
+}
+
+func (t *transport) Context() context.Context { return t.c.Request.Context() }
+
+func (t *transport) SetContext(ctx context.Context) {
+	t.c.Request = t.c.Request.WithContext(ctx)
+}
+
+func (t *transport) Path() string { return t.c.Request.URL.Path }
+
+func (t *transport) Header(name string) string { return t.c.GetHeader(name) }
+
+func (t *transport) ClientIP() string { return t.c.ClientIP() }
+
+// Body reads the request body once and keeps it for later callers.
+func (t *transport) Body() ([]byte, error) {
+	if t.body != nil {
+		return t.body, nil
+	}
+	body, err := io.ReadAll(t.c.Request.Body)
+	if err != nil {
+		return nil, err
+	}
+	t.body = body
+	return body, nil
+}
+
+func (t *transport) SetHeader(name, value string) { t.c.Header(name, value) }
+
+func (t *transport) JSON(status int, v any) { t.c.JSON(status, v) }
+
+func (t *transport) Stream(fn func(w handler.StreamWriter)) {
+	t.c.Status(http.StatusOK)
+	t.c.Writer.WriteHeaderNow()
+	t.c.Writer.Flush()
+	fn(streamWriter{t.c.Writer})
+}
+
+func (t *transport) Upgrade(fn func(conn handler.Conn)) error {
+	conn, err := upgrader.Upgrade(t.c.Writer, t.c.Request, nil)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	fn(conn)
+	return nil
+}
+
+// streamWriter exposes gin.ResponseWriter as a handler.StreamWriter.
+type streamWriter struct {
+	w gin.ResponseWriter
+}
+
+func (s streamWriter) Write(p []byte) (int, error) { return s.w.Write(p) }
+
+func (s streamWriter) Flush() error {
+	s.w.Flush()
+	return nil
+}
@@ -0,0 +1,24 @@
+package handler
+
+import "github.com/raja.aiml/llm-fast-wrapper/internal/auditlog/prompt"
+
+// WithAuditLog records every API request through l: the request body (the
+// path for requests without one), the assembled response text, the API key
+// and tenant as the token (see tokenOf), the status and the token usage of
+// chat responses. Streams that fail after their headers are sent are
+// recorded with the status of the failure, and requests rejected by
+// middleware are recorded too. l should not block (see prompt.AsyncLogger).
+func WithAuditLog(l prompt.Logger) Option {
+	return func(h *Handler) { h.audit = l }
+}
+
+func (h *Handler) auditExchange(x *exchange) {
+	if h.audit != nil {
+		usage := prompt.Usage{
+			PromptTokens:     x.usage.PromptTokens,
+			CompletionTokens: x.usage.CompletionTokens,
+			TotalTokens:      x.usage.TotalTokens,
+		}
+		_ = h.audit.LogRequest(x.prompt, x.response.String(), x.token, x.status, usage, x.start)
+	}
+}
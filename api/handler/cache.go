@@ -0,0 +1,108 @@
+package handler
+
+import (
+	"context"
+	"strings"
+
+	"github.com/raja.aiml/llm-fast-wrapper/internal/llm"
+)
+
+// ResponseCache answers repeated chat requests from earlier responses.
+// responsecache.Cache satisfies it.
+type ResponseCache interface {
+	// Lookup returns the cached chunk sequence for req, if any.
+	Lookup(ctx context.Context, tenant string, req *llm.ChatCompletionRequest) ([]llm.ChatCompletionChunk, bool)
+	// Record forwards ch and caches it once the stream completes.
+	Record(ctx context.Context, tenant string, req *llm.ChatCompletionRequest, ch <-chan llm.ChatCompletionChunk) <-chan llm.ChatCompletionChunk
+}
+
+// WithResponseCache serves chat requests from c when possible. Responses
+// carry X-Cache: HIT or MISS. Clients can skip the lookup with
+// "Cache-Control: no-cache" and keep a response out of the cache with
+// "Cache-Control: no-store".
+func WithResponseCache(c ResponseCache) Option {
+	return func(h *Handler) { h.cache = c }
+}
+
+// WithSemanticCache consults c after the exact-match cache. Semantic hits
+// carry X-Cache: HIT and X-Cache-Source: semantic. Clients opt out per
+// request with "X-Semantic-Cache: off".
+func WithSemanticCache(c ResponseCache) Option {
+	return func(h *Handler) { h.semantic = c }
+}
+
+// lookupCache consults the exact-match and semantic caches in turn and sets
+// X-Cache when either is configured.
+func (h *Handler) lookupCache(t Transport, tenant string, req *llm.ChatCompletionRequest) ([]llm.ChatCompletionChunk, bool) {
+	if (h.cache == nil && h.semantic == nil) || noCache(t) {
+		return nil, false
+	}
+	if h.cache != nil {
+		if chunks, ok := h.cache.Lookup(t.Context(), tenant, req); ok {
+			t.SetHeader("X-Cache", "HIT")
+			t.SetHeader("X-Cache-Source", "exact")
+			return chunks, true
+		}
+	}
+	if h.semantic != nil && !semanticOptOut(t) {
+		if chunks, ok := h.semantic.Lookup(t.Context(), tenant, req); ok {
+			t.SetHeader("X-Cache", "HIT")
+			t.SetHeader("X-Cache-Source", "semantic")
+			return chunks, true
+		}
+	}
+	t.SetHeader("X-Cache", "MISS")
+	return nil, false
+}
+
+// recorder stores the response streamed on ch.
+type recorder func(ctx context.Context, ch <-chan llm.ChatCompletionChunk) <-chan llm.ChatCompletionChunk
+
+// cacheRecorder returns the recorder for the response to req. It reads the
+// request headers up front because streamed responses are recorded once the
+// upstream starts, possibly after the Transport has been released.
+func (h *Handler) cacheRecorder(t Transport, tenant string, req *llm.ChatCompletionRequest) recorder {
+	store := !noStore(t)
+	semantic := store && h.semantic != nil && !semanticOptOut(t)
+	return func(ctx context.Context, ch <-chan llm.ChatCompletionChunk) <-chan llm.ChatCompletionChunk {
+		if store && h.cache != nil {
+			ch = h.cache.Record(ctx, tenant, req, ch)
+		}
+		if semantic {
+			ch = h.semantic.Record(ctx, tenant, req, ch)
+		}
+		return ch
+	}
+}
+
+// replay turns cached chunks back into a closed stream.
+func replay(chunks []llm.ChatCompletionChunk) <-chan llm.ChatCompletionChunk {
+	ch := make(chan llm.ChatCompletionChunk, len(chunks))
+	for _, c := range chunks {
+		ch <- c
+	}
+	close(ch)
+	return ch
+}
+
+func noCache(t Transport) bool { return cacheDirective(t, "no-cache") }
+
+func noStore(t Transport) bool { return cacheDirective(t, "no-store") }
+
+func cacheDirective(t Transport, directive string) bool {
+	for _, d := range strings.Split(t.Header("Cache-Control"), ",") {
+		if strings.EqualFold(strings.TrimSpace(d), directive) {
+			return true
+		}
+	}
+	return false
+}
+
+// semanticOptOut reports whether the client disabled semantic matching.
+func semanticOptOut(t Transport) bool {
+	switch strings.ToLower(strings.TrimSpace(t.Header("X-Semantic-Cache"))) {
+	case "off", "false", "0":
+		return true
+	}
+	return false
+}
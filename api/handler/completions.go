@@ -0,0 +1,51 @@
+package handler
+
+import (
+	"context"
+
+	"github.com/raja.aiml/llm-fast-wrapper/internal/llm"
+)
+
+// Completions handles the legacy POST /v1/completions in both its streaming
+// and non-streaming forms. The prompt goes upstream as a chat request with a
+// single user message; the answer comes back as text_completion objects.
+func (h *Handler) Completions(t Transport) {
+	var req llm.CompletionRequest
+	if !h.decodeBody(t, &req) {
+		return
+	}
+	h.complete(t, req.ChatRequest(), &textFormat{req: &req, echoed: make(map[int]bool)})
+}
+
+// textFormat renders text_completion objects, applying the stop sequences
+// locally and prepending the prompt to each choice when echo is set.
+type textFormat struct {
+	req *llm.CompletionRequest
+	// echoed records the choices whose first chunk carried the prompt.
+	echoed map[int]bool
+}
+
+func (f *textFormat) chunks(ctx context.Context, ch <-chan llm.ChatCompletionChunk) <-chan llm.ChatCompletionChunk {
+	return llm.StopAt(ctx, ch, f.req.Stop)
+}
+
+func (f *textFormat) event(chunk llm.ChatCompletionChunk) any {
+	completion := llm.TextChunk(chunk)
+	for i, choice := range completion.Choices {
+		if f.req.Echo && !f.echoed[choice.Index] {
+			f.echoed[choice.Index] = true
+			completion.Choices[i].Text = string(f.req.Prompt) + choice.Text
+		}
+	}
+	return completion
+}
+
+func (f *textFormat) response(completion *llm.ChatCompletion) any {
+	text := llm.TextResponse(completion)
+	if f.req.Echo {
+		for i := range text.Choices {
+			text.Choices[i].Text = string(f.req.Prompt) + text.Choices[i].Text
+		}
+	}
+	return text
+}
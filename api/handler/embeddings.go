@@ -0,0 +1,140 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+
+	"github.com/raja.aiml/llm-fast-wrapper/internal/tokenizer"
+)
+
+// Embedder produces embeddings for a batch of texts, keyed by text, with a
+// single model. embeddings.Service satisfies it.
+type Embedder interface {
+	GetBatch(ctx context.Context, texts []string) (map[string][]float32, error)
+	Model() string
+}
+
+// WithEmbeddings serves POST /v1/embeddings from e.
+func WithEmbeddings(e Embedder) Option {
+	return func(h *Handler) { h.embedder = e }
+}
+
+// embeddingRequest is the body of POST /v1/embeddings.
+type embeddingRequest struct {
+	Model          string         `json:"model"`
+	Input          embeddingInput `json:"input"`
+	EncodingFormat string         `json:"encoding_format,omitempty"`
+	User           string         `json:"user,omitempty"`
+}
+
+// embeddingInput accepts either a single string or an array of strings.
+type embeddingInput []string
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (in *embeddingInput) UnmarshalJSON(data []byte) error {
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte(`"`)) {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		*in = embeddingInput{s}
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return errors.New("input must be a string or an array of strings")
+	}
+	*in = list
+	return nil
+}
+
+type embeddingResponse struct {
+	Object string          `json:"object"`
+	Data   []embeddingData `json:"data"`
+	Model  string          `json:"model"`
+	Usage  embeddingUsage  `json:"usage"`
+}
+
+type embeddingData struct {
+	Object    string `json:"object"`
+	Index     int    `json:"index"`
+	Embedding any    `json:"embedding"`
+}
+
+type embeddingUsage struct {
+	PromptTokens int `json:"prompt_tokens"`
+	TotalTokens  int `json:"total_tokens"`
+}
+
+// Embeddings handles POST /v1/embeddings. Only the embedder's model is
+// served; a request without a model uses it.
+func (h *Handler) Embeddings(t Transport) {
+	if h.embedder == nil {
+		WriteError(t, NewError(http.StatusNotImplemented, "", "embeddings are not configured on this server"))
+		return
+	}
+	var req embeddingRequest
+	if !h.decodeBody(t, &req) {
+		return
+	}
+	if len(req.Input) == 0 {
+		WriteError(t, NewError(http.StatusBadRequest, "", "input must not be empty").WithParam("input"))
+		return
+	}
+	if req.EncodingFormat != "" && req.EncodingFormat != "float" && req.EncodingFormat != "base64" {
+		WriteError(t, NewError(http.StatusBadRequest, "", fmt.Sprintf("unsupported encoding_format %q", req.EncodingFormat)).WithParam("encoding_format"))
+		return
+	}
+	model := h.embedder.Model()
+	if req.Model == "" {
+		req.Model = model
+	}
+	if req.Model != model {
+		WriteError(t, NewError(http.StatusBadRequest, "model_not_supported",
+			fmt.Sprintf("this server embeds with model '%s', not '%s'", model, req.Model)).WithParam("model"))
+		return
+	}
+	if !h.checkModel(t, req.Model) {
+		return
+	}
+
+	vectors, err := h.embedder.GetBatch(t.Context(), req.Input)
+	if err != nil {
+		WriteError(t, err)
+		return
+	}
+
+	resp := embeddingResponse{Object: "list", Model: model, Data: make([]embeddingData, 0, len(req.Input))}
+	for i, text := range req.Input {
+		vec, ok := vectors[text]
+		if !ok {
+			WriteError(t, fmt.Errorf("no embedding returned for input %d", i))
+			return
+		}
+		var embedding any = vec
+		if req.EncodingFormat == "base64" {
+			embedding = encodeBase64(vec)
+		}
+		resp.Data = append(resp.Data, embeddingData{Object: "embedding", Index: i, Embedding: embedding})
+		resp.Usage.PromptTokens += tokenizer.CountTokens(model, text)
+	}
+	resp.Usage.TotalTokens = resp.Usage.PromptTokens
+	t.JSON(http.StatusOK, resp)
+}
+
+// encodeBase64 packs vec as little-endian float32s, the layout OpenAI uses
+// for encoding_format=base64.
+func encodeBase64(vec []float32) string {
+	buf := make([]byte, 4*len(vec))
+	for i, v := range vec {
+		binary.LittleEndian.PutUint32(buf[4*i:], math.Float32bits(v))
+	}
+	return base64.StdEncoding.EncodeToString(buf)
+}
@@ -0,0 +1,149 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+
+	openai "github.com/openai/openai-go"
+	"github.com/raja.aiml/llm-fast-wrapper/internal/llm"
+	"github.com/raja.aiml/llm-fast-wrapper/internal/routing"
+)
+
+// Error is an API error with the HTTP status it is answered with. It is
+// written as the OpenAI error envelope,
+// {"error": {"message", "type", "param", "code"}}: as the response body, or
+// as the last SSE event of a stream that fails after its headers are sent.
+type Error struct {
+	Status  int
+	Message string
+	// Type is derived from Status unless set.
+	Type string
+	// Param names the request parameter at fault, if any.
+	Param string
+	// Code is a machine-readable error code, if any.
+	Code string
+}
+
+// NewError returns an Error with the type derived from status. code may be
+// empty.
+func NewError(status int, code, message string) *Error {
+	return &Error{Status: status, Message: message, Type: errorType(status), Code: code}
+}
+
+// WithParam sets the request parameter at fault and returns e.
+func (e *Error) WithParam(param string) *Error {
+	e.Param = param
+	return e
+}
+
+func (e *Error) Error() string { return e.Message }
+
+// body returns the wire form of e.
+func (e *Error) body() errorBody {
+	detail := errorDetail{Message: e.Message, Type: e.Type}
+	if detail.Type == "" {
+		detail.Type = errorType(e.Status)
+	}
+	if e.Param != "" {
+		detail.Param = &e.Param
+	}
+	if e.Code != "" {
+		detail.Code = &e.Code
+	}
+	return errorBody{Error: detail}
+}
+
+// errorBody is the OpenAI error envelope: {"error": {...}}.
+type errorBody struct {
+	Error errorDetail `json:"error"`
+}
+
+type errorDetail struct {
+	Message string  `json:"message"`
+	Type    string  `json:"type"`
+	Param   *string `json:"param"`
+	Code    *string `json:"code"`
+}
+
+// WriteError answers the request with err as an OpenAI-style error body; see
+// AsError for how errors other than *Error are reported.
+func WriteError(t Transport, err error) {
+	e := AsError(err)
+	t.JSON(e.Status, e.body())
+}
+
+// AsError returns the Error in err's chain. Errors of the upstream API keep
+// its status when they are the client's fault and become a 502 otherwise,
+// failures to reach the upstream are a 502 and open circuits a 503, unknown
+// models are a 404, and anything else is a 500. The messages of upstream
+// failures name no hosts; the details are logged instead.
+func AsError(err error) *Error {
+	var e *Error
+	if errors.As(err, &e) {
+		return e
+	}
+	var apiErr *openai.Error
+	if errors.As(err, &apiErr) {
+		return upstreamError(apiErr)
+	}
+	if errors.Is(err, llm.ErrModelNotFound) {
+		return NewError(http.StatusNotFound, "model_not_found", err.Error())
+	}
+	if errors.Is(err, routing.ErrCircuitOpen) {
+		log.Printf("[WARN] %v", err)
+		return NewError(http.StatusServiceUnavailable, "upstream_unavailable", "upstream temporarily unavailable, retry later")
+	}
+	var urlErr *url.Error
+	var netErr net.Error
+	if errors.As(err, &urlErr) || errors.As(err, &netErr) {
+		log.Printf("[ERROR] upstream request failed: %v", err)
+		return NewError(http.StatusBadGateway, "upstream_error", "upstream request failed")
+	}
+	return NewError(http.StatusInternalServerError, "", err.Error())
+}
+
+// upstreamError maps an error answered by the upstream API. Its message is
+// passed on rather than err.Error(), which names the upstream URL.
+func upstreamError(err *openai.Error) *Error {
+	message := err.Message
+	if message == "" {
+		message = http.StatusText(err.StatusCode)
+	}
+	switch err.StatusCode {
+	case http.StatusBadRequest, http.StatusNotFound, http.StatusRequestEntityTooLarge,
+		http.StatusUnprocessableEntity, http.StatusTooManyRequests:
+		e := NewError(err.StatusCode, err.Code, message).WithParam(err.Param)
+		if err.Type != "" {
+			e.Type = err.Type
+		}
+		return e
+	default:
+		// the upstream failed, or refused our credentials: not something
+		// the client can fix
+		return NewError(http.StatusBadGateway, "upstream_error", "upstream error: "+message)
+	}
+}
+
+func errorType(status int) string {
+	switch {
+	case status == http.StatusUnauthorized:
+		return "authentication_error"
+	case status == http.StatusForbidden:
+		return "permission_error"
+	case status == http.StatusTooManyRequests:
+		return "rate_limit_error"
+	case status >= 500:
+		return "server_error"
+	default:
+		return "invalid_request_error"
+	}
+}
+
+// NotFound answers requests for routes the server does not have.
+func NotFound(t Transport) {
+	WriteError(t, NewError(http.StatusNotFound, "unknown_url", fmt.Sprintf("Invalid URL (%s)", t.Path())))
+}
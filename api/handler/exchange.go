@@ -0,0 +1,185 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/raja.aiml/llm-fast-wrapper/internal/auth"
+	"github.com/raja.aiml/llm-fast-wrapper/internal/llm"
+	"github.com/raja.aiml/llm-fast-wrapper/internal/metrics"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// statusClientClosed records streams abandoned by the client, following the
+// nginx convention.
+const statusClientClosed = 499
+
+// exchange wraps the Transport of every API request to capture what it
+// answered, for metrics, traces and the audit log. Stream bodies run after the
+// handler returns on Fiber, so everything needed from the underlying
+// Transport is read before the stream starts.
+type exchange struct {
+	Transport
+	h        *Handler
+	start    time.Time
+	endpoint string
+	// model and backend label chat requests; see annotate.
+	model   string
+	backend string
+	// prompt, token and tenant are read from the request when it is
+	// answered.
+	prompt     string
+	token      string
+	tenant     string
+	status     int
+	streaming  bool
+	firstChunk time.Time
+	// upstreamFailed marks a stream cut short by its upstream.
+	upstreamFailed bool
+	response       strings.Builder
+	// usage counts the tokens of chat responses.
+	usage llm.Usage
+	span  trace.Span
+}
+
+// observe wraps next so that every request is recorded once answered.
+func (h *Handler) observe(next Func) Func {
+	return func(t Transport) {
+		x := &exchange{Transport: t, h: h, start: time.Now(), endpoint: endpointOf(t.Path())}
+		startSpan(x)
+		next(x)
+		if !x.streaming {
+			x.snapshot()
+			h.finish(x)
+		}
+	}
+}
+
+func (x *exchange) JSON(status int, v any) {
+	x.status = status
+	switch body := v.(type) {
+	case *llm.ChatCompletion:
+		x.response.WriteString(completionText(body))
+		x.usage = body.Usage
+	case *llm.TextCompletion:
+		for _, choice := range body.Choices {
+			x.response.WriteString(choice.Text)
+		}
+		if body.Usage != nil {
+			x.usage = *body.Usage
+		}
+	case errorBody:
+		x.response.WriteString(body.Error.Message)
+	}
+	x.Transport.JSON(status, v)
+}
+
+func (x *exchange) Stream(fn func(w StreamWriter)) {
+	x.streaming = true
+	x.status = http.StatusOK
+	x.snapshot()
+	metrics.ActiveStreams.Inc()
+	x.Transport.Stream(func(w StreamWriter) {
+		fn(w)
+		x.h.finish(x)
+	})
+}
+
+func (x *exchange) Upgrade(fn func(conn Conn)) error {
+	x.status = http.StatusSwitchingProtocols
+	err := x.Transport.Upgrade(fn)
+	if err != nil {
+		x.status = http.StatusBadRequest
+	}
+	return err
+}
+
+// snapshot reads the request details recorded with the exchange.
+func (x *exchange) snapshot() {
+	if body, err := x.Body(); err == nil && len(body) > 0 {
+		x.prompt = string(body)
+	} else {
+		x.prompt = x.Path()
+	}
+	x.token = tokenOf(x)
+	if key, ok := auth.FromContext(x.Context()); ok {
+		x.tenant = key.Tenant
+	}
+}
+
+// tokenOf identifies the caller as "key:<id>", prefixed with "<tenant>/"
+// for keys that belong to a tenant. Unauthenticated requests have no token.
+func tokenOf(t Transport) string {
+	key, ok := auth.FromContext(t.Context())
+	if !ok {
+		return ""
+	}
+	if key.Tenant != "" {
+		return fmt.Sprintf("%s/key:%d", key.Tenant, key.ID)
+	}
+	return fmt.Sprintf("key:%d", key.ID)
+}
+
+// endpointOf maps a request path onto a fixed endpoint label.
+func endpointOf(path string) string {
+	switch {
+	case path == "/v1/chat/completions", path == "/v1/completions", path == "/v1/embeddings",
+		path == "/v1/models", path == "/v1/realtime":
+		return path
+	case strings.HasPrefix(path, "/v1/models/"):
+		return "/v1/models/{id}"
+	default:
+		return "other"
+	}
+}
+
+// annotate labels the exchange behind t with the request model and the
+// backend serving it.
+func annotate(t Transport, model, backend string) {
+	if x, ok := t.(*exchange); ok {
+		x.model, x.backend = model, backend
+	}
+}
+
+// observeChunk adds a streamed chunk to the exchange behind t.
+func observeChunk(t Transport, chunk llm.ChatCompletionChunk) {
+	if x, ok := t.(*exchange); ok {
+		x.markFirstChunk()
+		for _, choice := range chunk.Choices {
+			x.response.WriteString(choice.Delta.Text())
+		}
+	}
+}
+
+// observeUsage records the token counts of a streamed response.
+func observeUsage(t Transport, usage llm.Usage) {
+	if x, ok := t.(*exchange); ok {
+		x.usage = usage
+	}
+}
+
+// observeFirstChunk notes the first chunk of a response that is not
+// streamed to the client.
+func observeFirstChunk(t Transport) {
+	if x, ok := t.(*exchange); ok {
+		x.markFirstChunk()
+	}
+}
+
+// markFirstChunk notes the arrival of the first chunk.
+func (x *exchange) markFirstChunk() {
+	if x.firstChunk.IsZero() {
+		x.firstChunk = time.Now()
+		x.span.AddEvent("first_token")
+	}
+}
+
+// observeFailure records that a stream ended early with status.
+func observeFailure(t Transport, status int) {
+	if x, ok := t.(*exchange); ok {
+		x.status = status
+		x.upstreamFailed = status != statusClientClosed
+	}
+}
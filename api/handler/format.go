@@ -0,0 +1,29 @@
+package handler
+
+import (
+	"context"
+
+	"github.com/raja.aiml/llm-fast-wrapper/internal/llm"
+)
+
+// format renders the chunks of the chat pipeline in the wire format of one
+// endpoint.
+type format interface {
+	// chunks adapts the upstream stream before it is rendered.
+	chunks(ctx context.Context, ch <-chan llm.ChatCompletionChunk) <-chan llm.ChatCompletionChunk
+	// event is the SSE payload of a streamed chunk.
+	event(chunk llm.ChatCompletionChunk) any
+	// response is the body of a non-streaming response.
+	response(completion *llm.ChatCompletion) any
+}
+
+// chatFormat renders chat.completion objects and chunks as they are.
+type chatFormat struct{}
+
+func (chatFormat) chunks(_ context.Context, ch <-chan llm.ChatCompletionChunk) <-chan llm.ChatCompletionChunk {
+	return ch
+}
+
+func (chatFormat) event(chunk llm.ChatCompletionChunk) any { return chunk }
+
+func (chatFormat) response(completion *llm.ChatCompletion) any { return completion }
@@ -0,0 +1,152 @@
+// Package handler implements the OpenAI-compatible HTTP endpoints once, on
+// top of the Transport abstraction, so the Gin and Fiber servers only need to
+// adapt their request contexts.
+package handler
+
+import (
+	"net/http"
+
+	"github.com/raja.aiml/llm-fast-wrapper/internal/auditlog/prompt"
+	"github.com/raja.aiml/llm-fast-wrapper/internal/auth"
+	"github.com/raja.aiml/llm-fast-wrapper/internal/config"
+	"github.com/raja.aiml/llm-fast-wrapper/internal/intent"
+	"github.com/raja.aiml/llm-fast-wrapper/internal/llm"
+	"github.com/raja.aiml/llm-fast-wrapper/internal/metrics"
+	"github.com/raja.aiml/llm-fast-wrapper/internal/models"
+	"github.com/raja.aiml/llm-fast-wrapper/internal/ratelimit"
+)
+
+// Handler serves the chat completion endpoints backed by a Streamer.
+type Handler struct {
+	streamer   llm.Streamer
+	models     *models.Registry
+	embedder   Embedder
+	middleware []Middleware
+	limiter    *ratelimit.Limiter
+	limits     config.RateLimitConfig
+	cache      ResponseCache
+	semantic   ResponseCache
+	strategies intent.Matcher
+	audit      prompt.Logger
+	// modelLabels bounds the model metric label when models is empty.
+	modelLabels *metrics.LabelSet
+	streaming   config.StreamConfig
+	validation  config.ValidationConfig
+}
+
+// Option configures optional Handler dependencies.
+type Option func(*Handler)
+
+// WithModels serves reg on /v1/models and rejects chat requests for models
+// it does not contain. An empty registry accepts any model.
+func WithModels(reg *models.Registry) Option {
+	return func(h *Handler) { h.models = reg }
+}
+
+// New creates a Handler that forwards requests to streamer.
+func New(streamer llm.Streamer, opts ...Option) *Handler {
+	h := &Handler{streamer: streamer, modelLabels: metrics.NewLabelSet(maxModelLabels)}
+	for _, opt := range opts {
+		opt(h)
+	}
+	if h.models == nil {
+		h.models, _ = models.NewRegistry(nil)
+	}
+	return h
+}
+
+// ChatCompletions handles POST /v1/chat/completions in both its streaming
+// (SSE) and non-streaming forms.
+func (h *Handler) ChatCompletions(t Transport) {
+	var req llm.ChatCompletionRequest
+	if !h.decodeBody(t, &req) {
+		return
+	}
+	h.complete(t, &req, chatFormat{})
+}
+
+// complete serves req from the response cache or the Streamer, rendering the
+// response in format f.
+func (h *Handler) complete(t Transport, req *llm.ChatCompletionRequest, f format) {
+	backend := h.backendLabel(req.Model)
+	annotate(t, h.modelLabel(req.Model), backend)
+	if !h.validate(t, req) || !h.checkModel(t, req.Model) {
+		return
+	}
+	if !h.rateLimit(t, req) {
+		return
+	}
+	h.injectStrategy(t, req)
+
+	tenant := ""
+	if key, ok := auth.FromContext(t.Context()); ok {
+		tenant = key.Tenant
+	}
+	// The response may outlive this call (Fiber runs stream writers after
+	// the handler returns), so c is released by whichever path consumes it.
+	c := h.newCall(t.Context())
+	if chunks, ok := h.lookupCache(t, tenant, req); ok {
+		annotate(t, h.modelLabel(req.Model), "cache")
+		h.respond(t, req, f, replay(chunks), c)
+		return
+	}
+
+	record := h.cacheRecorder(t, tenant, req)
+	pending := h.startStream(c, req)
+	select {
+	case res := <-pending:
+		if res.err != nil {
+			c.cancel()
+			WriteError(t, h.startFailure(c, req.Model, backend, res.err))
+			return
+		}
+		h.respond(t, req, f, record(c.ctx, res.ch), c)
+	case <-h.commitAfter(req.Stream):
+		// the upstream is slow to start; commit the stream so heartbeats
+		// keep the connection open in the meantime
+		h.streamPending(t, req, f, backend, pending, record, c)
+	}
+}
+
+// respond writes ch in format f: as one response object or, for streaming
+// requests, as SSE events. c is released once ch is no longer consumed.
+func (h *Handler) respond(t Transport, req *llm.ChatCompletionRequest, f format, ch <-chan llm.ChatCompletionChunk, c *call) {
+	ch = f.chunks(c.ctx, ch)
+	if !req.Stream {
+		defer c.cancel()
+		completion, err := llm.Collect(onFirstChunk(ch, func() {
+			c.firstToken()
+			observeFirstChunk(t)
+		}))
+		if err != nil {
+			e := AsError(err)
+			observeFailure(t, e.Status)
+			WriteError(t, e)
+			return
+		}
+		if err := c.timedOut(); err != nil {
+			observeFailure(t, http.StatusGatewayTimeout)
+			WriteError(t, NewError(http.StatusGatewayTimeout, "timeout", err.Error()))
+			return
+		}
+		completion.Usage = responseUsage(req, completion)
+		t.JSON(http.StatusOK, f.response(completion))
+		return
+	}
+
+	setStreamHeaders(t)
+	t.Stream(func(w StreamWriter) {
+		defer c.cancel()
+		h.pump(t, w, f, ch, newMeter(req), c)
+	})
+}
+
+// completionText joins what the model generated in every choice for token
+// counting.
+func completionText(completion *llm.ChatCompletion) string {
+	var text string
+	for _, choice := range completion.Choices {
+		text += choice.Message.Text()
+	}
+	return text
+}
@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/raja.aiml/llm-fast-wrapper/internal/metrics"
+	"github.com/raja.aiml/llm-fast-wrapper/internal/tokenizer"
+)
+
+// maxModelLabels bounds the model label when no registry restricts models.
+const maxModelLabels = 50
+
+// backendResolver reports the primary backend of a model; routing.Router
+// satisfies it.
+type backendResolver interface {
+	Resolve(model string) (backend, upstream string, err error)
+}
+
+// modelLabel maps a requested model onto a bounded label: registered models
+// keep their id, and unregistered ones, even when routed, are "other".
+func (h *Handler) modelLabel(model string) string {
+	if h.models.Len() > 0 {
+		if _, ok := h.models.Get(model); ok {
+			return model
+		}
+		return metrics.Other
+	}
+	return h.modelLabels.Value(model)
+}
+
+// backendLabel names the backend serving model: the routed backend, or
+// "default" for a single upstream.
+func (h *Handler) backendLabel(model string) string {
+	r, ok := h.streamer.(backendResolver)
+	if !ok {
+		return "default"
+	}
+	backend, _, err := r.Resolve(model)
+	if err != nil {
+		return "none"
+	}
+	return backend
+}
+
+// finish records an answered exchange.
+func (h *Handler) finish(x *exchange) {
+	recordMetrics(x, time.Now())
+	endSpan(x)
+	h.auditExchange(x)
+}
+
+func recordMetrics(x *exchange, end time.Time) {
+	metrics.Requests.WithLabelValues(x.endpoint, x.model, x.backend, strconv.Itoa(x.status)).Inc()
+	if x.upstreamFailed {
+		metrics.UpstreamErrors.WithLabelValues(x.backend, "stream").Inc()
+	}
+	if !x.usage.IsZero() {
+		metrics.Tokens.WithLabelValues(x.model, x.backend, x.tenant, "prompt").Add(float64(x.usage.PromptTokens))
+		metrics.Tokens.WithLabelValues(x.model, x.backend, x.tenant, "completion").Add(float64(x.usage.CompletionTokens))
+	}
+	if x.streaming {
+		metrics.ActiveStreams.Dec()
+		metrics.StreamDuration.WithLabelValues(x.model, x.backend).Observe(end.Sub(x.start).Seconds())
+	}
+	if x.firstChunk.IsZero() {
+		return
+	}
+	metrics.TimeToFirstToken.WithLabelValues(x.model, x.backend).Observe(x.firstChunk.Sub(x.start).Seconds())
+	tokens := x.usage.CompletionTokens
+	if tokens == 0 {
+		tokens = tokenizer.CountTokens(x.model, x.response.String())
+	}
+	if elapsed := end.Sub(x.firstChunk).Seconds(); tokens > 0 && elapsed > 0 {
+		metrics.TokensPerSecond.WithLabelValues(x.model, x.backend).Observe(float64(tokens) / elapsed)
+	}
+}
@@ -0,0 +1,76 @@
+package handler
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/raja.aiml/llm-fast-wrapper/internal/auth"
+)
+
+// Middleware wraps a Func, e.g. to authenticate requests before the
+// endpoint runs.
+type Middleware func(next Func) Func
+
+// Wrap applies the Handler's middleware chain to fn, outermost first. The
+// Gin and Fiber adapters wrap every API route with it.
+func (h *Handler) Wrap(fn Func) Func {
+	for i := len(h.middleware) - 1; i >= 0; i-- {
+		fn = h.middleware[i](fn)
+	}
+	return h.observe(fn)
+}
+
+// WithAuth requires every request to carry a bearer token for an active key
+// in store. The key is attached to the request context for later handlers
+// (see auth.FromContext).
+func WithAuth(store auth.Store) Option {
+	return func(h *Handler) { h.middleware = append(h.middleware, authenticate(store)) }
+}
+
+func authenticate(store auth.Store) Middleware {
+	return func(next Func) Func {
+		return func(t Transport) {
+			token := bearerToken(t.Header("Authorization"))
+			if token == "" {
+				// browsers cannot set headers on WebSocket handshakes
+				token = subprotocolToken(t.Header("Sec-WebSocket-Protocol"))
+			}
+			key, err := auth.Authenticate(t.Context(), store, token)
+			if errors.Is(err, auth.ErrInvalidKey) {
+				t.SetHeader("WWW-Authenticate", "Bearer")
+				WriteError(t, NewError(http.StatusUnauthorized, "invalid_api_key", "invalid or missing API key"))
+				return
+			}
+			if err != nil {
+				// the store's error may describe the database; keep it in the log
+				log.Printf("[ERROR] API key lookup failed: %v", err)
+				WriteError(t, NewError(http.StatusServiceUnavailable, "", "API key verification is temporarily unavailable"))
+				return
+			}
+			t.SetContext(auth.NewContext(t.Context(), key))
+			next(t)
+		}
+	}
+}
+
+// bearerToken extracts the token of an "Authorization: Bearer" header.
+func bearerToken(header string) string {
+	scheme, token, ok := strings.Cut(header, " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return ""
+	}
+	return strings.TrimSpace(token)
+}
+
+// subprotocolToken extracts the token offered as a "bearer.<token>"
+// WebSocket subprotocol.
+func subprotocolToken(header string) string {
+	for _, p := range strings.Split(header, ",") {
+		if token, ok := strings.CutPrefix(strings.TrimSpace(p), "bearer."); ok {
+			return token
+		}
+	}
+	return ""
+}
@@ -0,0 +1,83 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/raja.aiml/llm-fast-wrapper/internal/auth"
+	"github.com/raja.aiml/llm-fast-wrapper/internal/models"
+)
+
+// modelsPath is the route prefix of the model endpoints; model IDs may
+// themselves contain slashes, so GetModel takes everything after it.
+const modelsPath = "/v1/models/"
+
+// ListModels handles GET /v1/models, listing the models the caller's key may
+// use.
+func (h *Handler) ListModels(t Transport) {
+	list := h.models.List()
+	list.Data = slices.DeleteFunc(list.Data, func(m models.Object) bool { return h.modelDenied(t, m.ID) != "" })
+	t.JSON(http.StatusOK, list)
+}
+
+// GetModel handles GET /v1/models/{id}.
+func (h *Handler) GetModel(t Transport) {
+	id := strings.TrimPrefix(t.Path(), modelsPath)
+	m, ok := h.models.Get(id)
+	if !ok || h.modelDenied(t, id) != "" {
+		WriteError(t, NewError(http.StatusNotFound, "model_not_found", modelNotFound(id)))
+		return
+	}
+	t.JSON(http.StatusOK, m.Object())
+}
+
+// checkModel writes an error and returns false unless the request may
+// target id: it must be registered or routable (when a registry is
+// configured) and allowed for the caller's key and tenant.
+func (h *Handler) checkModel(t Transport, id string) bool {
+	if h.models.Len() > 0 {
+		if _, ok := h.models.Get(id); !ok && !h.routable(id) {
+			WriteError(t, NewError(http.StatusNotFound, "model_not_found", modelNotFound(id)).WithParam("model"))
+			return false
+		}
+	}
+	if reason := h.modelDenied(t, id); reason != "" {
+		WriteError(t, NewError(http.StatusBadRequest, "", reason).WithParam("model"))
+		return false
+	}
+	return true
+}
+
+// routable reports whether a route, or the default backend, serves id. Only
+// routed streamers know; a single upstream serves registered models only.
+func (h *Handler) routable(id string) bool {
+	r, ok := h.streamer.(backendResolver)
+	if !ok {
+		return false
+	}
+	_, _, err := r.Resolve(id)
+	return err == nil
+}
+
+// modelDenied explains why the caller may not use model id: its key's
+// allow-list or its tenant's (see WithValidation) leaves the model out. It
+// returns "" when the model is allowed.
+func (h *Handler) modelDenied(t Transport, id string) string {
+	key, ok := auth.FromContext(t.Context())
+	switch {
+	case !ok:
+		return ""
+	case !key.AllowsModel(id):
+		return fmt.Sprintf("API key is not allowed to use model '%s'", id)
+	case !h.validation.AllowsModel(key.Tenant, id):
+		return fmt.Sprintf("tenant '%s' is not allowed to use model '%s'", key.Tenant, id)
+	default:
+		return ""
+	}
+}
+
+func modelNotFound(id string) string {
+	return fmt.Sprintf("The model '%s' does not exist", id)
+}
@@ -0,0 +1,66 @@
+package handler
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/raja.aiml/llm-fast-wrapper/internal/auth"
+	"github.com/raja.aiml/llm-fast-wrapper/internal/config"
+	"github.com/raja.aiml/llm-fast-wrapper/internal/llm"
+	"github.com/raja.aiml/llm-fast-wrapper/internal/ratelimit"
+)
+
+// WithRateLimit limits chat requests per API key, or per client IP when the
+// request is unauthenticated, using the tenant limits in cfg.
+func WithRateLimit(limiter *ratelimit.Limiter, cfg config.RateLimitConfig) Option {
+	return func(h *Handler) {
+		h.limiter = limiter
+		h.limits = cfg
+	}
+}
+
+// rateLimit charges req to its client and writes the x-ratelimit-* headers.
+// Over-limit requests get a 429 and rateLimit returns false.
+func (h *Handler) rateLimit(t Transport, req *llm.ChatCompletionRequest) bool {
+	if h.limiter == nil {
+		return true
+	}
+	client, tenant := "ip:"+t.ClientIP(), ""
+	if key, ok := auth.FromContext(t.Context()); ok {
+		client, tenant = fmt.Sprintf("key:%d", key.ID), key.Tenant
+	}
+	limit := h.limits.For(tenant)
+	if !limit.Enabled() {
+		return true
+	}
+
+	tokens := req.EstimatedTokens()
+	d := h.limiter.Allow(client, limit, tokens)
+	setQuotaHeaders(t, "requests", d.Requests)
+	setQuotaHeaders(t, "tokens", d.Tokens)
+	switch {
+	case d.Allowed:
+		return true
+	case d.TooLarge:
+		WriteError(t, NewError(http.StatusTooManyRequests, "rate_limit_exceeded", fmt.Sprintf(
+			"Request too large: %d estimated tokens exceed the limit of %d tokens per minute", tokens, limit.TokensPerMinute)))
+	default:
+		t.SetHeader("Retry-After", strconv.Itoa(int(math.Ceil(d.RetryAfter.Seconds()))))
+		WriteError(t, NewError(http.StatusTooManyRequests, "rate_limit_exceeded", fmt.Sprintf(
+			"Rate limit reached. Please try again in %s.", d.RetryAfter.Round(time.Millisecond))))
+	}
+	return false
+}
+
+// setQuotaHeaders writes the OpenAI x-ratelimit-* headers for one dimension.
+func setQuotaHeaders(t Transport, dimension string, q *ratelimit.Quota) {
+	if q == nil {
+		return
+	}
+	t.SetHeader("x-ratelimit-limit-"+dimension, strconv.Itoa(q.Limit))
+	t.SetHeader("x-ratelimit-remaining-"+dimension, strconv.Itoa(q.Remaining))
+	t.SetHeader("x-ratelimit-reset-"+dimension, q.Reset.Round(time.Millisecond).String())
+}
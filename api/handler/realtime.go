@@ -0,0 +1,342 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// RealtimeSubprotocol is the WebSocket subprotocol of /v1/realtime. Browsers,
+// which cannot set headers on the handshake, offer it together with a
+// "bearer.<API key>" subprotocol carrying their key.
+const RealtimeSubprotocol = "realtime"
+
+// WebSocket message types (RFC 6455 opcodes).
+const (
+	textMessage = 1
+	pingMessage = 9
+)
+
+const (
+	// maxFrameSize bounds the size of a client frame.
+	maxFrameSize = 4 << 20
+	// maxSocketRequests bounds the requests in flight on one connection.
+	maxSocketRequests = 32
+	// writeWait bounds a single frame write, so a stuck client cannot hold
+	// up the other requests on its connection.
+	writeWait = 10 * time.Second
+)
+
+// clientFrame is a message from the client: a chat request to start, the
+// id of one to cancel, or an application-level ping.
+type clientFrame struct {
+	Type    string          `json:"type"`
+	ID      string          `json:"id,omitempty"`
+	Request json.RawMessage `json:"request,omitempty"`
+	// Headers stand in for HTTP headers of the request, e.g. traceparent.
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
+// serverFrame is a message to the client, tagged with the request id.
+type serverFrame struct {
+	Type   string          `json:"type"`
+	ID     string          `json:"id,omitempty"`
+	Chunk  json.RawMessage `json:"chunk,omitempty"`
+	Status int             `json:"status,omitempty"`
+	Error  *errorDetail    `json:"error,omitempty"`
+}
+
+// Realtime handles GET /v1/realtime, a WebSocket over which a client runs any
+// number of concurrent chat requests. Each "request" frame is served like a
+// streaming POST /v1/chat/completions, with rate limits, caching and
+// timeouts; its chunks come back as "chunk" frames followed by "done", or an
+// "error" frame. A "cancel" frame stops a request, answered by "canceled".
+// The server pings every heartbeat interval and drops connections that miss
+// two pongs.
+func (h *Handler) Realtime(t Transport) {
+	s := &socket{
+		h:        h,
+		ctx:      t.Context(),
+		clientIP: t.ClientIP(),
+		requests: make(map[string]context.CancelFunc),
+	}
+	if err := t.Upgrade(s.serve); err != nil {
+		log.Println("websocket upgrade:", err)
+	}
+}
+
+// socket is an upgraded /v1/realtime connection.
+type socket struct {
+	h        *Handler
+	ctx      context.Context
+	clientIP string
+	conn     Conn
+
+	// writeMu serializes frames; mu guards requests.
+	writeMu  sync.Mutex
+	mu       sync.Mutex
+	requests map[string]context.CancelFunc
+	// wg tracks every goroutine that writes to conn.
+	wg sync.WaitGroup
+}
+
+// serve reads client frames until the connection fails, then cancels the
+// requests still running and waits for them, and the pinger, to finish:
+// the connection is closed, or on fasthttp released, once serve returns.
+func (s *socket) serve(conn Conn) {
+	s.conn = conn
+	ctx, cancel := context.WithCancel(s.ctx)
+	defer func() {
+		cancel()
+		s.wg.Wait()
+	}()
+
+	conn.SetReadLimit(maxFrameSize)
+	interval := s.h.streaming.ResolvedHeartbeatInterval()
+	alive := func() error { return nil }
+	if interval > 0 {
+		alive = func() error { return conn.SetReadDeadline(time.Now().Add(2 * interval)) }
+		conn.SetPongHandler(func(string) error { return alive() })
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.ping(ctx, interval)
+		}()
+	}
+	for {
+		if err := alive(); err != nil {
+			return
+		}
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		s.handle(ctx, data)
+	}
+}
+
+// ping sends a ping every interval until ctx ends or a write fails.
+func (s *socket) ping(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.conn.WriteControl(pingMessage, nil, time.Now().Add(writeWait)); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func (s *socket) handle(ctx context.Context, data []byte) {
+	var f clientFrame
+	if err := json.Unmarshal(data, &f); err != nil {
+		s.sendError("", http.StatusBadRequest, "invalid frame: "+err.Error())
+		return
+	}
+	switch f.Type {
+	case "request":
+		s.start(ctx, f)
+	case "cancel":
+		s.cancel(f.ID)
+	case "ping":
+		_ = s.send(ctx, serverFrame{Type: "pong", ID: f.ID})
+	default:
+		s.sendError(f.ID, http.StatusBadRequest, fmt.Sprintf("unknown frame type %q", f.Type))
+	}
+}
+
+// start runs the request of f in the background.
+func (s *socket) start(ctx context.Context, f clientFrame) {
+	if f.ID == "" {
+		s.sendError("", http.StatusBadRequest, "request frames need an id")
+		return
+	}
+	body, err := streamingBody(f.Request)
+	if err != nil {
+		s.sendError(f.ID, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	s.mu.Lock()
+	if _, dup := s.requests[f.ID]; dup {
+		s.mu.Unlock()
+		s.sendError(f.ID, http.StatusBadRequest, fmt.Sprintf("request %q is already in progress", f.ID))
+		return
+	}
+	if len(s.requests) >= maxSocketRequests {
+		s.mu.Unlock()
+		s.sendError(f.ID, http.StatusTooManyRequests, fmt.Sprintf("at most %d requests may run on one connection", maxSocketRequests))
+		return
+	}
+	reqCtx, cancel := context.WithCancel(ctx)
+	s.requests[f.ID] = cancel
+	s.mu.Unlock()
+
+	headers := make(http.Header, len(f.Headers))
+	for name, value := range f.Headers {
+		headers.Set(name, value)
+	}
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer s.release(f.ID)
+		s.h.observe(s.h.ChatCompletions)(&frameTransport{s: s, id: f.ID, ctx: reqCtx, body: body, headers: headers})
+	}()
+}
+
+// cancel stops the request id. Its frames already written stay written; none
+// follow the "canceled" frame.
+func (s *socket) cancel(id string) {
+	s.mu.Lock()
+	cancel, ok := s.requests[id]
+	delete(s.requests, id)
+	s.mu.Unlock()
+	if !ok {
+		s.sendError(id, http.StatusNotFound, fmt.Sprintf("no request %q in progress", id))
+		return
+	}
+	cancel()
+	_ = s.send(context.Background(), serverFrame{Type: "canceled", ID: id})
+}
+
+// release forgets a finished request.
+func (s *socket) release(id string) {
+	s.mu.Lock()
+	if cancel, ok := s.requests[id]; ok {
+		cancel()
+		delete(s.requests, id)
+	}
+	s.mu.Unlock()
+}
+
+// send writes f unless ctx, the context of the request it belongs to, has
+// ended, so that nothing is sent for a request after it was canceled.
+func (s *socket) send(ctx context.Context, f serverFrame) error {
+	data, err := json.Marshal(f)
+	if err != nil {
+		return err
+	}
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := s.conn.SetWriteDeadline(time.Now().Add(writeWait)); err != nil {
+		return err
+	}
+	return s.conn.WriteMessage(textMessage, data)
+}
+
+// sendError reports a frame that could not be served.
+func (s *socket) sendError(id string, status int, message string) {
+	body := NewError(status, "", message).body()
+	if err := s.send(context.Background(), serverFrame{Type: "error", ID: id, Status: status, Error: &body.Error}); err != nil {
+		log.Println("write error:", err)
+	}
+}
+
+// streamingBody returns the chat request of a request frame with stream
+// forced on, keeping every other field as the client sent it.
+func streamingBody(request json.RawMessage) ([]byte, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(request, &fields); err != nil || fields == nil {
+		return nil, fmt.Errorf("request frames need a chat completion request object")
+	}
+	fields["stream"] = json.RawMessage("true")
+	return json.Marshal(fields)
+}
+
+// frameTransport serves one socket request through the HTTP handlers. Its
+// JSON responses and the SSE events of its stream become frames tagged with
+// the request id.
+type frameTransport struct {
+	s       *socket
+	id      string
+	ctx     context.Context
+	body    []byte
+	headers http.Header
+	buf     bytes.Buffer
+}
+
+func (f *frameTransport) Context() context.Context { return f.ctx }
+
+func (f *frameTransport) SetContext(ctx context.Context) { f.ctx = ctx }
+
+func (f *frameTransport) Path() string { return "/v1/realtime" }
+
+func (f *frameTransport) ClientIP() string { return f.s.clientIP }
+
+func (f *frameTransport) Header(name string) string { return f.headers.Get(name) }
+
+func (f *frameTransport) Body() ([]byte, error) { return f.body, nil }
+
+// SetHeader drops response headers; frames have nowhere to carry them.
+func (f *frameTransport) SetHeader(string, string) {}
+
+// JSON sends errors as an error frame. Socket requests always stream, so
+// nothing else is answered with JSON.
+func (f *frameTransport) JSON(status int, v any) {
+	body, ok := v.(errorBody)
+	if !ok {
+		log.Printf("[WARN] dropping %T response to socket request %q", v, f.id)
+		return
+	}
+	if err := f.s.send(f.ctx, serverFrame{Type: "error", ID: f.id, Status: status, Error: &body.Error}); err != nil {
+		log.Println("write error:", err)
+	}
+}
+
+func (f *frameTransport) Stream(fn func(w StreamWriter)) { fn(f) }
+
+func (f *frameTransport) Upgrade(func(conn Conn)) error {
+	return fmt.Errorf("cannot upgrade a request on a WebSocket")
+}
+
+// Write buffers SSE output until Flush turns it into frames.
+func (f *frameTransport) Write(p []byte) (int, error) {
+	if err := f.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return f.buf.Write(p)
+}
+
+// Flush sends a frame for each complete SSE event written so far. Heartbeat
+// comments are dropped; pings keep the connection itself alive.
+func (f *frameTransport) Flush() error {
+	for {
+		event, _, ok := bytes.Cut(f.buf.Bytes(), []byte("\n\n"))
+		if !ok {
+			return nil
+		}
+		data, isData := bytes.CutPrefix(event, []byte("data: "))
+		frame := serverFrame{ID: f.id}
+		switch {
+		case !isData:
+			f.buf.Next(len(event) + 2)
+			continue
+		case string(data) == "[DONE]":
+			frame.Type = "done"
+		case bytes.HasPrefix(data, []byte(`{"error":`)):
+			var body errorBody
+			if err := json.Unmarshal(data, &body); err != nil {
+				return err
+			}
+			frame.Type, frame.Error = "error", &body.Error
+		default:
+			frame.Type, frame.Chunk = "chunk", bytes.Clone(data)
+		}
+		f.buf.Next(len(event) + 2)
+		if err := f.s.send(f.ctx, frame); err != nil {
+			return err
+		}
+	}
+}
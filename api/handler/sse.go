@@ -0,0 +1,38 @@
+package handler
+
+import "encoding/json"
+
+// writeEvent frames v as a single SSE data event and flushes it.
+func writeEvent(w StreamWriter, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write([]byte("data: ")); err != nil {
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		return err
+	}
+	if _, err := w.Write([]byte("\n\n")); err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+// writeDone emits the OpenAI end-of-stream sentinel.
+func writeDone(w StreamWriter) error {
+	if _, err := w.Write([]byte("data: [DONE]\n\n")); err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+// writeHeartbeat emits an SSE comment, which clients ignore, to keep idle
+// connections open through proxies and to detect departed clients.
+func writeHeartbeat(w StreamWriter) error {
+	if _, err := w.Write([]byte(": keep-alive\n\n")); err != nil {
+		return err
+	}
+	return w.Flush()
+}
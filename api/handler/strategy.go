@@ -0,0 +1,39 @@
+package handler
+
+import (
+	"log"
+	"strconv"
+
+	"github.com/raja.aiml/llm-fast-wrapper/internal/intent"
+	"github.com/raja.aiml/llm-fast-wrapper/internal/llm"
+)
+
+// WithStrategies classifies the last user message of each chat request with
+// m and prepends the matched strategy as a system message. Responses carry
+// X-Prompt-Strategy and X-Prompt-Strategy-Score when a strategy matched.
+func WithStrategies(m intent.Matcher) Option {
+	return func(h *Handler) { h.strategies = m }
+}
+
+// injectStrategy applies the matched strategy to req. Matching failures are
+// logged and the request goes out unchanged.
+func (h *Handler) injectStrategy(t Transport, req *llm.ChatCompletionRequest) {
+	if h.strategies == nil {
+		return
+	}
+	query, ok := req.LastUserMessage()
+	if !ok {
+		return
+	}
+	match, err := h.strategies.Match(t.Context(), query)
+	if err != nil {
+		log.Printf("[WARN] strategy match failed: %v", err)
+		return
+	}
+	if match == nil {
+		return
+	}
+	req.Messages = append([]llm.Message{{Role: "system", Content: match.Content}}, req.Messages...)
+	t.SetHeader("X-Prompt-Strategy", match.Name)
+	t.SetHeader("X-Prompt-Strategy-Score", strconv.FormatFloat(match.Score, 'f', 4, 64))
+}
@@ -0,0 +1,242 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/raja.aiml/llm-fast-wrapper/internal/config"
+	"github.com/raja.aiml/llm-fast-wrapper/internal/llm"
+	"github.com/raja.aiml/llm-fast-wrapper/internal/metrics"
+)
+
+var (
+	errFirstTokenTimeout = errors.New("upstream sent no tokens within the first-token timeout")
+	errStreamTimeout     = errors.New("response exceeded the stream timeout")
+)
+
+// WithStreaming sets the heartbeat interval and timeouts of chat responses.
+// Without it heartbeats use config.DefaultHeartbeatInterval and responses
+// are not bounded.
+func WithStreaming(cfg config.StreamConfig) Option {
+	return func(h *Handler) { h.streaming = cfg }
+}
+
+// call is the upstream side of a chat request.
+type call struct {
+	// ctx ends with the request, the stream timeouts or cancel.
+	ctx context.Context
+	// firstToken stops the first-token timeout.
+	firstToken func()
+	cancel     func()
+}
+
+// newCall derives the upstream context of a chat request from parent.
+func (h *Handler) newCall(parent context.Context) *call {
+	ctx, cancelCause := context.WithCancelCause(parent)
+	stopFirst := func() bool { return false }
+	if d := h.streaming.FirstTokenTimeout; d > 0 {
+		stopFirst = time.AfterFunc(d, func() { cancelCause(errFirstTokenTimeout) }).Stop
+	}
+	cancelTimeout := context.CancelFunc(func() {})
+	if d := h.streaming.Timeout; d > 0 {
+		ctx, cancelTimeout = context.WithTimeoutCause(ctx, d, errStreamTimeout)
+	}
+	return &call{
+		ctx:        ctx,
+		firstToken: func() { stopFirst() },
+		cancel: func() {
+			stopFirst()
+			cancelTimeout()
+			cancelCause(nil)
+		},
+	}
+}
+
+// timedOut returns the timeout that ended c, if any.
+func (c *call) timedOut() error {
+	cause := context.Cause(c.ctx)
+	if errors.Is(cause, errFirstTokenTimeout) || errors.Is(cause, errStreamTimeout) {
+		return cause
+	}
+	return nil
+}
+
+type streamResult struct {
+	ch  <-chan llm.ChatCompletionChunk
+	err error
+}
+
+// startStream calls the Streamer in the background, so a streaming response
+// can be committed and kept alive while a slow upstream gets going.
+func (h *Handler) startStream(c *call, req *llm.ChatCompletionRequest) <-chan streamResult {
+	res := make(chan streamResult, 1)
+	go func() {
+		ch, err := h.streamer.Stream(c.ctx, req)
+		res <- streamResult{ch, err}
+	}()
+	return res
+}
+
+// commitAfter fires when a streaming response should stop waiting for the
+// upstream to start and begin sending heartbeats. It never fires for other
+// responses.
+func (h *Handler) commitAfter(stream bool) <-chan time.Time {
+	interval := h.streaming.ResolvedHeartbeatInterval()
+	if !stream || interval == 0 {
+		return nil
+	}
+	return time.After(interval)
+}
+
+// startFailure maps an error of Streamer.Stream onto a response.
+func (h *Handler) startFailure(c *call, model, backend string, err error) *Error {
+	switch {
+	case errors.Is(err, llm.ErrModelNotFound):
+		return NewError(http.StatusNotFound, "model_not_found", modelNotFound(model))
+	case c.timedOut() != nil:
+		return NewError(http.StatusGatewayTimeout, "timeout", c.timedOut().Error())
+	default:
+		metrics.UpstreamErrors.WithLabelValues(backend, "connect").Inc()
+		return AsError(err)
+	}
+}
+
+// streamPending commits an SSE response before the upstream has started,
+// sending heartbeats until it does. Start failures become an error event.
+func (h *Handler) streamPending(t Transport, req *llm.ChatCompletionRequest, f format, backend string, pending <-chan streamResult, record recorder, c *call) {
+	setStreamHeaders(t)
+	t.Stream(func(w StreamWriter) {
+		defer c.cancel()
+		ticker := time.NewTicker(h.streaming.ResolvedHeartbeatInterval())
+		defer ticker.Stop()
+		for {
+			select {
+			case res := <-pending:
+				if res.err != nil {
+					h.endWithError(t, w, h.startFailure(c, req.Model, backend, res.err))
+					return
+				}
+				h.pump(t, w, f, f.chunks(c.ctx, record(c.ctx, res.ch)), newMeter(req), c)
+				return
+			case <-ticker.C:
+				if err := writeHeartbeat(w); err != nil {
+					log.Println("write error:", err)
+					observeFailure(t, statusClientClosed)
+					return
+				}
+			}
+		}
+	})
+}
+
+// pump writes ch as SSE events in format f, sending a heartbeat whenever the stream has
+// been idle for the heartbeat interval. Failed heartbeats are how a client
+// disconnect is noticed on Fiber, whose request context is not canceled.
+// m counts the tokens streamed, however the stream ends.
+func (h *Handler) pump(t Transport, w StreamWriter, f format, ch <-chan llm.ChatCompletionChunk, m *meter, c *call) {
+	defer func() { observeUsage(t, m.usage()) }()
+	var tick <-chan time.Time
+	interval := h.streaming.ResolvedHeartbeatInterval()
+	var ticker *time.Ticker
+	if interval > 0 {
+		ticker = time.NewTicker(interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+	for {
+		select {
+		case chunk, ok := <-ch:
+			if !ok {
+				h.endStream(t, w, f, m, c)
+				return
+			}
+			c.firstToken()
+			if chunk.Err != nil {
+				log.Println("stream error:", chunk.Err)
+				h.endWithError(t, w, AsError(chunk.Err))
+				return
+			}
+			if !m.add(&chunk) {
+				continue
+			}
+			if err := writeEvent(w, f.event(chunk)); err != nil {
+				log.Println("write error:", err)
+				observeFailure(t, statusClientClosed)
+				return
+			}
+			observeChunk(t, chunk)
+			if ticker != nil {
+				ticker.Reset(interval)
+			}
+		case <-tick:
+			if err := writeHeartbeat(w); err != nil {
+				log.Println("write error:", err)
+				observeFailure(t, statusClientClosed)
+				return
+			}
+		}
+	}
+}
+
+// endStream finishes a stream whose upstream has closed: with [DONE] when it
+// completed, preceded by the usage chunk if the client asked for it, or an
+// error event when it timed out.
+func (h *Handler) endStream(t Transport, w StreamWriter, f format, m *meter, c *call) {
+	if err := c.timedOut(); err != nil {
+		h.endWithError(t, w, NewError(http.StatusGatewayTimeout, "timeout", err.Error()))
+		return
+	}
+	if c.ctx.Err() != nil {
+		// the request context ended: the client is gone
+		observeFailure(t, statusClientClosed)
+		return
+	}
+	if m.include {
+		if err := writeEvent(w, f.event(m.chunk())); err != nil {
+			log.Println("write error:", err)
+			observeFailure(t, statusClientClosed)
+			return
+		}
+	}
+	if err := writeDone(w); err != nil {
+		log.Println("write error:", err)
+	}
+}
+
+// endWithError ends a stream whose headers are sent with an error event,
+// which OpenAI SDKs raise as an API error, in place of [DONE].
+func (h *Handler) endWithError(t Transport, w StreamWriter, e *Error) {
+	observeFailure(t, e.Status)
+	if err := writeEvent(w, e.body()); err != nil {
+		log.Println("write error:", err)
+	}
+}
+
+// onFirstChunk forwards ch, calling fn when its first chunk arrives.
+func onFirstChunk(ch <-chan llm.ChatCompletionChunk, fn func()) <-chan llm.ChatCompletionChunk {
+	out := make(chan llm.ChatCompletionChunk)
+	go func() {
+		defer close(out)
+		first := true
+		for chunk := range ch {
+			if first {
+				fn()
+				first = false
+			}
+			out <- chunk
+			if chunk.Err != nil {
+				// llm.Collect stops reading at the first error
+				return
+			}
+		}
+	}()
+	return out
+}
+
+func setStreamHeaders(t Transport) {
+	t.SetHeader("Content-Type", "text/event-stream")
+	t.SetHeader("Cache-Control", "no-cache")
+}
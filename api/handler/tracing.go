@@ -0,0 +1,44 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/raja.aiml/llm-fast-wrapper/internal/telemetry"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// headerCarrier exposes the request headers of a Transport to propagators.
+// Only extraction is supported.
+type headerCarrier struct{ t Transport }
+
+func (c headerCarrier) Get(key string) string { return c.t.Header(key) }
+func (c headerCarrier) Set(string, string)    {}
+func (c headerCarrier) Keys() []string        { return nil }
+
+// startSpan starts the server span of the exchange, continuing the trace of
+// an incoming traceparent header, and attaches it to the request context so
+// upstream calls join the trace.
+func startSpan(x *exchange) {
+	ctx := otel.GetTextMapPropagator().Extract(x.Context(), headerCarrier{x})
+	ctx, x.span = telemetry.Tracer().Start(ctx, x.endpoint,
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithTimestamp(x.start),
+		trace.WithAttributes(semconv.HTTPRoute(x.endpoint)))
+	x.SetContext(ctx)
+}
+
+// endSpan ends the server span of the exchange with its outcome.
+func endSpan(x *exchange) {
+	x.span.SetAttributes(semconv.HTTPStatusCode(x.status))
+	if x.model != "" {
+		x.span.SetAttributes(attribute.String("llm.model", x.model), attribute.String("llm.backend", x.backend))
+	}
+	if x.status >= http.StatusInternalServerError {
+		x.span.SetStatus(codes.Error, http.StatusText(x.status))
+	}
+	x.span.End()
+}
@@ -0,0 +1,66 @@
+package handler
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+// Transport is the framework-specific view of a single HTTP exchange. The Gin
+// and Fiber packages each provide a thin implementation so the handlers in
+// this package never depend on either framework.
+type Transport interface {
+	// Context returns the request context.
+	Context() context.Context
+	// SetContext replaces the request context, e.g. to attach values for
+	// handlers further down the chain.
+	SetContext(ctx context.Context)
+	// Path returns the unescaped request path.
+	Path() string
+	// ClientIP returns the address of the client, honouring the framework's
+	// trusted-proxy settings.
+	ClientIP() string
+	// Header returns the named request header.
+	Header(name string) string
+	// Body returns the raw request body. It may be called more than once.
+	Body() ([]byte, error)
+	// SetHeader sets a response header. It must be called before JSON or Stream.
+	SetHeader(name, value string)
+	// JSON writes v as the JSON response body with the given status code.
+	JSON(status int, v any)
+	// Stream commits a 200 response with the headers set so far and hands fn
+	// a writer for the body. fn may run after the calling handler returns, so
+	// it must not touch the Transport.
+	Stream(fn func(w StreamWriter))
+	// Upgrade switches the connection to the WebSocket protocol and hands fn
+	// the connection, which is closed when fn returns. A failed handshake has
+	// already been answered with an HTTP error when Upgrade returns it. As
+	// with Stream, fn may run after the calling handler returns.
+	Upgrade(fn func(conn Conn)) error
+}
+
+// StreamWriter is the response body writer handed to streaming handlers.
+type StreamWriter interface {
+	io.Writer
+	// Flush pushes buffered bytes to the client. An error means the client
+	// is gone and the stream should be abandoned.
+	Flush() error
+}
+
+// Conn is an upgraded WebSocket connection. The *Conn types of
+// gorilla/websocket and fasthttp/websocket both implement it. Message types
+// are RFC 6455 opcodes. One goroutine may read and one may write at a time;
+// WriteControl and Close may be called concurrently with either.
+type Conn interface {
+	ReadMessage() (messageType int, data []byte, err error)
+	WriteMessage(messageType int, data []byte) error
+	WriteControl(messageType int, data []byte, deadline time.Time) error
+	SetReadLimit(limit int64)
+	SetReadDeadline(t time.Time) error
+	SetWriteDeadline(t time.Time) error
+	SetPongHandler(h func(appData string) error)
+	Close() error
+}
+
+// Func is a transport-neutral request handler.
+type Func func(t Transport)
@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"strings"
+
+	"github.com/raja.aiml/llm-fast-wrapper/internal/llm"
+)
+
+// meter counts the tokens of a streamed response. Counts reported by the
+// upstream win; without them both sides are counted locally with the
+// model's tokenizer.
+type meter struct {
+	model   string
+	prompt  string
+	include bool
+	// upstream is the usage reported by the upstream, if any.
+	upstream *llm.Usage
+	text     strings.Builder
+	// last is the latest chunk, whose id and model the usage chunk repeats.
+	last llm.ChatCompletionChunk
+}
+
+func newMeter(req *llm.ChatCompletionRequest) *meter {
+	return &meter{model: req.Model, prompt: req.PromptText(), include: req.IncludeUsage()}
+}
+
+// add counts chunk and strips the upstream usage from it, so it is only
+// reported once at the end of the stream. It reports whether anything is
+// left to forward.
+func (m *meter) add(chunk *llm.ChatCompletionChunk) bool {
+	if chunk.Usage != nil {
+		m.upstream, chunk.Usage = chunk.Usage, nil
+		if len(chunk.Choices) == 0 {
+			return false
+		}
+	}
+	for _, choice := range chunk.Choices {
+		m.text.WriteString(choice.Delta.Text())
+	}
+	m.last = *chunk
+	return true
+}
+
+// usage returns the token counts of the stream so far.
+func (m *meter) usage() llm.Usage {
+	if m.upstream != nil && !m.upstream.IsZero() {
+		return *m.upstream
+	}
+	return llm.CountUsage(m.model, m.prompt, m.text.String())
+}
+
+// chunk returns the usage-only chunk that ends the stream when the client
+// set stream_options.include_usage.
+func (m *meter) chunk() llm.ChatCompletionChunk {
+	usage := m.usage()
+	object := m.last.Object
+	if object == "" {
+		object = "chat.completion.chunk"
+	}
+	return llm.ChatCompletionChunk{
+		ID:      m.last.ID,
+		Object:  object,
+		Created: m.last.Created,
+		Model:   m.last.Model,
+		Choices: []llm.ChatCompletionChoice{},
+		Usage:   &usage,
+	}
+}
+
+// responseUsage returns the usage of a collected completion: the upstream's
+// counts when it reported them, local counts otherwise.
+func responseUsage(req *llm.ChatCompletionRequest, completion *llm.ChatCompletion) llm.Usage {
+	if !completion.Usage.IsZero() {
+		return completion.Usage
+	}
+	return llm.CountUsage(req.Model, req.PromptText(), completionText(completion))
+}
@@ -0,0 +1,128 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strconv"
+
+	"github.com/raja.aiml/llm-fast-wrapper/internal/config"
+	"github.com/raja.aiml/llm-fast-wrapper/internal/llm"
+	"github.com/raja.aiml/llm-fast-wrapper/internal/tokenizer"
+)
+
+// WithValidation applies the request limits and per-tenant model
+// allow-lists of cfg. Without it bodies are limited to
+// config.DefaultMaxBodyBytes and chat requests are only checked against
+// OpenAI's own rules.
+func WithValidation(cfg config.ValidationConfig) Option {
+	return func(h *Handler) { h.validation = cfg }
+}
+
+// MaxBodyBytes returns the request body limit. The Gin and Fiber adapters
+// enforce it while reading bodies, so oversized ones are never buffered.
+func (h *Handler) MaxBodyBytes() int64 {
+	return h.validation.ResolvedMaxBodyBytes()
+}
+
+// BodyTooLarge answers a request whose body exceeds MaxBodyBytes.
+func (h *Handler) BodyTooLarge(t Transport) {
+	WriteError(t, NewError(http.StatusRequestEntityTooLarge, "request_too_large",
+		fmt.Sprintf("request body exceeds the limit of %d bytes", h.MaxBodyBytes())))
+}
+
+// decodeBody reads the JSON request body into v. Oversized and malformed
+// bodies are answered with an error and decodeBody returns false.
+func (h *Handler) decodeBody(t Transport, v any) bool {
+	if n, err := strconv.ParseInt(t.Header("Content-Length"), 10, 64); err == nil && n > h.MaxBodyBytes() {
+		h.BodyTooLarge(t)
+		return false
+	}
+	body, err := t.Body()
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) || int64(len(body)) > h.MaxBodyBytes() {
+		h.BodyTooLarge(t)
+		return false
+	}
+	if err != nil {
+		WriteError(t, NewError(http.StatusBadRequest, "", err.Error()))
+		return false
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		WriteError(t, decodeError(err))
+		return false
+	}
+	return true
+}
+
+// arrayIndex matches the array indexes of encoding/json field paths.
+var arrayIndex = regexp.MustCompile(`\.(\d+)`)
+
+// decodeError describes a body json.Unmarshal rejected, naming the field
+// with the wrong type when there is one.
+func decodeError(err error) *Error {
+	var typeErr *json.UnmarshalTypeError
+	var syntaxErr *json.SyntaxError
+	switch {
+	case errors.As(err, &typeErr) && typeErr.Field != "":
+		// "messages.0.role" is reported as "messages[0].role"
+		field := arrayIndex.ReplaceAllString(typeErr.Field, "[$1]")
+		return NewError(http.StatusBadRequest, "", fmt.Sprintf("invalid type for '%s': expected %s, got %s",
+			field, jsonType(typeErr.Type), typeErr.Value)).WithParam(field)
+	case errors.As(err, &syntaxErr):
+		return NewError(http.StatusBadRequest, "", "request body is not valid JSON: "+err.Error())
+	default:
+		return NewError(http.StatusBadRequest, "", err.Error())
+	}
+}
+
+// jsonType names the JSON type that decodes into t.
+func jsonType(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "integer"
+	case reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	default:
+		return "object"
+	}
+}
+
+// validate answers a chat request that breaks OpenAI's rules or the
+// configured limits with a 400 naming the parameter at fault, and returns
+// false.
+func (h *Handler) validate(t Transport, req *llm.ChatCompletionRequest) bool {
+	if err := h.checkRequest(req); err != nil {
+		WriteError(t, err)
+		return false
+	}
+	return true
+}
+
+func (h *Handler) checkRequest(req *llm.ChatCompletionRequest) *Error {
+	var paramErr *llm.ParamError
+	if err := req.Validate(); errors.As(err, &paramErr) {
+		return NewError(http.StatusBadRequest, "", paramErr.Message).WithParam(paramErr.Param)
+	}
+	if limit := h.validation.MaxMessages; limit > 0 && len(req.Messages) > limit {
+		return NewError(http.StatusBadRequest, "", fmt.Sprintf(
+			"messages holds %d messages, more than the limit of %d", len(req.Messages), limit)).WithParam("messages")
+	}
+	if limit := h.validation.MaxPromptTokens; limit > 0 {
+		if n := tokenizer.CountTokens(req.Model, req.PromptText()); n > limit {
+			return NewError(http.StatusBadRequest, "context_length_exceeded", fmt.Sprintf(
+				"messages resulted in about %d tokens, more than the limit of %d", n, limit)).WithParam("messages")
+		}
+	}
+	return nil
+}
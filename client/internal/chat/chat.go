@@ -16,6 +16,8 @@ import (
 	"github.com/raja.aiml/llm-fast-wrapper/client/internal/printer"
 	"github.com/raja.aiml/llm-fast-wrapper/client/internal/ui"
 	"github.com/raja.aiml/llm-fast-wrapper/internal/config"
+	"github.com/raja.aiml/llm-fast-wrapper/internal/telemetry"
+	"go.opentelemetry.io/otel/trace"
 )
 
 func RunQuery(client *openai.Client, cfg *config.CLIConfig, logger *zap.SugaredLogger) {
@@ -54,7 +56,8 @@ func RunInteractive(client *openai.Client, cfg *config.CLIConfig, logger *zap.Su
 }
 
 func runSync(client *openai.Client, cfg *config.CLIConfig, logger *zap.SugaredLogger) {
-	ctx := context.Background()
+	ctx, span := telemetry.Tracer().Start(context.Background(), "chat", trace.WithSpanKind(trace.SpanKindClient))
+	defer span.End()
 	req := openai.ChatCompletionNewParams{
 		Model: openai.ChatModel(cfg.Model),
 		Messages: []openai.ChatCompletionMessageParamUnion{
@@ -81,7 +84,8 @@ func runSync(client *openai.Client, cfg *config.CLIConfig, logger *zap.SugaredLo
 }
 
 func runStreaming(client *openai.Client, cfg *config.CLIConfig, logger *zap.SugaredLogger) {
-	ctx := context.Background()
+	ctx, span := telemetry.Tracer().Start(context.Background(), "chat", trace.WithSpanKind(trace.SpanKindClient))
+	defer span.End()
 	params := openai.ChatCompletionNewParams{
 		Model: openai.ChatModel(cfg.Model),
 		Messages: []openai.ChatCompletionMessageParamUnion{
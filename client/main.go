@@ -1,12 +1,11 @@
 package main
 
 import (
+   "context"
    "fmt"
    "os"
 
    "github.com/joho/godotenv"
-   openai "github.com/openai/openai-go"
-   "github.com/openai/openai-go/option"
    "github.com/spf13/cobra"
    "go.uber.org/zap"
 
@@ -14,12 +13,14 @@ import (
    "github.com/raja.aiml/llm-fast-wrapper/client/internal/help"
    "github.com/raja.aiml/llm-fast-wrapper/internal/config"
    "github.com/raja.aiml/llm-fast-wrapper/internal/logging"
+   "github.com/raja.aiml/llm-fast-wrapper/internal/telemetry"
 )
 
 func main() {
    _ = godotenv.Load()
    cfg := config.NewCLIConfig()
    var logger *zap.SugaredLogger
+   shutdownTracing := func(context.Context) error { return nil }
    rootCmd := &cobra.Command{
 		Use:     "llm-client",
 		Short:   "CLI to interact with OpenAI-compatible LLM",
@@ -27,6 +28,13 @@ func main() {
        // Initialize logger after flags are parsed
        PersistentPreRun: func(cmd *cobra.Command, args []string) {
            logger = logging.InitLogger(cfg.LogFile)
+           // spans are exported when OTEL_EXPORTER_OTLP_ENDPOINT is set
+           shutdown, err := telemetry.Init(cmd.Context(), "llm-client", config.TracingFromEnv())
+           if err != nil {
+               logger.Warnf("Tracing disabled: %v", err)
+               return
+           }
+           shutdownTracing = shutdown
        },
 		Run: func(cmd *cobra.Command, args []string) {
 			apiKey := os.Getenv("OPENAI_API_KEY")
@@ -34,13 +42,8 @@ func main() {
 				logger.Fatal("Missing environment variable: OPENAI_API_KEY")
 			}
 
-			var opts []option.RequestOption
-			opts = append(opts, option.WithAPIKey(apiKey))
-			if cfg.BaseURL != "" {
-				opts = append(opts, option.WithBaseURL(cfg.BaseURL))
-			}
-
-			client := openai.NewClient(opts...)
+			// config.NewClient propagates the trace context to the server
+			client := config.NewClient(apiKey, cfg.BaseURL)
 
 			if cfg.Query != "" {
 				chat.RunQuery(&client, cfg, logger)
@@ -61,7 +64,9 @@ func main() {
 
 	rootCmd.AddCommand(helpCommand())
 
-   if err := rootCmd.Execute(); err != nil {
+   err := rootCmd.Execute()
+   _ = shutdownTracing(context.Background())
+   if err != nil {
        if logger != nil {
            logger.Fatalf("Command execution failed: %v", err)
        } else {
@@ -4,12 +4,14 @@ import (
 	"context"
 	"os"
 
+	"github.com/raja.aiml/llm-fast-wrapper/internal/config"
 	"github.com/raja.aiml/llm-fast-wrapper/internal/embeddings"
 	"github.com/raja.aiml/llm-fast-wrapper/internal/embeddings/api"
 	"github.com/raja.aiml/llm-fast-wrapper/internal/embeddings/storage"
 	pgstore "github.com/raja.aiml/llm-fast-wrapper/internal/embeddings/storage/postgres"
 	"github.com/raja.aiml/llm-fast-wrapper/internal/intent"
 	"github.com/raja.aiml/llm-fast-wrapper/internal/logging"
+	"github.com/raja.aiml/llm-fast-wrapper/internal/telemetry"
 	"github.com/spf13/cobra"
 	"go.uber.org/zap"
 )
@@ -19,7 +21,15 @@ func runIntentCommand(cmd *cobra.Command, args []string) {
 	logger := logging.InitLogger("logs/intent.log", "stdout")
 	defer func() { _ = logger.Sync() }()
 
-	ctx := context.Background()
+	// spans are exported when OTEL_EXPORTER_OTLP_ENDPOINT is set
+	shutdown, err := telemetry.Init(cmd.Context(), "intent", config.TracingFromEnv())
+	if err != nil {
+		logger.Fatalf("Failed to initialize tracing: %v", err)
+	}
+	defer func() { _ = shutdown(context.Background()) }()
+	ctx, span := telemetry.Tracer().Start(context.Background(), "intent")
+	defer span.End()
+
 	cfg.Query = parseQuery(cfg, args, logger)
 
 	strategies, paths := loadStrategies(cfg, logger)
@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/raja.aiml/llm-fast-wrapper/internal/auth"
+	"github.com/spf13/cobra"
+)
+
+var keysDSN string
+
+var (
+	keyTenant string
+	keyName   string
+	keyModels []string
+)
+
+var keysCmd = &cobra.Command{
+	Use:   "keys",
+	Short: "manage API keys for the server",
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if keysDSN == "" {
+			keysDSN = os.Getenv("KEYS_DSN")
+		}
+		if keysDSN == "" {
+			return fmt.Errorf("key store requires --keys-dsn or KEYS_DSN")
+		}
+		return nil
+	},
+}
+
+var keysCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "create an API key and print its token once",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if keyTenant == "" {
+			return fmt.Errorf("--tenant is required")
+		}
+		store, err := auth.NewPostgresStore(keysDSN)
+		if err != nil {
+			return err
+		}
+		token, key, err := auth.NewKey(keyTenant, keyName, keyModels)
+		if err != nil {
+			return err
+		}
+		if err := store.Create(cmd.Context(), key); err != nil {
+			return err
+		}
+		fmt.Fprintf(cmd.ErrOrStderr(), "Created key %d for tenant %q. Store the token now; it cannot be shown again.\n", key.ID, key.Tenant)
+		fmt.Fprintln(cmd.OutOrStdout(), token)
+		return nil
+	},
+}
+
+var keysListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "list API keys",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		store, err := auth.NewPostgresStore(keysDSN)
+		if err != nil {
+			return err
+		}
+		keys, err := store.List(cmd.Context())
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tPREFIX\tTENANT\tNAME\tMODELS\tCREATED\tREVOKED")
+		for _, k := range keys {
+			models := strings.Join(k.AllowedModels, ",")
+			if models == "" {
+				models = "*"
+			}
+			revoked := "-"
+			if k.RevokedAt != nil {
+				revoked = k.RevokedAt.Format("2006-01-02 15:04")
+			}
+			fmt.Fprintf(w, "%d\t%s…\t%s\t%s\t%s\t%s\t%s\n", k.ID, k.Prefix, k.Tenant, k.Name, models, k.CreatedAt.Format("2006-01-02 15:04"), revoked)
+		}
+		return w.Flush()
+	},
+}
+
+var keysRevokeCmd = &cobra.Command{
+	Use:   "revoke <id>",
+	Short: "revoke an API key",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		id, err := strconv.ParseUint(args[0], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid key id %q", args[0])
+		}
+		store, err := auth.NewPostgresStore(keysDSN)
+		if err != nil {
+			return err
+		}
+		if err := store.Revoke(cmd.Context(), uint(id)); err != nil {
+			return fmt.Errorf("revoke key %d: %w", id, err)
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "Revoked key %d\n", id)
+		return nil
+	},
+}
+
+func init() {
+	keysCmd.PersistentFlags().StringVar(&keysDSN, "keys-dsn", "", "Postgres DSN of the key store (defaults to KEYS_DSN)")
+	keysCreateCmd.Flags().StringVar(&keyTenant, "tenant", "", "tenant the key belongs to (required)")
+	keysCreateCmd.Flags().StringVar(&keyName, "name", "", "human-readable label for the key")
+	keysCreateCmd.Flags().StringSliceVar(&keyModels, "models", nil, "models the key may use (default: all)")
+
+	keysCmd.AddCommand(keysCreateCmd, keysListCmd, keysRevokeCmd)
+	rootCmd.AddCommand(keysCmd)
+}
@@ -1,31 +1,342 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	fiberapi "github.com/raja.aiml/llm-fast-wrapper/api/fiber"
 	ginapi "github.com/raja.aiml/llm-fast-wrapper/api/gin"
+	"github.com/raja.aiml/llm-fast-wrapper/api/handler"
+	"github.com/raja.aiml/llm-fast-wrapper/internal/auditlog/prompt"
+	"github.com/raja.aiml/llm-fast-wrapper/internal/auth"
+	"github.com/raja.aiml/llm-fast-wrapper/internal/config"
+	"github.com/raja.aiml/llm-fast-wrapper/internal/embeddings"
+	embedapi "github.com/raja.aiml/llm-fast-wrapper/internal/embeddings/api"
+	memstore "github.com/raja.aiml/llm-fast-wrapper/internal/embeddings/storage/memory"
+	pgstore "github.com/raja.aiml/llm-fast-wrapper/internal/embeddings/storage/postgres"
+	"github.com/raja.aiml/llm-fast-wrapper/internal/intent"
+	"github.com/raja.aiml/llm-fast-wrapper/internal/llm"
+	"github.com/raja.aiml/llm-fast-wrapper/internal/metrics"
+	"github.com/raja.aiml/llm-fast-wrapper/internal/models"
+	"github.com/raja.aiml/llm-fast-wrapper/internal/ratelimit"
+	"github.com/raja.aiml/llm-fast-wrapper/internal/responsecache"
+	"github.com/raja.aiml/llm-fast-wrapper/internal/routing"
+	"github.com/raja.aiml/llm-fast-wrapper/internal/telemetry"
 	"github.com/spf13/cobra"
 )
 
 var useFiber bool
 var useGin bool
 
+var (
+	backend string
+	baseURL string
+	apiKey  string
+	model   string
+)
+
+var listenCfg config.ListenConfig
+
+var serverConfigPath string
+
+var keysStoreDSN string
+
+var (
+	embeddingModel string
+	dbDSN          string
+	dbDim          int
+)
+
 var serveCmd = &cobra.Command{
 	Use:   "serve",
 	Short: "start the API server",
 	RunE: func(cmd *cobra.Command, args []string) error {
+		serverCfg := &config.ServerConfig{}
+		if serverConfigPath != "" {
+			var err error
+			if serverCfg, err = config.LoadServerConfig(serverConfigPath); err != nil {
+				return err
+			}
+		}
+		shutdownTracing, err := initTracing("llm-fast-wrapper", serverCfg.Tracing)
+		if err != nil {
+			return err
+		}
+		// registered first so spans of drained requests are flushed last
+		defer shutdownTracing()
+		if apiKey == "" {
+			apiKey = os.Getenv("OPENAI_API_KEY")
+		}
+		streamer, err := newStreamer(serverCfg)
+		if err != nil {
+			return err
+		}
+		registry, err := models.NewRegistry(serverCfg.Models)
+		if err != nil {
+			return fmt.Errorf("model registry: %w", err)
+		}
+		opts := []handler.Option{handler.WithModels(registry)}
+		embedder, vectors, err := newEmbedder()
+		if err != nil {
+			return err
+		}
+		if embedder != nil {
+			opts = append(opts, handler.WithEmbeddings(embedder))
+		}
+		if keysStoreDSN == "" {
+			keysStoreDSN = os.Getenv("KEYS_DSN")
+		}
+		if keysStoreDSN != "" {
+			store, err := auth.NewPostgresStore(keysStoreDSN)
+			if err != nil {
+				return fmt.Errorf("connect key store: %w", err)
+			}
+			opts = append(opts, handler.WithAuth(store))
+			log.Printf("[INFO] API key authentication enabled")
+		} else {
+			log.Printf("[WARN] API key authentication disabled; set --keys-dsn to require keys")
+		}
+		if serverCfg.RateLimits.Enabled() {
+			opts = append(opts, handler.WithRateLimit(ratelimit.New(), serverCfg.RateLimits))
+		}
+		cache, err := newResponseCache(serverCfg.ResponseCache)
+		if err != nil {
+			return err
+		}
+		if cache != nil {
+			opts = append(opts, handler.WithResponseCache(cache))
+		}
+		if serverCfg.SemanticCache.Enabled {
+			semantic, err := newSemanticCache(serverCfg.SemanticCache, embedder, vectors)
+			if err != nil {
+				return err
+			}
+			opts = append(opts, handler.WithSemanticCache(semantic))
+		}
+		if serverCfg.Strategies.Enabled {
+			matcher, err := newStrategyMatcher(serverCfg.Strategies, embedder, vectors)
+			if err != nil {
+				return err
+			}
+			opts = append(opts, handler.WithStrategies(matcher))
+		}
+		auditLog, err := newAuditLogger(serverCfg.AuditLog)
+		if err != nil {
+			return err
+		}
+		if auditLog != nil {
+			// flush queued records once the servers have drained
+			defer auditLog.Close()
+			opts = append(opts, handler.WithAuditLog(auditLog))
+		}
+		opts = append(opts, handler.WithStreaming(serverCfg.Streaming), handler.WithValidation(serverCfg.Validation))
+		h := handler.New(streamer, opts...)
+
+		// stop accepting requests on SIGINT/SIGTERM and let active streams drain
+		ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
 		if useFiber {
-			return fiberapi.Start()
+			return fiberapi.Start(ctx, listenCfg, h)
 		}
 		if useGin {
-			return ginapi.Start()
+			return ginapi.Start(ctx, listenCfg, h)
 		}
 		return fmt.Errorf("no framework selected")
 	},
 }
 
+// initTracing sets up tracing from cfg or, when cfg leaves it disabled, from
+// the OTEL_* environment. The returned function flushes pending spans.
+func initTracing(service string, cfg config.TracingConfig) (func(), error) {
+	if !cfg.Enabled {
+		cfg = config.TracingFromEnv()
+	}
+	shutdown, err := telemetry.Init(context.Background(), service, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("tracing: %w", err)
+	}
+	return func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdown(ctx); err != nil {
+			log.Printf("[WARN] flushing spans: %v", err)
+		}
+	}, nil
+}
+
+// newStreamer builds a router over the backends in cfg or, when none are
+// configured, the single llm.Streamer selected by the --backend flag.
+func newStreamer(cfg *config.ServerConfig) (llm.Streamer, error) {
+	if len(cfg.Backends) > 0 {
+		router, err := routing.FromConfig(cfg, routing.WithStateChange(func(backend string, _, to routing.State) {
+			metrics.BreakerState.WithLabelValues(backend).Set(float64(to))
+		}))
+		if err != nil {
+			return nil, fmt.Errorf("routing: %w", err)
+		}
+		for _, b := range cfg.Backends {
+			metrics.BreakerState.WithLabelValues(b.Name).Set(float64(routing.StateClosed))
+		}
+		log.Printf("[INFO] Routing across %d backends", len(cfg.Backends))
+		return router, nil
+	}
+	switch backend {
+	case "openai":
+		if apiKey == "" {
+			return nil, fmt.Errorf("openai backend requires --api-key or OPENAI_API_KEY")
+		}
+		return llm.NewOpenAIStreamer(apiKey, baseURL, model), nil
+	case "mock":
+		return llm.NewMockStreamer(), nil
+	default:
+		return nil, fmt.Errorf("unknown backend %q", backend)
+	}
+}
+
+// newEmbedder builds the embeddings.Service behind /v1/embeddings. It shares
+// the upstream credentials of the openai backend and persists vectors to
+// pgvector when --db-dsn is set. Without an API key, or with the mock
+// backend, no embeddings are served. The pgvector store is returned too so
+// the semantic cache can search it.
+func newEmbedder() (*embeddings.Service, *pgstore.PostgresStore, error) {
+	if backend != "openai" || apiKey == "" {
+		return nil, nil, nil
+	}
+	provider := embedapi.NewOpenAIProviderWithClient(config.NewClient(apiKey, baseURL), embeddingModel)
+	if dbDSN == "" {
+		return embeddings.NewService(provider, nil), nil, nil
+	}
+	store, err := pgstore.NewPostgresStore(dbDSN, dbDim)
+	if err != nil {
+		return nil, nil, fmt.Errorf("connect pgvector store: %w", err)
+	}
+	log.Printf("[INFO] Persisting embeddings to pgvector")
+	return embeddings.NewService(provider, store), store, nil
+}
+
+// newResponseCache builds the response cache selected in cfg, or nil when
+// it is disabled.
+func newResponseCache(cfg config.CacheConfig) (*responsecache.Cache, error) {
+	ttl := cfg.TTL
+	if ttl <= 0 {
+		ttl = 10 * time.Minute
+	}
+	switch cfg.Backend {
+	case "":
+		return nil, nil
+	case "memory":
+		log.Printf("[INFO] Response cache: memory (ttl %s)", ttl)
+		return responsecache.New(responsecache.NewMemoryStore(cfg.MaxEntries), ttl), nil
+	case "postgres":
+		store, err := responsecache.NewPostgresStore(cfg.ResolvedDSN())
+		if err != nil {
+			return nil, fmt.Errorf("connect response cache: %w", err)
+		}
+		log.Printf("[INFO] Response cache: postgres (ttl %s)", ttl)
+		return responsecache.New(store, ttl), nil
+	default:
+		return nil, fmt.Errorf("unknown response cache backend %q", cfg.Backend)
+	}
+}
+
+// newSemanticCache builds the semantic cache over embedder. Prompt vectors
+// and responses are kept in Postgres alongside the embeddings when vectors
+// is set, in memory otherwise.
+func newSemanticCache(cfg config.SemanticCacheConfig, embedder *embeddings.Service, vectors *pgstore.PostgresStore) (*responsecache.Semantic, error) {
+	if embedder == nil {
+		return nil, fmt.Errorf("semantic cache requires embeddings: use the openai backend with an API key")
+	}
+	if cfg.TTL <= 0 {
+		cfg.TTL = time.Hour
+	}
+	if vectors == nil {
+		log.Printf("[INFO] Semantic cache: memory (ttl %s)", cfg.TTL)
+		return responsecache.NewSemantic(embedder, memstore.NewMemoryStore(), responsecache.NewMemoryStore(cfg.MaxEntries), cfg), nil
+	}
+	store, err := responsecache.NewPostgresStore(dbDSN)
+	if err != nil {
+		return nil, fmt.Errorf("connect semantic cache: %w", err)
+	}
+	log.Printf("[INFO] Semantic cache: pgvector (ttl %s)", cfg.TTL)
+	return responsecache.NewSemantic(embedder, vectors, store, cfg), nil
+}
+
+// newAuditLogger builds the asynchronous audit logger selected in cfg, or
+// nil when audit logging is disabled.
+func newAuditLogger(cfg config.AuditConfig) (*prompt.AsyncLogger, error) {
+	var backend prompt.Logger
+	switch cfg.Backend {
+	case "":
+		return nil, nil
+	case "memory":
+		backend = prompt.NewMemoryLogger()
+	case "postgres":
+		var err error
+		if backend, err = prompt.NewPostgresLogger(cfg.ResolvedDSN()); err != nil {
+			return nil, fmt.Errorf("connect audit log: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("unknown audit log backend %q", cfg.Backend)
+	}
+	log.Printf("[INFO] Audit log: %s", cfg.Backend)
+	return prompt.NewAsyncLogger(backend, cfg.QueueSize), nil
+}
+
+// newStrategyMatcher builds the intent matcher used for prompt-strategy
+// injection, over strategy files or the pgvector prompt_strategies table.
+func newStrategyMatcher(cfg config.StrategyConfig, embedder *embeddings.Service, vectors *pgstore.PostgresStore) (intent.Matcher, error) {
+	if embedder == nil {
+		return nil, fmt.Errorf("strategy injection requires embeddings: use the openai backend with an API key")
+	}
+	threshold := cfg.ResolvedThreshold()
+	switch cfg.Source {
+	case "", "files":
+		dir := cfg.Dir
+		if dir == "" {
+			dir = "strategies"
+		}
+		matcher, err := intent.NewFileMatcher(dir, cfg.Ext, embedder, threshold)
+		if err != nil {
+			return nil, fmt.Errorf("strategies: %w", err)
+		}
+		log.Printf("[INFO] Injecting prompt strategies from %s (threshold %.2f)", dir, threshold)
+		return matcher, nil
+	case "db":
+		if vectors == nil {
+			return nil, fmt.Errorf("strategies: source db requires --db-dsn")
+		}
+		log.Printf("[INFO] Injecting prompt strategies from pgvector (threshold %.2f)", threshold)
+		return intent.NewStoreMatcher(embedder, vectors, threshold), nil
+	default:
+		return nil, fmt.Errorf("unknown strategy source %q", cfg.Source)
+	}
+}
+
 func init() {
 	serveCmd.Flags().BoolVar(&useFiber, "fiber", false, "use Fiber")
 	serveCmd.Flags().BoolVar(&useGin, "gin", false, "use Gin")
+	serveCmd.Flags().StringVar(&serverConfigPath, "config", "", "YAML server config: models, backends and routes, rate limits, caches, strategies, audit log, validation, streaming and tracing (see deploy/server.example.yaml)")
+	serveCmd.Flags().StringVar(&backend, "backend", "openai", "streaming backend: openai or mock")
+	serveCmd.Flags().StringVar(&baseURL, "base-url", "", "OpenAI-compatible upstream base URL")
+	serveCmd.Flags().StringVar(&apiKey, "api-key", "", "upstream API key (defaults to OPENAI_API_KEY)")
+	serveCmd.Flags().StringVar(&model, "model", config.DefaultModel, "upstream model name")
+
+	serveCmd.Flags().StringVar(&keysStoreDSN, "keys-dsn", "", "Postgres DSN of the API key store; enables bearer-token auth (defaults to KEYS_DSN)")
+	serveCmd.Flags().StringVar(&embeddingModel, "embedding-model", "", "embedding model for /v1/embeddings (provider default when empty)")
+	serveCmd.Flags().StringVar(&dbDSN, "db-dsn", "", "Postgres DSN for pgvector embedding persistence (optional)")
+	serveCmd.Flags().IntVar(&dbDim, "db-dim", 1536, "expected vector dimension for pgvector")
+
+	serveCmd.Flags().StringVar(&listenCfg.Addr, "addr", ":8080", "listen address")
+	serveCmd.Flags().StringVar(&listenCfg.TLSCertFile, "tls-cert", "", "TLS certificate file (enables HTTPS with --tls-key)")
+	serveCmd.Flags().StringVar(&listenCfg.TLSKeyFile, "tls-key", "", "TLS private key file")
+	serveCmd.Flags().DurationVar(&listenCfg.ReadTimeout, "read-timeout", 30*time.Second, "maximum duration for reading a request")
+	serveCmd.Flags().DurationVar(&listenCfg.WriteTimeout, "write-timeout", 0, "maximum duration for writing a response, 0 disables (SSE streams can be long)")
+	serveCmd.Flags().DurationVar(&listenCfg.IdleTimeout, "idle-timeout", 120*time.Second, "keep-alive idle timeout")
+	serveCmd.Flags().DurationVar(&listenCfg.ShutdownTimeout, "shutdown-timeout", 30*time.Second, "drain deadline for in-flight requests on shutdown")
 }
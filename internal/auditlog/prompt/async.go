@@ -0,0 +1,96 @@
+package prompt
+
+import (
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultQueueSize is the number of records an AsyncLogger buffers when no
+// size is given.
+const DefaultQueueSize = 1024
+
+// AsyncLogger hands records to another Logger from a background goroutine,
+// so callers never wait on the backend. When the queue is full records are
+// dropped and counted rather than blocking the caller.
+type AsyncLogger struct {
+	next    Logger
+	queue   chan func(Logger) error
+	done    chan struct{}
+	mu      sync.RWMutex
+	closed  bool
+	dropped atomic.Int64
+}
+
+// NewAsyncLogger starts an AsyncLogger writing to next with room for size
+// pending records.
+func NewAsyncLogger(next Logger, size int) *AsyncLogger {
+	if size <= 0 {
+		size = DefaultQueueSize
+	}
+	a := &AsyncLogger{next: next, queue: make(chan func(Logger) error, size), done: make(chan struct{})}
+	go a.run()
+	return a
+}
+
+func (a *AsyncLogger) run() {
+	defer close(a.done)
+	for write := range a.queue {
+		if err := write(a.next); err != nil {
+			log.Printf("[WARN] audit log write failed: %v", err)
+		}
+	}
+}
+
+// enqueue schedules write without blocking. Records arriving after Close
+// are dropped.
+func (a *AsyncLogger) enqueue(write func(Logger) error) error {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if a.closed {
+		a.dropped.Add(1)
+		return nil
+	}
+	select {
+	case a.queue <- write:
+	default:
+		if a.dropped.Add(1)%100 == 1 {
+			log.Printf("[WARN] audit log queue full; %d records dropped so far", a.dropped.Load())
+		}
+	}
+	return nil
+}
+
+// LogPrompt queues a prompt-only record.
+func (a *AsyncLogger) LogPrompt(prompt, token string, ts time.Time) error {
+	return a.enqueue(func(l Logger) error { return l.LogPrompt(prompt, token, ts) })
+}
+
+// LogResponse queues a prompt + response record.
+func (a *AsyncLogger) LogResponse(prompt, response, token string, ts time.Time) error {
+	return a.enqueue(func(l Logger) error { return l.LogResponse(prompt, response, token, ts) })
+}
+
+// LogRequest queues a prompt + response record with its status code and
+// usage.
+func (a *AsyncLogger) LogRequest(prompt, response, token string, status int, usage Usage, ts time.Time) error {
+	return a.enqueue(func(l Logger) error { return l.LogRequest(prompt, response, token, status, usage, ts) })
+}
+
+// Dropped returns the number of records discarded so far.
+func (a *AsyncLogger) Dropped() int64 {
+	return a.dropped.Load()
+}
+
+// Close stops accepting records and waits until the queued ones are written.
+func (a *AsyncLogger) Close() error {
+	a.mu.Lock()
+	if !a.closed {
+		a.closed = true
+		close(a.queue)
+	}
+	a.mu.Unlock()
+	<-a.done
+	return nil
+}
@@ -0,0 +1,66 @@
+package prompt_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/raja.aiml/llm-fast-wrapper/internal/auditlog/prompt"
+)
+
+// blockingLogger holds every write until release is closed.
+type blockingLogger struct {
+	*prompt.MemoryLogger
+	release chan struct{}
+}
+
+func (b *blockingLogger) LogRequest(p, r, t string, status int, usage prompt.Usage, ts time.Time) error {
+	<-b.release
+	return b.MemoryLogger.LogRequest(p, r, t, status, usage, ts)
+}
+
+func TestAsyncLogger_WritesInBackground(t *testing.T) {
+	mem := prompt.NewMemoryLogger().(*prompt.MemoryLogger)
+	async := prompt.NewAsyncLogger(mem, 0)
+
+	ts := time.Now()
+	if err := async.LogRequest("prompt", "response", "key-1", 200, prompt.Usage{PromptTokens: 2, CompletionTokens: 3, TotalTokens: 5}, ts); err != nil {
+		t.Fatalf("LogRequest failed: %v", err)
+	}
+	if err := async.LogPrompt("prompt-only", "key-1", ts); err != nil {
+		t.Fatalf("LogPrompt failed: %v", err)
+	}
+	if err := async.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	if len(mem.Responses) != 1 || len(mem.Prompts) != 1 {
+		t.Fatalf("expected queued records to be flushed on Close, got %+v %+v", mem.Responses, mem.Prompts)
+	}
+	entry := mem.Responses[0]
+	if entry.Prompt != "prompt" || entry.Response != "response" || entry.Token != "key-1" || entry.Status != 200 || entry.Usage.TotalTokens != 5 {
+		t.Errorf("incorrect entry: %+v", entry)
+	}
+}
+
+func TestAsyncLogger_DropsWhenFull(t *testing.T) {
+	slow := &blockingLogger{MemoryLogger: prompt.NewMemoryLogger().(*prompt.MemoryLogger), release: make(chan struct{})}
+	async := prompt.NewAsyncLogger(slow, 1)
+
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		_ = async.LogRequest("p", "r", "t", 200, prompt.Usage{}, time.Now())
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("callers blocked on a slow backend for %s", elapsed)
+	}
+	close(slow.release)
+	_ = async.Close()
+
+	written := int64(len(slow.Responses))
+	if written+async.Dropped() != 5 || async.Dropped() == 0 {
+		t.Errorf("expected drops with a full queue: written=%d dropped=%d", written, async.Dropped())
+	}
+	if err := async.LogRequest("late", "", "", 200, prompt.Usage{}, time.Now()); err != nil {
+		t.Errorf("logging after Close should be a no-op, got %v", err)
+	}
+}
@@ -7,7 +7,14 @@ type PromptLogEntry struct {
 	Prompt    string    `gorm:"type:text"`
 	Response  string    `gorm:"type:text"`
 	Token     string    `gorm:"index"`
+	Status    int       `gorm:"index"`
 	Timestamp time.Time `gorm:"autoCreateTime"`
+
+	// PromptTokens, CompletionTokens and TotalTokens meter the request for
+	// chargeback; see Usage.
+	PromptTokens     int
+	CompletionTokens int
+	TotalTokens      int
 }
 
 type QueryableLogger interface {
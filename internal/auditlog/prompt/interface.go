@@ -6,4 +6,15 @@ import "time"
 type Logger interface {
 	LogPrompt(prompt, token string, ts time.Time) error
 	LogResponse(prompt, response, token string, ts time.Time) error
+	// LogRequest records a request served over HTTP with its status code
+	// and the tokens it consumed.
+	LogRequest(prompt, response, token string, status int, usage Usage, ts time.Time) error
+}
+
+// Usage is the token count of a request, zero for requests that did not
+// reach a model.
+type Usage struct {
+	PromptTokens     int
+	CompletionTokens int
+	TotalTokens      int
 }
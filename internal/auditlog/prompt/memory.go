@@ -1,9 +1,13 @@
 package prompt
 
-import "time"
+import (
+	"sync"
+	"time"
+)
 
 // MemoryLogger stores prompt and response logs in memory.
 type MemoryLogger struct {
+	mu        sync.Mutex
 	Prompts   []PromptEntry
 	Responses []ResponseEntry
 }
@@ -19,6 +23,8 @@ type ResponseEntry struct {
 	Response  string
 	Token     string
 	Timestamp time.Time
+	Status    int
+	Usage     Usage
 }
 
 // NewMemoryLogger creates a new in-memory logger instance.
@@ -28,12 +34,25 @@ func NewMemoryLogger() Logger {
 
 // LogPrompt stores a prompt entry in memory.
 func (m *MemoryLogger) LogPrompt(p, t string, ts time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	m.Prompts = append(m.Prompts, PromptEntry{p, t, ts})
 	return nil
 }
 
 // LogResponse stores a prompt + response entry in memory.
 func (m *MemoryLogger) LogResponse(p, r, t string, ts time.Time) error {
-	m.Responses = append(m.Responses, ResponseEntry{p, r, t, ts})
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.Responses = append(m.Responses, ResponseEntry{Prompt: p, Response: r, Token: t, Timestamp: ts})
+	return nil
+}
+
+// LogRequest stores a prompt + response entry with its status and usage in
+// memory.
+func (m *MemoryLogger) LogRequest(p, r, t string, status int, usage Usage, ts time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.Responses = append(m.Responses, ResponseEntry{Prompt: p, Response: r, Token: t, Timestamp: ts, Status: status, Usage: usage})
 	return nil
 }
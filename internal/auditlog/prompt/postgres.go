@@ -58,6 +58,22 @@ func (l *PostgresLogger) LogResponse(prompt, response, token string, ts time.Tim
 	return l.DB.Create(entry).Error
 }
 
+// LogRequest inserts a prompt + response record with its status code and
+// token counts.
+func (l *PostgresLogger) LogRequest(prompt, response, token string, status int, usage Usage, ts time.Time) error {
+	entry := &PromptLogEntry{
+		Prompt:           prompt,
+		Response:         response,
+		Token:            token,
+		Status:           status,
+		PromptTokens:     usage.PromptTokens,
+		CompletionTokens: usage.CompletionTokens,
+		TotalTokens:      usage.TotalTokens,
+		Timestamp:        ts,
+	}
+	return l.DB.Create(entry).Error
+}
+
 // GetRecentLogs returns the most recent prompt-response entries.
 func (l *PostgresLogger) GetRecentLogs(limit int) ([]PromptLogEntry, error) {
 	var entries []PromptLogEntry
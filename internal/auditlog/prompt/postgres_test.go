@@ -65,3 +65,23 @@ func TestPostgresLogger_LogResponse(t *testing.T) {
 		t.Errorf("unexpected entry: %+v", entry)
 	}
 }
+
+func TestPostgresLogger_LogRequest(t *testing.T) {
+	logger := setupTestPostgresLogger(t)
+
+	usage := prompt.Usage{PromptTokens: 7, CompletionTokens: 5, TotalTokens: 12}
+	if err := logger.LogRequest("prompt", "response", "key-1", 429, usage, time.Now()); err != nil {
+		t.Fatalf("LogRequest failed: %v", err)
+	}
+
+	entries, err := logger.GetRecentLogs(10)
+	if err != nil {
+		t.Fatalf("GetRecentLogs failed: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Status != 429 || entries[0].Response != "response" {
+		t.Fatalf("unexpected entries: %+v", entries)
+	}
+	if e := entries[0]; e.PromptTokens != 7 || e.CompletionTokens != 5 || e.TotalTokens != 12 {
+		t.Errorf("unexpected entries: %+v", entries)
+	}
+}
@@ -0,0 +1,86 @@
+package auth_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/raja.aiml/llm-fast-wrapper/internal/auth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func stores(t *testing.T) map[string]auth.Store {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Discard})
+	require.NoError(t, err)
+	pg, err := auth.NewStoreFromDB(db)
+	require.NoError(t, err)
+	return map[string]auth.Store{"postgres": pg, "memory": auth.NewMemoryStore()}
+}
+
+func TestNewKey(t *testing.T) {
+	token, key, err := auth.NewKey("acme", "ci", []string{"gpt-4o"})
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(token, auth.TokenPrefix))
+	assert.True(t, strings.HasPrefix(token, key.Prefix))
+	assert.NotContains(t, key.Hash, token)
+	assert.Equal(t, auth.HashToken(token), key.Hash)
+
+	other, _, err := auth.NewKey("acme", "ci", nil)
+	require.NoError(t, err)
+	assert.NotEqual(t, token, other)
+}
+
+func TestAllowsModel(t *testing.T) {
+	assert.True(t, (&auth.Key{}).AllowsModel("anything"))
+	restricted := &auth.Key{AllowedModels: []string{"gpt-4o"}}
+	assert.True(t, restricted.AllowsModel("gpt-4o"))
+	assert.False(t, restricted.AllowsModel("gpt-4"))
+}
+
+func TestStores(t *testing.T) {
+	for name, store := range stores(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			token, key, err := auth.NewKey("acme", "ci", []string{"gpt-4o", "echo"})
+			require.NoError(t, err)
+			require.NoError(t, store.Create(ctx, key))
+			require.NotZero(t, key.ID)
+
+			got, err := auth.Authenticate(ctx, store, token)
+			require.NoError(t, err)
+			assert.Equal(t, "acme", got.Tenant)
+			assert.Equal(t, []string{"gpt-4o", "echo"}, got.AllowedModels)
+
+			_, err = auth.Authenticate(ctx, store, token+"x")
+			assert.ErrorIs(t, err, auth.ErrInvalidKey)
+			_, err = auth.Authenticate(ctx, store, "")
+			assert.ErrorIs(t, err, auth.ErrInvalidKey)
+
+			keys, err := store.List(ctx)
+			require.NoError(t, err)
+			require.Len(t, keys, 1)
+			assert.Equal(t, key.Prefix, keys[0].Prefix)
+
+			require.NoError(t, store.Revoke(ctx, key.ID))
+			_, err = auth.Authenticate(ctx, store, token)
+			assert.ErrorIs(t, err, auth.ErrInvalidKey)
+			assert.ErrorIs(t, store.Revoke(ctx, key.ID), auth.ErrKeyNotFound)
+			assert.ErrorIs(t, store.Revoke(ctx, 999), auth.ErrKeyNotFound)
+		})
+	}
+}
+
+func TestContext(t *testing.T) {
+	_, ok := auth.FromContext(context.Background())
+	assert.False(t, ok)
+
+	key := &auth.Key{Tenant: "acme"}
+	got, ok := auth.FromContext(auth.NewContext(context.Background(), key))
+	require.True(t, ok)
+	assert.Same(t, key, got)
+}
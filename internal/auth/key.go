@@ -0,0 +1,115 @@
+// Package auth implements API-key authentication for the server. Keys are
+// random bearer tokens stored only as SHA-256 hashes; each carries the tenant
+// it belongs to and the models it may use.
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"slices"
+	"time"
+)
+
+// TokenPrefix starts every generated token so keys are easy to recognise.
+const TokenPrefix = "lfw-"
+
+// prefixLen is how much of a token is kept in clear to identify the key.
+const prefixLen = len(TokenPrefix) + 8
+
+var (
+	// ErrInvalidKey is returned for unknown or revoked tokens.
+	ErrInvalidKey = errors.New("invalid API key")
+	// ErrKeyNotFound is returned when a key to revoke does not exist.
+	ErrKeyNotFound = errors.New("key not found")
+)
+
+// Key is a stored API key. The token itself is never persisted.
+type Key struct {
+	ID            uint   `gorm:"primaryKey"`
+	Prefix        string `gorm:"index"`
+	Hash          string `gorm:"uniqueIndex"`
+	Name          string
+	Tenant        string    `gorm:"index"`
+	AllowedModels []string  `gorm:"serializer:json"`
+	CreatedAt     time.Time `gorm:"autoCreateTime"`
+	RevokedAt     *time.Time
+}
+
+// TableName keeps the table name stable regardless of GORM naming rules.
+func (Key) TableName() string { return "api_keys" }
+
+// AllowsModel reports whether the key may use model. An empty allow-list
+// permits every model.
+func (k *Key) AllowsModel(model string) bool {
+	return len(k.AllowedModels) == 0 || slices.Contains(k.AllowedModels, model)
+}
+
+// Revoked reports whether the key has been revoked.
+func (k *Key) Revoked() bool { return k.RevokedAt != nil }
+
+// NewKey generates a token for tenant and returns it with the Key to store.
+func NewKey(tenant, name string, models []string) (string, *Key, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", nil, err
+	}
+	token := TokenPrefix + hex.EncodeToString(buf)
+	return token, &Key{
+		Prefix:        token[:prefixLen],
+		Hash:          HashToken(token),
+		Name:          name,
+		Tenant:        tenant,
+		AllowedModels: models,
+	}, nil
+}
+
+// HashToken returns the hex SHA-256 of token, the form keys are looked up by.
+// Tokens are long random strings, so a fast unsalted hash is sufficient.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// Store persists API keys.
+type Store interface {
+	Create(ctx context.Context, key *Key) error
+	// Lookup returns the key with the given hash, revoked or not.
+	Lookup(ctx context.Context, hash string) (*Key, error)
+	List(ctx context.Context) ([]Key, error)
+	// Revoke marks the key with the given ID as revoked.
+	Revoke(ctx context.Context, id uint) error
+}
+
+// Authenticate resolves token to an active key.
+func Authenticate(ctx context.Context, store Store, token string) (*Key, error) {
+	if token == "" {
+		return nil, ErrInvalidKey
+	}
+	key, err := store.Lookup(ctx, HashToken(token))
+	if errors.Is(err, ErrKeyNotFound) {
+		return nil, ErrInvalidKey
+	}
+	if err != nil {
+		return nil, err
+	}
+	if key.Revoked() {
+		return nil, ErrInvalidKey
+	}
+	return key, nil
+}
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx carrying key.
+func NewContext(ctx context.Context, key *Key) context.Context {
+	return context.WithValue(ctx, contextKey{}, key)
+}
+
+// FromContext returns the authenticated key stored in ctx, if any.
+func FromContext(ctx context.Context) (*Key, bool) {
+	key, ok := ctx.Value(contextKey{}).(*Key)
+	return key, ok
+}
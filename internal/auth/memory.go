@@ -0,0 +1,61 @@
+package auth
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryStore is an in-process Store for tests and local development.
+type MemoryStore struct {
+	mu   sync.RWMutex
+	keys []Key
+}
+
+// NewMemoryStore creates an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{}
+}
+
+// Create stores a copy of key and assigns its ID.
+func (s *MemoryStore) Create(_ context.Context, key *Key) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key.ID = uint(len(s.keys) + 1)
+	key.CreatedAt = time.Now()
+	s.keys = append(s.keys, *key)
+	return nil
+}
+
+// Lookup finds a key by hash.
+func (s *MemoryStore) Lookup(_ context.Context, hash string) (*Key, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, k := range s.keys {
+		if k.Hash == hash {
+			return &k, nil
+		}
+	}
+	return nil, ErrKeyNotFound
+}
+
+// List returns every key ordered by ID.
+func (s *MemoryStore) List(_ context.Context) ([]Key, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]Key(nil), s.keys...), nil
+}
+
+// Revoke marks an active key as revoked.
+func (s *MemoryStore) Revoke(_ context.Context, id uint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.keys {
+		if s.keys[i].ID == id && s.keys[i].RevokedAt == nil {
+			now := time.Now()
+			s.keys[i].RevokedAt = &now
+			return nil
+		}
+	}
+	return ErrKeyNotFound
+}
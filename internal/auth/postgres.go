@@ -0,0 +1,81 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"os"
+	"time"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// PostgresStore keeps API keys in the api_keys table using GORM.
+type PostgresStore struct {
+	DB *gorm.DB // Exported for test injection
+}
+
+// NewPostgresStore connects using GORM and ensures the api_keys table.
+func NewPostgresStore(dsn string) (*PostgresStore, error) {
+	cfg := &gorm.Config{}
+
+	if os.Getenv("GORM_LOG_LEVEL") == "silent" {
+		cfg.Logger = logger.Discard
+	}
+
+	db, err := gorm.Open(postgres.Open(dsn), cfg)
+	if err != nil {
+		return nil, err
+	}
+	return NewStoreFromDB(db)
+}
+
+// NewStoreFromDB migrates and wraps an existing GORM DB, e.g. SQLite in tests.
+func NewStoreFromDB(db *gorm.DB) (*PostgresStore, error) {
+	if err := db.AutoMigrate(&Key{}); err != nil {
+		return nil, err
+	}
+	return &PostgresStore{DB: db}, nil
+}
+
+// Create inserts key and fills in its ID.
+func (s *PostgresStore) Create(ctx context.Context, key *Key) error {
+	return s.DB.WithContext(ctx).Create(key).Error
+}
+
+// Lookup finds a key by hash.
+func (s *PostgresStore) Lookup(ctx context.Context, hash string) (*Key, error) {
+	var key Key
+	err := s.DB.WithContext(ctx).Where("hash = ?", hash).First(&key).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrKeyNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+// List returns every key ordered by ID.
+func (s *PostgresStore) List(ctx context.Context) ([]Key, error) {
+	var keys []Key
+	if err := s.DB.WithContext(ctx).Order("id").Find(&keys).Error; err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// Revoke sets revoked_at on an active key.
+func (s *PostgresStore) Revoke(ctx context.Context, id uint) error {
+	res := s.DB.WithContext(ctx).Model(&Key{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", time.Now())
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrKeyNotFound
+	}
+	return nil
+}
@@ -1,8 +1,12 @@
 package config
 
 import (
+	"net/http"
+
 	openai "github.com/openai/openai-go"
 	"github.com/openai/openai-go/option"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
 )
 
 const DefaultModel = "gpt-4"
@@ -23,10 +27,17 @@ func NewCLIConfig() *CLIConfig {
 	return &CLIConfig{}
 }
 
-func NewClient(apiKey, baseURL string) openai.Client {
-	opts := []option.RequestOption{option.WithAPIKey(apiKey)}
+func NewClient(apiKey, baseURL string, extra ...option.RequestOption) openai.Client {
+	opts := []option.RequestOption{option.WithAPIKey(apiKey), option.WithMiddleware(injectTraceContext)}
 	if baseURL != "" {
 		opts = append(opts, option.WithBaseURL(baseURL))
 	}
-	return openai.NewClient(opts...)
+	return openai.NewClient(append(opts, extra...)...)
+}
+
+// injectTraceContext propagates the trace of the request context, such as
+// the traceparent of the incoming API request, to the upstream.
+func injectTraceContext(req *http.Request, next option.MiddlewareNext) (*http.Response, error) {
+	otel.GetTextMapPropagator().Inject(req.Context(), propagation.HeaderCarrier(req.Header))
+	return next(req)
 }
@@ -0,0 +1,52 @@
+package config
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+)
+
+// ListenConfig holds the listener settings of the serve command.
+type ListenConfig struct {
+	Addr         string
+	TLSCertFile  string
+	TLSKeyFile   string
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+	IdleTimeout  time.Duration
+	// ShutdownTimeout bounds how long in-flight requests, including SSE
+	// streams, may keep running after shutdown starts.
+	ShutdownTimeout time.Duration
+}
+
+// TLSEnabled reports whether a certificate and key are configured.
+func (c ListenConfig) TLSEnabled() bool {
+	return c.TLSCertFile != "" || c.TLSKeyFile != ""
+}
+
+// Listen opens the TCP listener on Addr, wrapping it in TLS when a
+// certificate and key are configured.
+func (c ListenConfig) Listen() (net.Listener, error) {
+	var tlsCfg *tls.Config
+	if c.TLSEnabled() {
+		if c.TLSCertFile == "" || c.TLSKeyFile == "" {
+			return nil, errors.New("both a TLS certificate and key are required")
+		}
+		cert, err := tls.LoadX509KeyPair(c.TLSCertFile, c.TLSKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("load TLS key pair: %w", err)
+		}
+		tlsCfg = &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12}
+	}
+
+	ln, err := net.Listen("tcp", c.Addr)
+	if err != nil {
+		return nil, err
+	}
+	if tlsCfg != nil {
+		return tls.NewListener(ln, tlsCfg), nil
+	}
+	return ln, nil
+}
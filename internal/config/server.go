@@ -0,0 +1,255 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/raja.aiml/llm-fast-wrapper/internal/models"
+	"gopkg.in/yaml.v3"
+)
+
+// ServerConfig is the structured configuration of the serve command, loaded
+// from the YAML file given with --config.
+type ServerConfig struct {
+	// Models lists the models advertised on /v1/models. When empty, chat
+	// requests are not checked against a registry.
+	Models []models.Model `yaml:"models"`
+
+	// Backends are the upstreams chat requests can be routed to. When empty,
+	// serve uses the single backend selected by its flags.
+	Backends []BackendConfig `yaml:"backends"`
+	// Routes map request models onto backends.
+	Routes []RouteConfig `yaml:"routes"`
+	// DefaultBackend receives requests no route matches. When empty such
+	// requests are rejected.
+	DefaultBackend string `yaml:"default_backend"`
+	// Retry controls retries of requests that fail before their first token.
+	Retry RetryConfig `yaml:"retry"`
+	// CircuitBreaker controls the per-backend circuit breakers.
+	CircuitBreaker BreakerConfig `yaml:"circuit_breaker"`
+	// RateLimits limit chat requests per API key, or per client IP for
+	// unauthenticated requests.
+	RateLimits RateLimitConfig `yaml:"rate_limits"`
+	// ResponseCache configures the exact-match response cache.
+	ResponseCache CacheConfig `yaml:"response_cache"`
+	// SemanticCache answers prompts similar to earlier ones.
+	SemanticCache SemanticCacheConfig `yaml:"semantic_cache"`
+	// Strategies injects a matching prompt strategy into chat requests.
+	Strategies StrategyConfig `yaml:"strategies"`
+	// AuditLog records every request through prompt.Logger.
+	AuditLog AuditConfig `yaml:"audit_log"`
+	// Validation bounds request sizes and restricts models per tenant.
+	Validation ValidationConfig `yaml:"validation"`
+	// Streaming bounds chat responses and keeps SSE streams alive.
+	Streaming StreamConfig `yaml:"streaming"`
+	// Tracing exports OpenTelemetry spans.
+	Tracing TracingConfig `yaml:"tracing"`
+}
+
+// AuditConfig selects the audit log backend. An empty Backend disables
+// server-side audit logging.
+type AuditConfig struct {
+	// Backend is "memory" or "postgres".
+	Backend string `yaml:"backend"`
+	// QueueSize bounds the records waiting to be written; records beyond
+	// it are dropped rather than delaying responses.
+	QueueSize int `yaml:"queue_size"`
+	// DSN, or the environment variable named by DSNEnv, locates the
+	// Postgres backend.
+	DSN    string `yaml:"dsn"`
+	DSNEnv string `yaml:"dsn_env"`
+}
+
+// ResolvedDSN returns DSN, resolving DSNEnv when DSN is unset.
+func (c AuditConfig) ResolvedDSN() string {
+	if c.DSN == "" && c.DSNEnv != "" {
+		return os.Getenv(c.DSNEnv)
+	}
+	return c.DSN
+}
+
+// DefaultStrategyThreshold is the minimum similarity for a strategy match
+// when none is configured, as in the intent CLI.
+const DefaultStrategyThreshold = 0.5
+
+// StrategyConfig enables prompt-strategy injection: the last user message is
+// classified with the intent matcher and the matched strategy is prepended
+// as a system message.
+type StrategyConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// Source is "files" (default), matching the files under Dir with
+	// extension Ext, or "db", searching the pgvector prompt_strategies table
+	// seeded by the intent CLI. Dir defaults to "strategies".
+	Source    string  `yaml:"source"`
+	Dir       string  `yaml:"dir"`
+	Ext       string  `yaml:"ext"`
+	Threshold float64 `yaml:"threshold"`
+}
+
+// ResolvedThreshold returns Threshold, or DefaultStrategyThreshold when unset.
+func (c StrategyConfig) ResolvedThreshold() float64 {
+	if c.Threshold > 0 {
+		return c.Threshold
+	}
+	return DefaultStrategyThreshold
+}
+
+// DefaultSemanticThreshold is the minimum cosine similarity for a semantic
+// cache hit when none is configured.
+const DefaultSemanticThreshold = 0.95
+
+// SemanticCacheConfig configures the semantic response cache. Entries hold
+// whole responses, like the exact-match cache, but are found by embedding
+// similarity of the last user message.
+type SemanticCacheConfig struct {
+	Enabled bool          `yaml:"enabled"`
+	TTL     time.Duration `yaml:"ttl"`
+	// MaxEntries bounds the in-memory response store; zero means unbounded.
+	MaxEntries int `yaml:"max_entries"`
+	// Threshold is the minimum cosine similarity for a hit, and Thresholds
+	// overrides it per model.
+	Threshold  float32            `yaml:"threshold"`
+	Thresholds map[string]float32 `yaml:"thresholds"`
+	// Candidates is the number of nearest neighbours examined per lookup.
+	Candidates int `yaml:"candidates"`
+}
+
+// ThresholdFor returns the similarity threshold of model.
+func (c SemanticCacheConfig) ThresholdFor(model string) float32 {
+	if t, ok := c.Thresholds[model]; ok {
+		return t
+	}
+	if c.Threshold > 0 {
+		return c.Threshold
+	}
+	return DefaultSemanticThreshold
+}
+
+// CacheConfig selects the response cache backend. An empty Backend disables
+// the cache.
+type CacheConfig struct {
+	// Backend is "memory" or "postgres".
+	Backend string        `yaml:"backend"`
+	TTL     time.Duration `yaml:"ttl"`
+	// MaxEntries bounds the memory backend; zero means unbounded.
+	MaxEntries int `yaml:"max_entries"`
+	// DSN, or the environment variable named by DSNEnv, locates the
+	// Postgres backend.
+	DSN    string `yaml:"dsn"`
+	DSNEnv string `yaml:"dsn_env"`
+}
+
+// ResolvedDSN returns DSN, resolving DSNEnv when DSN is unset.
+func (c CacheConfig) ResolvedDSN() string {
+	if c.DSN == "" && c.DSNEnv != "" {
+		return os.Getenv(c.DSNEnv)
+	}
+	return c.DSN
+}
+
+// RateLimitConfig holds the default limits and per-tenant overrides.
+type RateLimitConfig struct {
+	Default LimitConfig            `yaml:"default"`
+	Tenants map[string]LimitConfig `yaml:"tenants"`
+}
+
+// For returns the limits of tenant, falling back to Default. Unauthenticated
+// requests have an empty tenant.
+func (c RateLimitConfig) For(tenant string) LimitConfig {
+	if l, ok := c.Tenants[tenant]; ok && tenant != "" {
+		return l
+	}
+	return c.Default
+}
+
+// Enabled reports whether any limit is configured.
+func (c RateLimitConfig) Enabled() bool {
+	if c.Default.Enabled() {
+		return true
+	}
+	for _, l := range c.Tenants {
+		if l.Enabled() {
+			return true
+		}
+	}
+	return false
+}
+
+// LimitConfig sets token-bucket sizes per minute; zero means unlimited.
+type LimitConfig struct {
+	RequestsPerMinute int `yaml:"requests_per_minute"`
+	TokensPerMinute   int `yaml:"tokens_per_minute"`
+}
+
+// Enabled reports whether either limit is set.
+func (l LimitConfig) Enabled() bool {
+	return l.RequestsPerMinute > 0 || l.TokensPerMinute > 0
+}
+
+// RetryConfig bounds the attempts made on each backend of a route. Delays
+// between attempts are drawn with full jitter from [0, min(MaxDelay, BaseDelay*2^n)].
+type RetryConfig struct {
+	MaxAttempts int           `yaml:"max_attempts"`
+	BaseDelay   time.Duration `yaml:"base_delay"`
+	MaxDelay    time.Duration `yaml:"max_delay"`
+}
+
+// BreakerConfig opens a backend's circuit after FailureThreshold consecutive
+// failures and probes it again after OpenTimeout.
+type BreakerConfig struct {
+	FailureThreshold int           `yaml:"failure_threshold"`
+	OpenTimeout      time.Duration `yaml:"open_timeout"`
+}
+
+// BackendConfig describes one OpenAI-compatible upstream.
+type BackendConfig struct {
+	Name string `yaml:"name"`
+	// Type is "openai" (default) or "mock".
+	Type    string `yaml:"type"`
+	BaseURL string `yaml:"base_url"`
+	APIKey  string `yaml:"api_key"`
+	// APIKeyEnv names an environment variable holding the API key, so keys
+	// can stay out of the file.
+	APIKeyEnv string `yaml:"api_key_env"`
+	// FanOut serves requests for several choices (n > 1) with parallel
+	// single-choice requests, for upstreams that do not support n.
+	FanOut bool `yaml:"fan_out"`
+}
+
+// Key returns the backend API key, resolving APIKeyEnv when APIKey is unset.
+func (b BackendConfig) Key() string {
+	if b.APIKey == "" && b.APIKeyEnv != "" {
+		return os.Getenv(b.APIKeyEnv)
+	}
+	return b.APIKey
+}
+
+// RouteConfig sends requests whose model matches exactly one of Model,
+// Prefix or Glob to Backend, optionally rewriting the model name.
+type RouteConfig struct {
+	Model   string `yaml:"model"`
+	Prefix  string `yaml:"prefix"`
+	Glob    string `yaml:"glob"`
+	Backend string `yaml:"backend"`
+	// Rewrite replaces the model name sent upstream.
+	Rewrite string `yaml:"rewrite"`
+	// StripPrefix removes Prefix from the model name sent upstream.
+	StripPrefix bool `yaml:"strip_prefix"`
+	// Fallbacks are backends tried in order, with the same upstream model
+	// name, when Backend fails before its first token or its circuit is open.
+	Fallbacks []string `yaml:"fallbacks"`
+}
+
+// LoadServerConfig reads and parses the YAML file at path.
+func LoadServerConfig(path string) (*ServerConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read config: %w", err)
+	}
+	cfg := &ServerConfig{}
+	if err := yaml.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("parse config %s: %w", path, err)
+	}
+	return cfg, nil
+}
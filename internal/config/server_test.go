@@ -0,0 +1,102 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/raja.aiml/llm-fast-wrapper/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadServerConfig(t *testing.T) {
+	cfg, err := config.LoadServerConfig(filepath.Join("..", "..", "deploy", "server.example.yaml"))
+	require.NoError(t, err)
+	require.Len(t, cfg.Models, 2)
+	assert.Equal(t, "gpt-4o-mini", cfg.Models[0].ID)
+	assert.Equal(t, "openai", cfg.Models[0].Backend)
+	assert.Equal(t, 128000, cfg.Models[0].ContextWindow)
+	assert.Equal(t, []string{"chat", "streaming"}, cfg.Models[0].Capabilities)
+
+	require.Len(t, cfg.Backends, 3)
+	assert.Equal(t, "OPENAI_API_KEY", cfg.Backends[0].APIKeyEnv)
+	assert.False(t, cfg.Backends[0].FanOut)
+	assert.True(t, cfg.Backends[2].FanOut)
+	require.Len(t, cfg.Routes, 3)
+	assert.Equal(t, config.RouteConfig{Prefix: "ollama/", Backend: "ollama", StripPrefix: true}, cfg.Routes[1])
+	assert.Equal(t, []string{"ollama"}, cfg.Routes[2].Fallbacks)
+	assert.Equal(t, "openai", cfg.DefaultBackend)
+	assert.Equal(t, config.RetryConfig{MaxAttempts: 2, BaseDelay: 200 * time.Millisecond, MaxDelay: 2 * time.Second}, cfg.Retry)
+	assert.Equal(t, config.BreakerConfig{FailureThreshold: 5, OpenTimeout: 30 * time.Second}, cfg.CircuitBreaker)
+	assert.Equal(t, config.LimitConfig{RequestsPerMinute: 60, TokensPerMinute: 90000}, cfg.RateLimits.Default)
+	assert.Equal(t, 600, cfg.RateLimits.For("acme").RequestsPerMinute)
+	assert.Equal(t, config.CacheConfig{Backend: "memory", TTL: 10 * time.Minute, MaxEntries: 10000}, cfg.ResponseCache)
+	assert.True(t, cfg.SemanticCache.Enabled)
+	assert.Equal(t, float32(0.98), cfg.SemanticCache.ThresholdFor("gpt-4o-mini"))
+	assert.Equal(t, config.StrategyConfig{Enabled: true, Source: "files", Dir: "strategies", Ext: ".md", Threshold: 0.6}, cfg.Strategies)
+	assert.Equal(t, config.DefaultStrategyThreshold, config.StrategyConfig{}.ResolvedThreshold())
+	assert.Equal(t, config.AuditConfig{Backend: "postgres", DSNEnv: "AUDIT_DSN", QueueSize: 1024}, cfg.AuditLog)
+	assert.Equal(t, int64(1<<20), cfg.Validation.ResolvedMaxBodyBytes())
+	assert.Equal(t, 256, cfg.Validation.MaxMessages)
+	assert.True(t, cfg.Validation.AllowsModel("acme", "gpt-4o-mini"))
+	assert.False(t, cfg.Validation.AllowsModel("acme", "gpt-4o"))
+	assert.True(t, cfg.Validation.AllowsModel("other", "llama3"))
+	assert.Equal(t, config.StreamConfig{HeartbeatInterval: 15 * time.Second, FirstTokenTimeout: 30 * time.Second, Timeout: 10 * time.Minute}, cfg.Streaming)
+	assert.Equal(t, config.TracingConfig{Enabled: true, Endpoint: "http://localhost:4318", SampleRatio: 1}, cfg.Tracing)
+}
+
+func TestResolvedHeartbeatInterval(t *testing.T) {
+	assert.Equal(t, config.DefaultHeartbeatInterval, config.StreamConfig{}.ResolvedHeartbeatInterval())
+	assert.Equal(t, 5*time.Second, config.StreamConfig{HeartbeatInterval: 5 * time.Second}.ResolvedHeartbeatInterval())
+	assert.Zero(t, config.StreamConfig{HeartbeatInterval: -1}.ResolvedHeartbeatInterval())
+}
+
+func TestValidationDefaults(t *testing.T) {
+	var cfg config.ValidationConfig
+	assert.Equal(t, int64(config.DefaultMaxBodyBytes), cfg.ResolvedMaxBodyBytes())
+	assert.True(t, cfg.AllowsModel("acme", "anything"))
+	assert.True(t, config.ValidationConfig{AllowedModels: map[string][]string{"acme": nil}}.AllowsModel("", "anything"),
+		"unauthenticated requests have no tenant to restrict")
+}
+
+func TestTracingFromEnv(t *testing.T) {
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "")
+	t.Setenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "")
+	assert.False(t, config.TracingFromEnv().Enabled)
+
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "http://localhost:4318")
+	t.Setenv("OTEL_SERVICE_NAME", "gateway")
+	assert.Equal(t, config.TracingConfig{Enabled: true, ServiceName: "gateway"}, config.TracingFromEnv())
+}
+
+func TestBackendKey(t *testing.T) {
+	t.Setenv("TEST_BACKEND_KEY", "from-env")
+	assert.Equal(t, "inline", config.BackendConfig{APIKey: "inline", APIKeyEnv: "TEST_BACKEND_KEY"}.Key())
+	assert.Equal(t, "from-env", config.BackendConfig{APIKeyEnv: "TEST_BACKEND_KEY"}.Key())
+	assert.Empty(t, config.BackendConfig{}.Key())
+}
+
+func TestLoadServerConfigErrors(t *testing.T) {
+	_, err := config.LoadServerConfig(filepath.Join(t.TempDir(), "missing.yaml"))
+	assert.ErrorContains(t, err, "read config")
+
+	bad := filepath.Join(t.TempDir(), "bad.yaml")
+	require.NoError(t, os.WriteFile(bad, []byte("models: {"), 0o600))
+	_, err = config.LoadServerConfig(bad)
+	assert.ErrorContains(t, err, "parse config")
+}
+
+func TestCacheResolvedDSN(t *testing.T) {
+	t.Setenv("TEST_CACHE_DSN", "postgres://env")
+	assert.Equal(t, "postgres://inline", config.CacheConfig{DSN: "postgres://inline", DSNEnv: "TEST_CACHE_DSN"}.ResolvedDSN())
+	assert.Equal(t, "postgres://env", config.CacheConfig{DSNEnv: "TEST_CACHE_DSN"}.ResolvedDSN())
+}
+
+func TestSemanticThresholdFor(t *testing.T) {
+	assert.Equal(t, float32(config.DefaultSemanticThreshold), config.SemanticCacheConfig{}.ThresholdFor("m"))
+	cfg := config.SemanticCacheConfig{Threshold: 0.9, Thresholds: map[string]float32{"strict": 0.99}}
+	assert.Equal(t, float32(0.9), cfg.ThresholdFor("m"))
+	assert.Equal(t, float32(0.99), cfg.ThresholdFor("strict"))
+}
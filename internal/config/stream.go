@@ -0,0 +1,33 @@
+package config
+
+import "time"
+
+// DefaultHeartbeatInterval is how often idle SSE streams send a comment
+// when no interval is configured; load balancers commonly drop connections
+// idle for 60s.
+const DefaultHeartbeatInterval = 15 * time.Second
+
+// StreamConfig bounds chat responses and keeps idle streams alive.
+type StreamConfig struct {
+	// HeartbeatInterval is how long a stream may stay silent before an SSE
+	// comment is sent. Zero selects DefaultHeartbeatInterval; a negative
+	// value disables heartbeats.
+	HeartbeatInterval time.Duration `yaml:"heartbeat_interval"`
+	// FirstTokenTimeout bounds the wait for the first upstream chunk.
+	FirstTokenTimeout time.Duration `yaml:"first_token_timeout"`
+	// Timeout bounds the whole response. Zero disables either timeout.
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+// ResolvedHeartbeatInterval returns the heartbeat interval, zero when
+// heartbeats are disabled.
+func (c StreamConfig) ResolvedHeartbeatInterval() time.Duration {
+	switch {
+	case c.HeartbeatInterval < 0:
+		return 0
+	case c.HeartbeatInterval == 0:
+		return DefaultHeartbeatInterval
+	default:
+		return c.HeartbeatInterval
+	}
+}
@@ -0,0 +1,29 @@
+package config
+
+import "os"
+
+// TracingConfig controls OpenTelemetry trace export. Spans are sent over
+// OTLP/HTTP, e.g. to the Jaeger of the docker/ setup.
+type TracingConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// Endpoint is the collector URL, e.g. "http://localhost:4318". When
+	// empty the exporter honours OTEL_EXPORTER_OTLP_ENDPOINT and otherwise
+	// targets https://localhost:4318.
+	Endpoint string `yaml:"endpoint"`
+	// ServiceName overrides the service name reported with every span.
+	ServiceName string `yaml:"service_name"`
+	// SampleRatio is the fraction of new traces recorded, 1 when unset.
+	// Requests arriving with a traceparent follow the caller's decision.
+	SampleRatio float64 `yaml:"sample_ratio"`
+}
+
+// TracingFromEnv enables tracing when an OTLP endpoint is set in the
+// standard OpenTelemetry environment variables. It configures the CLIs and
+// serve runs without a tracing section.
+func TracingFromEnv() TracingConfig {
+	endpoint := os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT")
+	if endpoint == "" {
+		endpoint = os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT")
+	}
+	return TracingConfig{Enabled: endpoint != "", ServiceName: os.Getenv("OTEL_SERVICE_NAME")}
+}
@@ -0,0 +1,36 @@
+package config
+
+import "slices"
+
+// DefaultMaxBodyBytes bounds request bodies when no limit is configured. It
+// matches Fiber's default body limit.
+const DefaultMaxBodyBytes = 4 << 20
+
+// ValidationConfig bounds the requests the server accepts.
+type ValidationConfig struct {
+	// MaxBodyBytes bounds request bodies. Zero selects DefaultMaxBodyBytes.
+	MaxBodyBytes int64 `yaml:"max_body_bytes"`
+	// MaxMessages bounds the messages of a chat request; zero means no
+	// limit.
+	MaxMessages int `yaml:"max_messages"`
+	// MaxPromptTokens bounds the prompt tokens of a chat request, counted
+	// with the model's tokenizer; zero means no limit.
+	MaxPromptTokens int `yaml:"max_prompt_tokens"`
+	// AllowedModels lists the models each tenant may use. Tenants not
+	// listed, and unauthenticated requests, may use any model.
+	AllowedModels map[string][]string `yaml:"allowed_models"`
+}
+
+// ResolvedMaxBodyBytes returns the body limit.
+func (c ValidationConfig) ResolvedMaxBodyBytes() int64 {
+	if c.MaxBodyBytes <= 0 {
+		return DefaultMaxBodyBytes
+	}
+	return c.MaxBodyBytes
+}
+
+// AllowsModel reports whether tenant may use model.
+func (c ValidationConfig) AllowsModel(tenant, model string) bool {
+	allowed, ok := c.AllowedModels[tenant]
+	return !ok || tenant == "" || slices.Contains(allowed, model)
+}
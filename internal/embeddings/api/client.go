@@ -18,4 +18,7 @@ type Provider interface {
 
 	// SetDefaultModel changes the default embedding model
 	SetDefaultModel(model string)
+
+	// DefaultModel returns the model used when none is specified
+	DefaultModel() string
 }
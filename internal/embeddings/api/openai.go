@@ -36,6 +36,15 @@ func NewOpenAIProvider() (*OpenAIProvider, error) {
 	}, nil
 }
 
+// NewOpenAIProviderWithClient creates a provider that uses client instead of
+// the environment-configured default. An empty model keeps the default.
+func NewOpenAIProviderWithClient(client openai.Client, model string) *OpenAIProvider {
+	if model == "" {
+		model = defaultOpenAIModel
+	}
+	return &OpenAIProvider{client: &client, defaultModel: model}
+}
+
 // initializeClient initializes the OpenAI client
 func initializeClient() error {
 	initOnce.Do(func() {
@@ -59,6 +68,13 @@ func (p *OpenAIProvider) SetDefaultModel(model string) {
 	p.defaultModel = model
 }
 
+// DefaultModel returns the model used when none is specified
+func (p *OpenAIProvider) DefaultModel() string {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+	return p.defaultModel
+}
+
 // GenerateEmbedding retrieves or generates an embedding for the given text
 func (p *OpenAIProvider) GenerateEmbedding(ctx context.Context, text string, modelName string) ([]float32, error) {
 	// Use default model if none specified
@@ -3,11 +3,16 @@ package embeddings
 import (
 	"context"
 	"database/sql"
+	"slices"
 
 	"github.com/raja.aiml/llm-fast-wrapper/internal/embeddings/api"
 	"github.com/raja.aiml/llm-fast-wrapper/internal/embeddings/cache"
 	"github.com/raja.aiml/llm-fast-wrapper/internal/embeddings/storage"
 	"github.com/raja.aiml/llm-fast-wrapper/internal/logging"
+	"github.com/raja.aiml/llm-fast-wrapper/internal/metrics"
+	"github.com/raja.aiml/llm-fast-wrapper/internal/telemetry"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 )
 
@@ -31,12 +36,15 @@ func NewService(provider api.Provider, store storage.VectorStore) *Service {
 }
 
 // Get retrieves the embedding for the given text using a three-tier lookup
-func (s *Service) Get(ctx context.Context, text string) ([]float32, error) {
+func (s *Service) Get(ctx context.Context, text string) (_ []float32, err error) {
+	ctx, span := telemetry.Tracer().Start(ctx, "embeddings.get")
+	defer func() { telemetry.End(span, err) }()
 	s.logger.Debugf("Fetching embedding for text: %q", text)
 
 	// 1. In-memory cache
 	if cached, found := s.cache.Get(text); found {
 		s.logger.Debugf("Cache hit for text: %q", text)
+		servedBy(span, "memory")
 		return cached, nil
 	}
 	s.logger.Infof("Cache miss for text: %q", text)
@@ -44,10 +52,13 @@ func (s *Service) Get(ctx context.Context, text string) ([]float32, error) {
 	// 2. Attempt storage fetch
 	if s.store != nil {
 		s.logger.Debugf("Attempting storage retrieval for text: %q", text)
-		vec, err := s.store.Get(ctx, text)
+		storeCtx, storeSpan := telemetry.Tracer().Start(ctx, "embeddings.store")
+		vec, err := s.store.Get(storeCtx, text)
+		storeSpan.End()
 		if err == nil {
 			s.cache.Set(text, vec)
 			s.logger.Infof("Embedding loaded from storage for %q", text)
+			servedBy(span, "store")
 			return vec, nil
 		}
 		if err != sql.ErrNoRows {
@@ -59,12 +70,15 @@ func (s *Service) Get(ctx context.Context, text string) ([]float32, error) {
 
 	// 3. Fallback to provider (e.g., OpenAI)
 	s.logger.Debugf("Calling embedding provider for %q", text)
-	vec, err := s.provider.GenerateEmbedding(ctx, text, "")
+	providerCtx, providerSpan := telemetry.Tracer().Start(ctx, "embeddings.provider")
+	vec, err := s.provider.GenerateEmbedding(providerCtx, text, "")
+	telemetry.End(providerSpan, err)
 	if err != nil {
 		s.logger.Errorf("Embedding generation failed for %q: %v", text, err)
 		return nil, err
 	}
 	s.logger.Infof("Successfully generated embedding for %q", text)
+	servedBy(span, "provider")
 
 	// Cache it
 	s.cache.Set(text, vec)
@@ -83,16 +97,42 @@ func (s *Service) Get(ctx context.Context, text string) ([]float32, error) {
 	return vec, nil
 }
 
-// GetBatch generates embeddings for multiple texts
+// GetBatch generates embeddings for multiple texts using the same cache,
+// storage and provider tiers as Get. When the provider fails on any text,
+// the texts it did embed are still cached and stored, and the first failure
+// is returned.
 func (s *Service) GetBatch(ctx context.Context, texts []string) (map[string][]float32, error) {
+	ctx, span := telemetry.Tracer().Start(ctx, "embeddings.get_batch", trace.WithAttributes(attribute.Int("embedding.texts", len(texts))))
+	served := make(map[string]int)
+	defer func() {
+		for source, n := range served {
+			metrics.EmbeddingLookups.WithLabelValues(source).Add(float64(n))
+			span.SetAttributes(attribute.Int("embedding."+source, n))
+		}
+		span.End()
+	}()
 	result := make(map[string][]float32)
 	var uncachedTexts []string
 
-	// Check cache first
+	// Check cache, then storage
 	for _, text := range texts {
+		if _, seen := result[text]; seen {
+			continue
+		}
 		if cached, found := s.cache.Get(text); found {
+			served["memory"]++
 			result[text] = cached
-		} else {
+			continue
+		}
+		if s.store != nil {
+			if vec, err := s.store.Get(ctx, text); err == nil {
+				served["store"]++
+				s.cache.Set(text, vec)
+				result[text] = vec
+				continue
+			}
+		}
+		if !slices.Contains(uncachedTexts, text) {
 			uncachedTexts = append(uncachedTexts, text)
 		}
 	}
@@ -103,16 +143,24 @@ func (s *Service) GetBatch(ctx context.Context, texts []string) (map[string][]fl
 	}
 
 	// Generate remaining embeddings
-	embeddings := s.provider.GenerateEmbeddingsBatch(ctx, uncachedTexts, "")
+	providerCtx, providerSpan := telemetry.Tracer().Start(ctx, "embeddings.provider",
+		trace.WithAttributes(attribute.Int("embedding.texts", len(uncachedTexts))))
+	embeddings := s.provider.GenerateEmbeddingsBatch(providerCtx, uncachedTexts, "")
+	providerSpan.End()
 
 	// Process results
+	var failed error
 	for i, embResult := range embeddings {
 		if embResult.Error != nil {
 			s.logger.Warnf("Failed to generate embedding for text %q: %v", uncachedTexts[i], embResult.Error)
+			if failed == nil {
+				failed = embResult.Error
+			}
 			continue
 		}
 
 		// Add to result
+		served["provider"]++
 		text := uncachedTexts[i]
 		result[text] = embResult.Embedding
 
@@ -127,9 +175,24 @@ func (s *Service) GetBatch(ctx context.Context, texts []string) (map[string][]fl
 		}
 	}
 
+	if failed != nil {
+		return nil, failed
+	}
 	return result, nil
 }
 
+// servedBy records that source (memory, store or provider) answered the
+// lookup traced by span.
+func servedBy(span trace.Span, source string) {
+	metrics.EmbeddingLookups.WithLabelValues(source).Inc()
+	span.SetAttributes(attribute.String("embedding.source", source))
+}
+
+// Model returns the embedding model the provider generates vectors with
+func (s *Service) Model() string {
+	return s.provider.DefaultModel()
+}
+
 // ClearCache clears the in-memory cache
 func (s *Service) ClearCache() {
 	s.cache.Clear()
@@ -0,0 +1,74 @@
+package embeddings_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/raja.aiml/llm-fast-wrapper/internal/embeddings"
+	"github.com/raja.aiml/llm-fast-wrapper/internal/embeddings/api"
+	"github.com/raja.aiml/llm-fast-wrapper/internal/embeddings/storage/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingProvider embeds text as [len(text)] and records provider calls.
+type countingProvider struct {
+	calls [][]string
+}
+
+func (p *countingProvider) GenerateEmbedding(_ context.Context, text, _ string) ([]float32, error) {
+	return []float32{float32(len(text))}, nil
+}
+
+func (p *countingProvider) GenerateEmbeddingsBatch(_ context.Context, texts []string, _ string) []api.EmbeddingResult {
+	p.calls = append(p.calls, texts)
+	out := make([]api.EmbeddingResult, len(texts))
+	for i, text := range texts {
+		if text == "bad" {
+			out[i].Error = errors.New("rejected")
+			continue
+		}
+		out[i].Embedding = []float32{float32(len(text))}
+	}
+	return out
+}
+
+func (p *countingProvider) SetDefaultModel(string) {}
+
+func (p *countingProvider) DefaultModel() string { return "counting" }
+
+func TestServiceGetBatchTiers(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewMemoryStore()
+	require.NoError(t, store.Store(ctx, "stored", []float32{42}))
+	provider := &countingProvider{}
+	svc := embeddings.NewService(provider, store)
+
+	got, err := svc.GetBatch(ctx, []string{"stored", "new", "new"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]float32{"stored": {42}, "new": {3}}, got)
+	assert.Equal(t, [][]string{{"new"}}, provider.calls, "stored and duplicate texts skip the provider")
+	assert.Equal(t, "counting", svc.Model())
+
+	persisted, err := store.Get(ctx, "new")
+	require.NoError(t, err)
+	assert.Equal(t, []float32{3}, persisted)
+
+	_, err = svc.GetBatch(ctx, []string{"new", "stored"})
+	require.NoError(t, err)
+	assert.Len(t, provider.calls, 1, "second batch is served from cache")
+}
+
+func TestServiceGetBatchProviderFailure(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewMemoryStore()
+	svc := embeddings.NewService(&countingProvider{}, store)
+
+	_, err := svc.GetBatch(ctx, []string{"new", "bad"})
+	require.EqualError(t, err, "rejected")
+
+	persisted, err := store.Get(ctx, "new")
+	require.NoError(t, err, "texts that did embed are kept")
+	assert.Equal(t, []float32{3}, persisted)
+}
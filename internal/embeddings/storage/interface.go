@@ -18,6 +18,14 @@ type SimilarItem struct {
 	Similarity float32
 }
 
+// Scope restricts scoped entries, such as semantic cache prompts, to one
+// tenant, model and request hash
+type Scope struct {
+	Tenant      string
+	Model       string
+	RequestHash string
+}
+
 // StrategyItem represents a prompt strategy record returned by DB search
 type StrategyItem struct {
 	ID         int
@@ -32,8 +40,16 @@ type AdvancedVectorStore interface {
 	VectorStore
 
 	// SearchByEmbedding finds the most similar vectors to the given embedding
+	// among those stored without a scope
 	SearchByEmbedding(ctx context.Context, embedding []float32, k int) ([]SimilarItem, error)
 
+	// StoreScoped saves an embedding for the given text under scope
+	StoreScoped(ctx context.Context, scope Scope, text string, vec []float32) error
+
+	// SearchScoped finds the most similar vectors to the given embedding among
+	// those stored under scope
+	SearchScoped(ctx context.Context, scope Scope, embedding []float32, k int) ([]SimilarItem, error)
+
 	// SearchStrategies searches for similar prompt strategies
 	SearchStrategies(ctx context.Context, embedding []float32, threshold float64, maxResults int) ([]StrategyItem, error)
 
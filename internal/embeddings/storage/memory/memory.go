@@ -13,6 +13,7 @@ import (
 // MemoryStore implements an in-memory vector store
 type MemoryStore struct {
 	embeddings map[string][]float32
+	scopes     map[string]storage.Scope
 	mutex      sync.RWMutex
 }
 
@@ -20,6 +21,7 @@ type MemoryStore struct {
 func NewMemoryStore() *MemoryStore {
 	return &MemoryStore{
 		embeddings: make(map[string][]float32),
+		scopes:     make(map[string]storage.Scope),
 	}
 }
 
@@ -42,18 +44,49 @@ func (s *MemoryStore) Store(ctx context.Context, text string, vec []float32) err
 	defer s.mutex.Unlock()
 
 	s.embeddings[text] = vec
+	delete(s.scopes, text)
 	return nil
 }
 
-// SearchByEmbedding finds similar vectors in the memory store
+// StoreScoped saves an embedding under scope to the in-memory store
+func (s *MemoryStore) StoreScoped(ctx context.Context, scope storage.Scope, text string, vec []float32) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.embeddings[text] = vec
+	s.scopes[text] = scope
+	return nil
+}
+
+// SearchByEmbedding finds similar unscoped vectors in the memory store
 func (s *MemoryStore) SearchByEmbedding(ctx context.Context, embedding []float32, k int) ([]storage.SimilarItem, error) {
+	return s.search(embedding, k, func(text string) bool {
+		_, scoped := s.scopes[text]
+		return !scoped
+	}), nil
+}
+
+// SearchScoped finds similar vectors stored under scope in the memory store
+func (s *MemoryStore) SearchScoped(ctx context.Context, scope storage.Scope, embedding []float32, k int) ([]storage.SimilarItem, error) {
+	return s.search(embedding, k, func(text string) bool {
+		got, scoped := s.scopes[text]
+		return scoped && got == scope
+	}), nil
+}
+
+// search returns the k stored vectors most similar to embedding among the
+// texts that match keeps
+func (s *MemoryStore) search(embedding []float32, k int, keep func(text string) bool) []storage.SimilarItem {
 	s.mutex.RLock()
 	defer s.mutex.RUnlock()
 
 	var items []storage.SimilarItem
 
-	// Calculate similarity with all stored embeddings
+	// Calculate similarity with the matching stored embeddings
 	for text, vec := range s.embeddings {
+		if !keep(text) {
+			continue
+		}
 		similarity := api.CosineSimilarity(embedding, vec)
 		items = append(items, storage.SimilarItem{
 			Text:       text,
@@ -72,5 +105,5 @@ func (s *MemoryStore) SearchByEmbedding(ctx context.Context, embedding []float32
 		items = items[:k]
 	}
 
-	return items, nil
+	return items
 }
@@ -3,12 +3,16 @@ package postgres
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"strings"
 
 	_ "github.com/lib/pq"
 	"github.com/raja.aiml/llm-fast-wrapper/internal/embeddings/storage"
 	"github.com/raja.aiml/llm-fast-wrapper/internal/logging"
+	"github.com/raja.aiml/llm-fast-wrapper/internal/telemetry"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 )
 
@@ -42,13 +46,29 @@ func NewPostgresStore(dsn string, dimension int) (*PostgresStore, error) {
 	createTable := fmt.Sprintf(
 		`CREATE TABLE IF NOT EXISTS embeddings (
 			text TEXT PRIMARY KEY,
-			embedding vector(%d)
+			embedding vector(%d),
+			tenant TEXT,
+			model TEXT,
+			request_hash TEXT
 		);`, dimension)
 	if _, err := db.Exec(createTable); err != nil {
 		return nil, fmt.Errorf("ensure embeddings table: %w", err)
 	}
 	logger.Infof("Ensured embeddings table with dimension=%d", dimension)
 
+	// Ensure the scope columns of scoped entries exist on older tables too
+	scopeColumns := `
+		ALTER TABLE embeddings
+			ADD COLUMN IF NOT EXISTS tenant TEXT,
+			ADD COLUMN IF NOT EXISTS model TEXT,
+			ADD COLUMN IF NOT EXISTS request_hash TEXT;
+		CREATE INDEX IF NOT EXISTS idx_embeddings_scope
+		ON embeddings (tenant, model, request_hash);`
+	if _, err := db.Exec(scopeColumns); err != nil {
+		return nil, fmt.Errorf("ensure embeddings scope columns: %w", err)
+	}
+	logger.Info("Ensured scope columns on embeddings")
+
 	// Ensure ivfflat index exists on the embeddings column
 	indexQuery := `
 		CREATE INDEX IF NOT EXISTS idx_embeddings_embedding
@@ -68,14 +88,17 @@ func NewPostgresStore(dsn string, dimension int) (*PostgresStore, error) {
 
 // Store stores an embedding in the PostgreSQL database
 func (s *PostgresStore) Store(ctx context.Context, text string, embedding []float32) error {
+	ctx, span := startQuery(ctx, "INSERT", "embeddings")
 	vectorLiteral := toVectorLiteral(embedding)
 	s.logger.Debugf("Storing embedding for %q", text)
 
 	query := `
 		INSERT INTO embeddings (text, embedding)
 		VALUES ($1, $2::vector)
-		ON CONFLICT (text) DO UPDATE SET embedding = EXCLUDED.embedding;`
+		ON CONFLICT (text) DO UPDATE SET embedding = EXCLUDED.embedding,
+			tenant = NULL, model = NULL, request_hash = NULL;`
 	_, err := s.db.ExecContext(ctx, query, text, vectorLiteral)
+	telemetry.End(span, err)
 	if err != nil {
 		s.logger.Warnf("Store failed for %q: %v", text, err)
 	} else {
@@ -84,8 +107,35 @@ func (s *PostgresStore) Store(ctx context.Context, text string, embedding []floa
 	return err
 }
 
+// StoreScoped stores an embedding under scope in the PostgreSQL database
+func (s *PostgresStore) StoreScoped(ctx context.Context, scope storage.Scope, text string, embedding []float32) error {
+	ctx, span := startQuery(ctx, "INSERT", "embeddings")
+	vectorLiteral := toVectorLiteral(embedding)
+	s.logger.Debugf("Storing scoped embedding for %q", text)
+
+	query := `
+		INSERT INTO embeddings (text, embedding, tenant, model, request_hash)
+		VALUES ($1, $2::vector, $3, $4, $5)
+		ON CONFLICT (text) DO UPDATE SET embedding = EXCLUDED.embedding,
+			tenant = EXCLUDED.tenant, model = EXCLUDED.model, request_hash = EXCLUDED.request_hash;`
+	_, err := s.db.ExecContext(ctx, query, text, vectorLiteral, scope.Tenant, scope.Model, scope.RequestHash)
+	telemetry.End(span, err)
+	if err != nil {
+		s.logger.Warnf("Scoped store failed for %q: %v", text, err)
+	}
+	return err
+}
+
 // Get retrieves an embedding from the PostgreSQL database
-func (s *PostgresStore) Get(ctx context.Context, text string) ([]float32, error) {
+func (s *PostgresStore) Get(ctx context.Context, text string) (_ []float32, err error) {
+	ctx, span := startQuery(ctx, "SELECT", "embeddings")
+	defer func() {
+		if errors.Is(err, sql.ErrNoRows) {
+			span.End()
+			return
+		}
+		telemetry.End(span, err)
+	}()
 	s.logger.Debugf("Retrieving embedding for %q", text)
 
 	row := s.db.QueryRowContext(ctx, `SELECT embedding FROM embeddings WHERE text = $1`, text)
@@ -104,17 +154,40 @@ func (s *PostgresStore) Get(ctx context.Context, text string) ([]float32, error)
 	return parsed, nil
 }
 
-// SearchByEmbedding searches for similar embeddings in the database
-func (s *PostgresStore) SearchByEmbedding(ctx context.Context, embedding []float32, k int) ([]storage.SimilarItem, error) {
-	vectorLiteral := toVectorLiteral(embedding)
+// SearchByEmbedding searches for similar unscoped embeddings in the database
+func (s *PostgresStore) SearchByEmbedding(ctx context.Context, embedding []float32, k int) (_ []storage.SimilarItem, err error) {
+	ctx, span := startQuery(ctx, "SELECT", "embeddings")
+	defer func() { telemetry.End(span, err) }()
 	s.logger.Debugf("Searching top-%d embeddings", k)
 
 	query := fmt.Sprintf(`
 		SELECT text, embedding <=> $1::vector AS distance
 		FROM embeddings
+		WHERE request_hash IS NULL
 		ORDER BY embedding <=> $1::vector
 		LIMIT %d`, k)
-	rows, err := s.db.QueryContext(ctx, query, vectorLiteral)
+	return s.search(ctx, query, toVectorLiteral(embedding))
+}
+
+// SearchScoped searches for similar embeddings stored under scope in the
+// database. The scope is filtered before the top k are taken.
+func (s *PostgresStore) SearchScoped(ctx context.Context, scope storage.Scope, embedding []float32, k int) (_ []storage.SimilarItem, err error) {
+	ctx, span := startQuery(ctx, "SELECT", "embeddings")
+	defer func() { telemetry.End(span, err) }()
+	s.logger.Debugf("Searching top-%d scoped embeddings", k)
+
+	query := fmt.Sprintf(`
+		SELECT text, embedding <=> $1::vector AS distance
+		FROM embeddings
+		WHERE tenant = $2 AND model = $3 AND request_hash = $4
+		ORDER BY embedding <=> $1::vector
+		LIMIT %d`, k)
+	return s.search(ctx, query, toVectorLiteral(embedding), scope.Tenant, scope.Model, scope.RequestHash)
+}
+
+// search runs a similarity query returning text and distance columns
+func (s *PostgresStore) search(ctx context.Context, query string, args ...any) ([]storage.SimilarItem, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		s.logger.Errorf("Search query failed: %v", err)
 		return nil, err
@@ -141,7 +214,9 @@ func (s *PostgresStore) SearchByEmbedding(ctx context.Context, embedding []float
 }
 
 // SearchStrategies searches for similar prompt strategies in the database
-func (s *PostgresStore) SearchStrategies(ctx context.Context, embedding []float32, threshold float64, maxResults int) ([]storage.StrategyItem, error) {
+func (s *PostgresStore) SearchStrategies(ctx context.Context, embedding []float32, threshold float64, maxResults int) (_ []storage.StrategyItem, err error) {
+	ctx, span := startQuery(ctx, "SELECT", "prompt_strategies")
+	defer func() { telemetry.End(span, err) }()
 	vectorLiteral := toVectorLiteral(embedding)
 	s.logger.Debugf("DB-based searching strategies with threshold=%.4f, maxResults=%d", threshold, maxResults)
 	query := `SELECT id, name, path, content, similarity FROM find_similar_strategies($1::vector, $2, $3)`
@@ -169,7 +244,9 @@ func (s *PostgresStore) SearchStrategies(ctx context.Context, embedding []float3
 }
 
 // UpsertStrategy inserts or updates a prompt strategy record in prompt_strategies table.
-func (s *PostgresStore) UpsertStrategy(ctx context.Context, name, path, content string, embedding []float32) (int64, error) {
+func (s *PostgresStore) UpsertStrategy(ctx context.Context, name, path, content string, embedding []float32) (_ int64, err error) {
+	ctx, span := startQuery(ctx, "INSERT", "prompt_strategies")
+	defer func() { telemetry.End(span, err) }()
 	vectorLiteral := toVectorLiteral(embedding)
 	s.logger.Debugf("Upserting strategy %q into prompt_strategies (dimension=%d)", name, s.dimension)
 	upsert := `
@@ -200,6 +277,14 @@ ON CONFLICT (name) DO UPDATE SET
 	return rows, nil
 }
 
+// startQuery starts the client span of a query on table. Missing rows are
+// not failures, so callers decide what ends a span with an error.
+func startQuery(ctx context.Context, operation, table string) (context.Context, trace.Span) {
+	return telemetry.Tracer().Start(ctx, "pgvector "+operation+" "+table,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(semconv.DBSystemPostgreSQL, semconv.DBOperation(operation), semconv.DBSQLTable(table)))
+}
+
 // toVectorLiteral formats a []float32 as a pgvector literal "[x1,x2,...]".
 func toVectorLiteral(vec []float32) string {
 	parts := make([]string, len(vec))
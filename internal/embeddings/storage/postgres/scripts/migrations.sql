@@ -6,7 +6,11 @@ DROP TABLE IF EXISTS embeddings;
 -- Create a table for storing embeddings (legacy format for backward compatibility)
 CREATE TABLE IF NOT EXISTS embeddings (
     text TEXT PRIMARY KEY,
-    embedding vector(%[1]d)
+    embedding vector(%[1]d),
+    -- set on scoped entries such as semantic cache prompts
+    tenant TEXT,
+    model TEXT,
+    request_hash TEXT
 );
 
 -- Create an index for the legacy embeddings table
@@ -14,6 +18,10 @@ CREATE INDEX IF NOT EXISTS idx_embeddings_embedding
     ON embeddings USING ivfflat (embedding vector_cosine_ops)
     WITH (lists = 100);
 
+-- Create an index for scoped searches
+CREATE INDEX IF NOT EXISTS idx_embeddings_scope
+    ON embeddings (tenant, model, request_hash);
+
 -- Create a table for storing prompt strategies with embeddings
 CREATE TABLE IF NOT EXISTS prompt_strategies (
     id SERIAL PRIMARY KEY,
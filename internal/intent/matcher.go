@@ -2,11 +2,18 @@ package intent
 
 import (
 	"context"
+	"fmt"
 	"sort"
 
 	"github.com/raja.aiml/llm-fast-wrapper/internal/embeddings"
+	"github.com/raja.aiml/llm-fast-wrapper/internal/embeddings/storage"
 )
 
+// Embedder embeds text. embeddings.Service satisfies it.
+type Embedder interface {
+	Get(ctx context.Context, text string) ([]float32, error)
+}
+
 type MatchResult struct {
 	Name    string
 	Path    string
@@ -18,31 +25,14 @@ func MatchBestStrategy(
 	ctx context.Context,
 	query string,
 	strategies map[string]string,
-	embedder *embeddings.Service,
+	embedder Embedder,
 	threshold float64,
 ) (*MatchResult, error) {
-	queryEmb, err := embeddings.GetEmbedding(query, "")
+	bestName, bestScore, err := bestStrategy(ctx, query, strategies, embedder)
 	if err != nil {
 		return nil, err
 	}
 
-	names := sortedKeys(strategies)
-	bestScore := -1.0
-	var bestName string
-
-	for _, name := range names {
-		text := strategies[name]
-		emb, err := embedder.Get(ctx, text)
-		if err != nil {
-			continue
-		}
-		score := float64(embeddings.CosineSimilarity(queryEmb, emb))
-		if score > bestScore {
-			bestScore = score
-			bestName = name
-		}
-	}
-
 	if bestScore < threshold {
 		return &MatchResult{
 			Name:    "Default Strategy",
@@ -60,6 +50,107 @@ func MatchBestStrategy(
 	}, nil
 }
 
+// bestStrategy returns the strategy closest to query. Strategies that fail
+// to embed are skipped; the score is -1 when none could be compared.
+func bestStrategy(ctx context.Context, query string, strategies map[string]string, embedder Embedder) (string, float64, error) {
+	queryEmb, err := embedder.Get(ctx, query)
+	if err != nil {
+		return "", 0, err
+	}
+
+	bestScore := -1.0
+	var bestName string
+	for _, name := range sortedKeys(strategies) {
+		emb, err := embedder.Get(ctx, strategies[name])
+		if err != nil {
+			continue
+		}
+		score := float64(embeddings.CosineSimilarity(queryEmb, emb))
+		if score > bestScore {
+			bestScore = score
+			bestName = name
+		}
+	}
+	return bestName, bestScore, nil
+}
+
+// Matcher picks the prompt strategy for a query. Match returns nil when no
+// strategy reaches the matcher's threshold.
+type Matcher interface {
+	Match(ctx context.Context, query string) (*MatchResult, error)
+}
+
+// FileMatcher matches queries against strategy files loaded once from disk.
+// Strategy embeddings are cached by the embedder after the first request.
+type FileMatcher struct {
+	strategies map[string]string
+	paths      map[string]string
+	embedder   Embedder
+	threshold  float64
+}
+
+// NewFileMatcher loads the strategies under dir with extension ext. Unlike
+// LoadStrategyFiles it fails when there are none, rather than falling back to
+// the default strategy.
+func NewFileMatcher(dir, ext string, embedder Embedder, threshold float64) (*FileMatcher, error) {
+	strategies, paths, err := LoadStrategyFiles(dir, ext)
+	if err != nil {
+		return nil, err
+	}
+	if paths["Default Strategy"] == "built-in" {
+		return nil, fmt.Errorf("no strategy files found in %s", dir)
+	}
+	return &FileMatcher{strategies: strategies, paths: paths, embedder: embedder, threshold: threshold}, nil
+}
+
+// Match returns the closest strategy file at or above the threshold.
+func (m *FileMatcher) Match(ctx context.Context, query string) (*MatchResult, error) {
+	name, score, err := bestStrategy(ctx, query, m.strategies, m.embedder)
+	if err != nil {
+		return nil, err
+	}
+	if score < 0 || score < m.threshold {
+		return nil, nil
+	}
+	return &MatchResult{Name: name, Path: m.paths[name], Score: score, Content: m.strategies[name]}, nil
+}
+
+// StrategySearcher searches seeded strategies by embedding. The pgvector
+// store satisfies it with its prompt_strategies table.
+type StrategySearcher interface {
+	SearchStrategies(ctx context.Context, embedding []float32, threshold float64, maxResults int) ([]storage.StrategyItem, error)
+}
+
+// StoreMatcher matches queries against strategies seeded into a vector store
+// with `intent --seed-only`.
+type StoreMatcher struct {
+	embedder  Embedder
+	store     StrategySearcher
+	threshold float64
+}
+
+// NewStoreMatcher creates a StoreMatcher searching store.
+func NewStoreMatcher(embedder Embedder, store StrategySearcher, threshold float64) *StoreMatcher {
+	return &StoreMatcher{embedder: embedder, store: store, threshold: threshold}
+}
+
+// Match returns the closest seeded strategy at or above the threshold.
+func (m *StoreMatcher) Match(ctx context.Context, query string) (*MatchResult, error) {
+	vec, err := m.embedder.Get(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	items, err := m.store.SearchStrategies(ctx, vec, m.threshold, 1)
+	if err != nil {
+		return nil, err
+	}
+	if len(items) == 0 {
+		return nil, nil
+	}
+	item := items[0]
+	return &MatchResult{Name: item.Name, Path: item.Path, Score: item.Similarity, Content: item.Content}, nil
+}
+
 func sortedKeys(m map[string]string) []string {
 	var keys []string
 	for k := range m {
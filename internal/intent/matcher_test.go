@@ -0,0 +1,87 @@
+package intent
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/raja.aiml/llm-fast-wrapper/internal/embeddings/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// keywordEmbedder embeds text by the presence of a few keywords.
+type keywordEmbedder struct{}
+
+func (keywordEmbedder) Get(_ context.Context, text string) ([]float32, error) {
+	if text == "" {
+		return nil, errors.New("empty text")
+	}
+	vec := []float32{0, 0, 0.01}
+	for i, word := range []string{"code", "poem"} {
+		if strings.Contains(strings.ToLower(text), word) {
+			vec[i] = 1
+		}
+	}
+	return vec, nil
+}
+
+func writeStrategies(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "code_review.md"), []byte("Review the code carefully."), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "poetry.md"), []byte("Write the poem in rhyme."), 0o644))
+	return dir
+}
+
+func TestFileMatcher(t *testing.T) {
+	m, err := NewFileMatcher(writeStrategies(t), ".md", keywordEmbedder{}, 0.5)
+	require.NoError(t, err)
+
+	match, err := m.Match(context.Background(), "please check my code")
+	require.NoError(t, err)
+	require.NotNil(t, match)
+	assert.Equal(t, "Code Review", match.Name)
+	assert.Equal(t, "Review the code carefully.", match.Content)
+	assert.True(t, filepath.IsAbs(match.Path))
+	assert.InDelta(t, 1.0, match.Score, 0.01)
+
+	match, err = m.Match(context.Background(), "what is the weather")
+	require.NoError(t, err)
+	assert.Nil(t, match, "below threshold")
+
+	_, err = m.Match(context.Background(), "")
+	assert.Error(t, err)
+}
+
+func TestNewFileMatcherWithoutFiles(t *testing.T) {
+	_, err := NewFileMatcher(t.TempDir(), ".md", keywordEmbedder{}, 0.5)
+	assert.ErrorContains(t, err, "no strategy files")
+}
+
+type fakeSearcher struct {
+	items     []storage.StrategyItem
+	threshold float64
+}
+
+func (f *fakeSearcher) SearchStrategies(_ context.Context, _ []float32, threshold float64, _ int) ([]storage.StrategyItem, error) {
+	f.threshold = threshold
+	return f.items, nil
+}
+
+func TestStoreMatcher(t *testing.T) {
+	store := &fakeSearcher{items: []storage.StrategyItem{{Name: "Poetry", Path: "poetry.md", Content: "rhyme", Similarity: 0.8}}}
+	m := NewStoreMatcher(keywordEmbedder{}, store, 0.7)
+	match, err := m.Match(context.Background(), "a poem")
+	require.NoError(t, err)
+	assert.Equal(t, &MatchResult{Name: "Poetry", Path: "poetry.md", Score: 0.8, Content: "rhyme"}, match)
+	assert.Equal(t, 0.7, store.threshold, "threshold is applied by the store")
+
+	store.items = nil
+	match, err = m.Match(context.Background(), "a poem")
+	require.NoError(t, err)
+	assert.Nil(t, match)
+}
@@ -0,0 +1,163 @@
+package llm
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/raja.aiml/llm-fast-wrapper/internal/tokenizer"
+)
+
+// ChatCompletion is the non-streaming chat completion response object.
+type ChatCompletion struct {
+	ID      string                  `json:"id"`
+	Object  string                  `json:"object"`
+	Created int64                   `json:"created"`
+	Model   string                  `json:"model"`
+	Choices []ChatCompletionMessage `json:"choices"`
+	Usage   Usage                   `json:"usage"`
+}
+
+// ChatCompletionMessage is a single choice of a non-streaming response.
+type ChatCompletionMessage struct {
+	Index        int     `json:"index"`
+	Message      Message `json:"message"`
+	FinishReason string  `json:"finish_reason"`
+}
+
+// Usage reports token counts for a completion.
+type Usage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+// IsZero reports whether no tokens were counted.
+func (u Usage) IsZero() bool { return u == Usage{} }
+
+// CountUsage computes Usage from the prompt and completion text with the
+// tokenizer of model (see tokenizer.CountTokens).
+func CountUsage(model, prompt, completion string) Usage {
+	p := tokenizer.CountTokens(model, prompt)
+	c := tokenizer.CountTokens(model, completion)
+	return Usage{PromptTokens: p, CompletionTokens: c, TotalTokens: p + c}
+}
+
+// Collect drains a chunk stream and assembles the equivalent non-streaming
+// ChatCompletion, joining content, refusals and tool call fragments per
+// choice. Usage is copied from the stream when the upstream reported it and
+// left zero otherwise. It returns the first stream error encountered.
+// Choices without an explicit finish reason are reported as "tool_calls"
+// when they called a tool and "stop" otherwise.
+func Collect(ch <-chan ChatCompletionChunk) (*ChatCompletion, error) {
+	completion := &ChatCompletion{Object: "chat.completion"}
+	choices := make(map[int]*collected)
+
+	for chunk := range ch {
+		if chunk.Err != nil {
+			return nil, chunk.Err
+		}
+		if completion.ID == "" {
+			completion.ID = chunk.ID
+			completion.Created = chunk.Created
+			completion.Model = chunk.Model
+		}
+		if chunk.Usage != nil {
+			completion.Usage = *chunk.Usage
+		}
+		for _, choice := range chunk.Choices {
+			c, ok := choices[choice.Index]
+			if !ok {
+				c = &collected{}
+				choices[choice.Index] = c
+			}
+			c.add(choice)
+		}
+	}
+
+	indexes := make([]int, 0, len(choices))
+	for i := range choices {
+		indexes = append(indexes, i)
+	}
+	sort.Ints(indexes)
+	completion.Choices = make([]ChatCompletionMessage, 0, len(indexes))
+	for _, i := range indexes {
+		completion.Choices = append(completion.Choices, choices[i].message(i))
+	}
+	return completion, nil
+}
+
+// collected accumulates the deltas of one choice.
+type collected struct {
+	role      string
+	content   strings.Builder
+	refusal   strings.Builder
+	toolCalls []ToolCall
+	// calls maps the index of a streamed tool call to its place in toolCalls.
+	calls  map[int]int
+	reason string
+}
+
+func (c *collected) add(choice ChatCompletionChoice) {
+	if choice.Delta.Role != "" {
+		c.role = choice.Delta.Role
+	}
+	c.content.WriteString(choice.Delta.Content)
+	c.refusal.WriteString(choice.Delta.Refusal)
+	for _, fragment := range choice.Delta.ToolCalls {
+		c.addToolCall(fragment)
+	}
+	if choice.FinishReason != nil {
+		c.reason = *choice.FinishReason
+	}
+}
+
+// addToolCall merges a fragment into the call with the same index, or
+// starts a new call. Fragments without an index are whole calls.
+func (c *collected) addToolCall(fragment ToolCall) {
+	if fragment.Index != nil {
+		if i, ok := c.calls[*fragment.Index]; ok {
+			call := &c.toolCalls[i]
+			call.Function.Name += fragment.Function.Name
+			call.Function.Arguments += fragment.Function.Arguments
+			if fragment.ID != "" {
+				call.ID = fragment.ID
+			}
+			if fragment.Type != "" {
+				call.Type = fragment.Type
+			}
+			return
+		}
+		if c.calls == nil {
+			c.calls = make(map[int]int)
+		}
+		c.calls[*fragment.Index] = len(c.toolCalls)
+	}
+	fragment.Index = nil
+	if fragment.Type == "" {
+		fragment.Type = "function"
+	}
+	c.toolCalls = append(c.toolCalls, fragment)
+}
+
+func (c *collected) message(index int) ChatCompletionMessage {
+	role, reason := c.role, c.reason
+	if role == "" {
+		role = "assistant"
+	}
+	if reason == "" {
+		reason = "stop"
+		if len(c.toolCalls) > 0 {
+			reason = "tool_calls"
+		}
+	}
+	return ChatCompletionMessage{
+		Index: index,
+		Message: Message{
+			Role:      role,
+			Content:   c.content.String(),
+			Refusal:   c.refusal.String(),
+			ToolCalls: c.toolCalls,
+		},
+		FinishReason: reason,
+	}
+}
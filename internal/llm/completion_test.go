@@ -0,0 +1,99 @@
+package llm_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/raja.aiml/llm-fast-wrapper/internal/llm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func chunkStream(chunks ...llm.ChatCompletionChunk) <-chan llm.ChatCompletionChunk {
+	ch := make(chan llm.ChatCompletionChunk, len(chunks))
+	for _, c := range chunks {
+		ch <- c
+	}
+	close(ch)
+	return ch
+}
+
+func contentChunk(index int, content string, finish string) llm.ChatCompletionChunk {
+	choice := llm.ChatCompletionChoice{Index: index, Delta: llm.Delta{Content: content}}
+	if finish != "" {
+		choice.FinishReason = &finish
+	}
+	return llm.ChatCompletionChunk{
+		ID:      "chatcmpl-1",
+		Object:  "chat.completion.chunk",
+		Created: 42,
+		Model:   "gpt-test",
+		Choices: []llm.ChatCompletionChoice{choice},
+	}
+}
+
+func TestCollect(t *testing.T) {
+	completion, err := llm.Collect(chunkStream(
+		contentChunk(0, "Hello", ""),
+		contentChunk(0, " world", ""),
+		contentChunk(0, "", "length"),
+	))
+	require.NoError(t, err)
+
+	assert.Equal(t, "chatcmpl-1", completion.ID)
+	assert.Equal(t, "chat.completion", completion.Object)
+	assert.Equal(t, int64(42), completion.Created)
+	assert.Equal(t, "gpt-test", completion.Model)
+	require.Len(t, completion.Choices, 1)
+	assert.Equal(t, "assistant", completion.Choices[0].Message.Role)
+	assert.Equal(t, "Hello world", completion.Choices[0].Message.Content)
+	assert.Equal(t, "length", completion.Choices[0].FinishReason)
+}
+
+func TestCollect_DefaultsFinishReason(t *testing.T) {
+	completion, err := llm.Collect(chunkStream(contentChunk(0, "hi", "")))
+	require.NoError(t, err)
+	assert.Equal(t, "stop", completion.Choices[0].FinishReason)
+}
+
+func TestCollect_Error(t *testing.T) {
+	_, err := llm.Collect(chunkStream(
+		contentChunk(0, "partial", ""),
+		llm.ChatCompletionChunk{Err: errors.New("upstream reset")},
+	))
+	assert.EqualError(t, err, "upstream reset")
+}
+
+func TestCountUsage(t *testing.T) {
+	usage := llm.CountUsage("gpt-4o", "one two three", "four")
+	assert.Equal(t, 3, usage.PromptTokens)
+	assert.Equal(t, 1, usage.CompletionTokens)
+	assert.Equal(t, 4, usage.TotalTokens)
+}
+
+func TestCollect_ToolCallsAndRefusal(t *testing.T) {
+	zero, one := 0, 1
+	call := func(index *int, id, name, args string) llm.ChatCompletionChunk {
+		chunk := contentChunk(0, "", "")
+		chunk.Choices[0].Delta.ToolCalls = []llm.ToolCall{{Index: index, ID: id, Function: llm.FunctionCall{Name: name, Arguments: args}}}
+		return chunk
+	}
+	completion, err := llm.Collect(chunkStream(
+		call(&zero, "call_a", "f", `{"x":`),
+		call(&one, "call_b", "g", `{}`),
+		call(&zero, "", "", `1}`),
+	))
+	require.NoError(t, err)
+	choice := completion.Choices[0]
+	assert.Equal(t, "tool_calls", choice.FinishReason)
+	assert.Equal(t, []llm.ToolCall{
+		{ID: "call_a", Type: "function", Function: llm.FunctionCall{Name: "f", Arguments: `{"x":1}`}},
+		{ID: "call_b", Type: "function", Function: llm.FunctionCall{Name: "g", Arguments: `{}`}},
+	}, choice.Message.ToolCalls)
+
+	refusal := contentChunk(0, "", "stop")
+	refusal.Choices[0].Delta = llm.Delta{Role: "assistant", Refusal: "no"}
+	completion, err = llm.Collect(chunkStream(refusal))
+	require.NoError(t, err)
+	assert.Equal(t, llm.Message{Role: "assistant", Refusal: "no"}, completion.Choices[0].Message)
+}
@@ -0,0 +1,130 @@
+package llm
+
+import (
+	"context"
+	"sync"
+)
+
+// FanOutStreamer serves requests for several choices from a Streamer whose
+// upstream only generates one: it issues n parallel single-choice requests
+// and interleaves their chunks as they arrive, renumbering each stream's
+// choice to its own index. Requests for one choice pass straight through.
+type FanOutStreamer struct {
+	next Streamer
+}
+
+// NewFanOutStreamer wraps next so that it honours n.
+func NewFanOutStreamer(next Streamer) Streamer { return &FanOutStreamer{next: next} }
+
+// fannedChunk is a chunk of the stream generating choice index.
+type fannedChunk struct {
+	index int
+	chunk ChatCompletionChunk
+}
+
+// Stream starts every request before returning, so a failure to start any
+// of them is returned as an error and the others are canceled. Chunks share
+// the ID and creation time of the first chunk received, usage reported by
+// the streams is summed into a single usage-only chunk sent last, and a
+// failure mid-stream ends the whole stream.
+func (f *FanOutStreamer) Stream(ctx context.Context, req *ChatCompletionRequest) (<-chan ChatCompletionChunk, error) {
+	n := req.Choices()
+	if n <= 1 {
+		return f.next.Stream(ctx, req)
+	}
+	single := *req
+	single.N = nil
+
+	ctx, cancel := context.WithCancel(ctx)
+	streams := make([]<-chan ChatCompletionChunk, n)
+	errs := make([]error, n)
+	var started sync.WaitGroup
+	for i := range n {
+		started.Add(1)
+		go func() {
+			defer started.Done()
+			streams[i], errs[i] = f.next.Stream(ctx, &single)
+		}()
+	}
+	started.Wait()
+	for _, err := range errs {
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+	}
+
+	merged := make(chan fannedChunk)
+	var forwarding sync.WaitGroup
+	for i, ch := range streams {
+		forwarding.Add(1)
+		go func() {
+			defer forwarding.Done()
+			for chunk := range ch {
+				select {
+				case merged <- fannedChunk{index: i, chunk: chunk}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+	go func() {
+		forwarding.Wait()
+		close(merged)
+	}()
+
+	out := make(chan ChatCompletionChunk)
+	go func() {
+		defer close(out)
+		defer cancel()
+		send := func(chunk ChatCompletionChunk) bool {
+			select {
+			case out <- chunk:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+		var first *ChatCompletionChunk
+		var usage *Usage
+		for m := range merged {
+			chunk := m.chunk
+			if chunk.Err != nil {
+				send(chunk)
+				return
+			}
+			if first == nil {
+				c := chunk
+				first = &c
+			}
+			if chunk.Usage != nil {
+				if usage == nil {
+					usage = &Usage{}
+				}
+				usage.PromptTokens += chunk.Usage.PromptTokens
+				usage.CompletionTokens += chunk.Usage.CompletionTokens
+				usage.TotalTokens += chunk.Usage.TotalTokens
+				chunk.Usage = nil
+				if len(chunk.Choices) == 0 {
+					continue
+				}
+			}
+			chunk.ID, chunk.Created = first.ID, first.Created
+			// copied, as the stream may still hold the original
+			chunk.Choices = append([]ChatCompletionChoice(nil), chunk.Choices...)
+			for i := range chunk.Choices {
+				chunk.Choices[i].Index = m.index
+			}
+			if !send(chunk) {
+				return
+			}
+		}
+		if usage != nil && ctx.Err() == nil {
+			last := *first
+			last.Choices, last.Usage = []ChatCompletionChoice{}, usage
+			send(last)
+		}
+	}()
+	return out, nil
+}
@@ -0,0 +1,139 @@
+package llm_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+
+	"github.com/raja.aiml/llm-fast-wrapper/internal/llm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// singleChoiceStreamer answers every request with one choice holding the
+// number of the call, like an upstream that ignores n.
+type singleChoiceStreamer struct {
+	calls atomic.Int32
+	fail  func(call int32) error
+	last  atomic.Pointer[llm.ChatCompletionRequest]
+}
+
+func (s *singleChoiceStreamer) Stream(ctx context.Context, req *llm.ChatCompletionRequest) (<-chan llm.ChatCompletionChunk, error) {
+	call := s.calls.Add(1)
+	s.last.Store(req)
+	if s.fail != nil {
+		if err := s.fail(call); err != nil {
+			return nil, err
+		}
+	}
+	stop, usage := "stop", llm.Usage{PromptTokens: 3, CompletionTokens: 2, TotalTokens: 5}
+	base := llm.ChatCompletionChunk{ID: fmt.Sprintf("chatcmpl-%d", call), Object: "chat.completion.chunk", Created: int64(call)}
+	first, last, metered := base, base, base
+	first.Choices = []llm.ChatCompletionChoice{{Delta: llm.Delta{Content: fmt.Sprintf("answer %d", call)}}}
+	last.Choices = []llm.ChatCompletionChoice{{FinishReason: &stop}}
+	metered.Choices, metered.Usage = []llm.ChatCompletionChoice{}, &usage
+	return replay(first, last, metered), nil
+}
+
+func replay(chunks ...llm.ChatCompletionChunk) <-chan llm.ChatCompletionChunk {
+	ch := make(chan llm.ChatCompletionChunk, len(chunks))
+	for _, c := range chunks {
+		ch <- c
+	}
+	close(ch)
+	return ch
+}
+
+func choicesRequest(n int64) *llm.ChatCompletionRequest {
+	req := userRequest("hi")
+	req.N = &n
+	return req
+}
+
+func TestFanOutStreamer(t *testing.T) {
+	next := &singleChoiceStreamer{}
+	ch, err := llm.NewFanOutStreamer(next).Stream(context.Background(), choicesRequest(3))
+	require.NoError(t, err)
+	assert.Equal(t, int32(3), next.calls.Load())
+	assert.Nil(t, next.last.Load().N, "upstream requests ask for one choice")
+
+	var chunks []llm.ChatCompletionChunk
+	for c := range ch {
+		require.NoError(t, c.Err)
+		chunks = append(chunks, c)
+	}
+	for _, c := range chunks {
+		assert.Equal(t, chunks[0].ID, c.ID)
+		assert.Equal(t, chunks[0].Created, c.Created)
+	}
+	usage := chunks[len(chunks)-1]
+	require.NotNil(t, usage.Usage, "usage is sent last")
+	assert.Empty(t, usage.Choices)
+	assert.Equal(t, llm.Usage{PromptTokens: 9, CompletionTokens: 6, TotalTokens: 15}, *usage.Usage)
+
+	completion, err := llm.Collect(replay(chunks...))
+	require.NoError(t, err)
+	require.Len(t, completion.Choices, 3)
+	answers := make(map[string]bool)
+	for i, c := range completion.Choices {
+		assert.Equal(t, i, c.Index)
+		assert.Equal(t, "stop", c.FinishReason)
+		answers[c.Message.Content] = true
+	}
+	assert.Len(t, answers, 3, "each choice comes from its own request")
+}
+
+func TestFanOutStreamer_SingleChoice(t *testing.T) {
+	next := &singleChoiceStreamer{}
+	req := choicesRequest(1)
+	ch, err := llm.NewFanOutStreamer(next).Stream(context.Background(), req)
+	require.NoError(t, err)
+	for range ch {
+	}
+	assert.Equal(t, int32(1), next.calls.Load())
+	assert.Same(t, req, next.last.Load(), "requests for one choice pass through")
+}
+
+func TestFanOutStreamer_StartFailure(t *testing.T) {
+	next := &singleChoiceStreamer{fail: func(call int32) error {
+		if call == 2 {
+			return errors.New("upstream unavailable")
+		}
+		return nil
+	}}
+	_, err := llm.NewFanOutStreamer(next).Stream(context.Background(), choicesRequest(3))
+	require.EqualError(t, err, "upstream unavailable")
+}
+
+func TestFanOutStreamer_MidStreamError(t *testing.T) {
+	var calls atomic.Int32
+	next := streamerFunc(func(ctx context.Context, req *llm.ChatCompletionRequest) (<-chan llm.ChatCompletionChunk, error) {
+		if calls.Add(1) == 1 {
+			return replay(llm.ChatCompletionChunk{Err: errors.New("connection reset")}), nil
+		}
+		// the other stream never ends on its own
+		ch := make(chan llm.ChatCompletionChunk)
+		go func() {
+			defer close(ch)
+			<-ctx.Done()
+		}()
+		return ch, nil
+	})
+	ch, err := llm.NewFanOutStreamer(next).Stream(context.Background(), choicesRequest(2))
+	require.NoError(t, err)
+
+	var last llm.ChatCompletionChunk
+	for c := range ch {
+		last = c
+	}
+	require.Error(t, last.Err)
+	assert.Contains(t, last.Err.Error(), "connection reset")
+}
+
+type streamerFunc func(context.Context, *llm.ChatCompletionRequest) (<-chan llm.ChatCompletionChunk, error)
+
+func (f streamerFunc) Stream(ctx context.Context, req *llm.ChatCompletionRequest) (<-chan llm.ChatCompletionChunk, error) {
+	return f(ctx, req)
+}
@@ -1,6 +1,13 @@
 package llm
 
-import "context"
+import (
+	"context"
+	"errors"
+)
+
+// ErrModelNotFound is returned by a Streamer that cannot serve the
+// requested model.
+var ErrModelNotFound = errors.New("model not found")
 
 // ChatCompletionChunk represents a single chunk of a streamed chat completion
 // response matching the OpenAI specification.
@@ -8,7 +15,14 @@ type ChatCompletionChunk struct {
 	ID      string                 `json:"id"`
 	Object  string                 `json:"object"`
 	Created int64                  `json:"created"`
+	Model   string                 `json:"model"`
 	Choices []ChatCompletionChoice `json:"choices"`
+	// Usage is set on the final, usage-only chunk of a stream.
+	Usage *Usage `json:"usage,omitempty"`
+
+	// Err is set on the final value sent by a Streamer when the upstream
+	// stream fails after it has started. It is never serialized.
+	Err error `json:"-"`
 }
 
 // ChatCompletionChoice contains the partial message delta for a streamed chunk.
@@ -18,12 +32,17 @@ type ChatCompletionChoice struct {
 	FinishReason *string `json:"finish_reason,omitempty"`
 }
 
-// Delta holds the incremental content for the chunk.
+// Delta holds the incremental content for the chunk. Role is set on the
+// first chunk of a choice; ToolCalls carries fragments of the tool calls.
 type Delta struct {
-	Content string `json:"content,omitempty"`
+	Role      string     `json:"role,omitempty"`
+	Content   string     `json:"content,omitempty"`
+	Refusal   string     `json:"refusal,omitempty"`
+	ToolCalls []ToolCall `json:"tool_calls,omitempty"`
 }
 
 // Streamer streams chat completions following the OpenAI streaming format.
+// Implementations must treat req as read-only.
 type Streamer interface {
-	Stream(ctx context.Context, prompt string) (<-chan ChatCompletionChunk, error)
+	Stream(ctx context.Context, req *ChatCompletionRequest) (<-chan ChatCompletionChunk, error)
 }
@@ -0,0 +1,79 @@
+package llm
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// MockStreamer echoes the prompt back without contacting any upstream. It is
+// used by the `--backend mock` mode of the serve command and in tests.
+type MockStreamer struct{}
+
+func NewMockStreamer() Streamer { return &MockStreamer{} }
+
+// Stream returns mock chat completion chunks that follow the OpenAI streaming
+// specification. The implementation simply splits the user messages into
+// tokens and emits one token per chunk with slight delays to mimic network
+// latency. When n asks for several choices, each token is sent once per
+// choice, interleaved as an upstream would.
+func (m *MockStreamer) Stream(ctx context.Context, req *ChatCompletionRequest) (<-chan ChatCompletionChunk, error) {
+	var prompt string
+	for _, msg := range req.Messages {
+		if msg.Role == "user" {
+			prompt += msg.Content + " "
+		}
+	}
+	model := req.Model
+	if model == "" {
+		model = "mock"
+	}
+
+	ch := make(chan ChatCompletionChunk)
+	go func() {
+		defer close(ch)
+		tokens := strings.Fields(prompt)
+		id := "chatcmpl-mock"
+		created := time.Now().Unix()
+		send := func(choice ChatCompletionChoice) bool {
+			select {
+			case ch <- ChatCompletionChunk{
+				ID:      id,
+				Object:  "chat.completion.chunk",
+				Created: created,
+				Model:   model,
+				Choices: []ChatCompletionChoice{choice},
+			}:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+		for _, t := range tokens {
+			select {
+			case <-ctx.Done():
+				return
+			default:
+			}
+
+			for i := range req.Choices() {
+				if !send(ChatCompletionChoice{Delta: Delta{Content: t + " "}, Index: i}) {
+					return
+				}
+			}
+
+			select {
+			case <-time.After(100 * time.Millisecond):
+			case <-ctx.Done():
+				return
+			}
+		}
+		stop := "stop"
+		for i := range req.Choices() {
+			if !send(ChatCompletionChoice{Delta: Delta{}, Index: i, FinishReason: &stop}) {
+				return
+			}
+		}
+	}()
+	return ch, nil
+}
@@ -2,66 +2,248 @@ package llm
 
 import (
 	"context"
-	"strings"
-	"time"
+	"encoding/json"
+	"fmt"
+
+	openai "github.com/openai/openai-go"
+	"github.com/openai/openai-go/option"
+	"github.com/openai/openai-go/packages/param"
+	"github.com/openai/openai-go/shared"
+	"github.com/raja.aiml/llm-fast-wrapper/internal/config"
+	"github.com/raja.aiml/llm-fast-wrapper/internal/telemetry"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
-type OpenAIStreamer struct{}
+// OpenAIStreamer streams chat completions from an OpenAI-compatible upstream
+// using the openai-go client.
+type OpenAIStreamer struct {
+	client openai.Client
+	model  string
+}
+
+// NewOpenAIStreamer creates a Streamer for the upstream at baseURL. An empty
+// baseURL targets the public OpenAI API; model is used for requests that do
+// not name one. opts are applied to the underlying client.
+func NewOpenAIStreamer(apiKey, baseURL, model string, opts ...option.RequestOption) Streamer {
+	if model == "" {
+		model = config.DefaultModel
+	}
+	return &OpenAIStreamer{
+		client: config.NewClient(apiKey, baseURL, opts...),
+		model:  model,
+	}
+}
 
-func NewOpenAIStreamer() Streamer { return &OpenAIStreamer{} }
+// Stream sends the request upstream and converts each stream event into a
+// ChatCompletionChunk. Errors raised before the stream is established are
+// returned directly; later failures are delivered as a final chunk with Err set.
+func (o *OpenAIStreamer) Stream(ctx context.Context, req *ChatCompletionRequest) (<-chan ChatCompletionChunk, error) {
+	params, err := o.toParams(req)
+	if err != nil {
+		return nil, err
+	}
+	ctx, span := telemetry.Tracer().Start(ctx, "llm.upstream", trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("llm.model", params.Model)))
+	stream := o.client.Chat.Completions.NewStreaming(ctx, params)
+	if err := stream.Err(); err != nil {
+		_ = stream.Close()
+		telemetry.End(span, err)
+		return nil, err
+	}
 
-// Stream returns mock chat completion chunks that follow the OpenAI streaming
-// specification. The implementation simply splits the prompt into tokens and
-// emits one token per chunk with slight delays to mimic network latency.
-func (o *OpenAIStreamer) Stream(ctx context.Context, prompt string) (<-chan ChatCompletionChunk, error) {
 	ch := make(chan ChatCompletionChunk)
 	go func() {
 		defer close(ch)
-		tokens := strings.Fields(prompt)
-		id := "chatcmpl-mock"
-		created := time.Now().Unix()
-		for _, t := range tokens {
-			select {
-			case <-ctx.Done():
-				return
-			default:
+		var streamErr error
+		defer func() { telemetry.End(span, streamErr) }()
+		defer stream.Close()
+		first := true
+		for stream.Next() {
+			if first {
+				span.AddEvent("first_token")
+				first = false
 			}
-
 			select {
-			case ch <- ChatCompletionChunk{
-				ID:      id,
-				Object:  "chat.completion.chunk",
-				Created: created,
-				Choices: []ChatCompletionChoice{{
-					Delta: Delta{Content: t + " "},
-					Index: 0,
-				}},
-			}:
+			case ch <- fromOpenAIChunk(stream.Current()):
 			case <-ctx.Done():
 				return
 			}
-
+		}
+		if err := stream.Err(); err != nil && ctx.Err() == nil {
+			streamErr = err
 			select {
-			case <-time.After(100 * time.Millisecond):
+			case ch <- ChatCompletionChunk{Err: err}:
 			case <-ctx.Done():
-				return
 			}
 		}
-		stop := "stop"
-		select {
-		case ch <- ChatCompletionChunk{
-			ID:      id,
-			Object:  "chat.completion.chunk",
-			Created: created,
-			Choices: []ChatCompletionChoice{{
-				Delta:        Delta{},
-				Index:        0,
-				FinishReason: &stop,
-			}},
-		}:
-		case <-ctx.Done():
-			return
-		}
 	}()
 	return ch, nil
 }
+
+// toParams maps the wrapper request onto openai-go request parameters.
+func (o *OpenAIStreamer) toParams(req *ChatCompletionRequest) (openai.ChatCompletionNewParams, error) {
+	params := openai.ChatCompletionNewParams{
+		Model: req.Model,
+		// always ask for the upstream's token counts, whatever the client wants
+		StreamOptions: openai.ChatCompletionStreamOptionsParam{IncludeUsage: openai.Bool(true)},
+	}
+	if params.Model == "" {
+		params.Model = o.model
+	}
+	for _, m := range req.Messages {
+		msg, err := toMessageParam(m)
+		if err != nil {
+			return params, err
+		}
+		params.Messages = append(params.Messages, msg)
+	}
+	if req.Temperature != nil {
+		params.Temperature = openai.Float(*req.Temperature)
+	}
+	if req.TopP != nil {
+		params.TopP = openai.Float(*req.TopP)
+	}
+	if req.MaxTokens != nil {
+		params.MaxTokens = openai.Int(*req.MaxTokens)
+	}
+	if req.Seed != nil {
+		params.Seed = openai.Int(*req.Seed)
+	}
+	if req.N != nil {
+		params.N = openai.Int(*req.N)
+	}
+	if req.User != "" {
+		params.User = openai.String(req.User)
+	}
+	if len(req.Stop) > 0 {
+		params.Stop = openai.ChatCompletionNewParamsStopUnion{OfStringArray: req.Stop}
+	}
+	for _, tool := range req.Tools {
+		fn, err := toFunctionParam(tool.Function)
+		if err != nil {
+			return params, err
+		}
+		params.Tools = append(params.Tools, openai.ChatCompletionToolParam{Function: fn})
+	}
+	if c := req.ToolChoice; c != nil {
+		if c.Function != "" {
+			params.ToolChoice = openai.ChatCompletionToolChoiceOptionParamOfChatCompletionNamedToolChoice(
+				openai.ChatCompletionNamedToolChoiceFunctionParam{Name: c.Function})
+		} else {
+			params.ToolChoice = openai.ChatCompletionToolChoiceOptionUnionParam{OfAuto: openai.String(c.Mode)}
+		}
+	}
+	if req.ParallelToolCalls != nil {
+		params.ParallelToolCalls = openai.Bool(*req.ParallelToolCalls)
+	}
+	return params, nil
+}
+
+// toFunctionParam converts a function definition, decoding its schema.
+func toFunctionParam(def FunctionDefinition) (shared.FunctionDefinitionParam, error) {
+	fn := shared.FunctionDefinitionParam{Name: def.Name}
+	if def.Description != "" {
+		fn.Description = openai.String(def.Description)
+	}
+	if def.Strict != nil {
+		fn.Strict = openai.Bool(*def.Strict)
+	}
+	if len(def.Parameters) > 0 {
+		if err := json.Unmarshal(def.Parameters, &fn.Parameters); err != nil {
+			return fn, fmt.Errorf("tool %q: parameters must be a JSON object: %w", def.Name, err)
+		}
+	}
+	return fn, nil
+}
+
+// toMessageParam converts a single message, preserving its role and name.
+func toMessageParam(m Message) (openai.ChatCompletionMessageParamUnion, error) {
+	var name param.Opt[string]
+	if m.Name != "" {
+		name = openai.String(m.Name)
+	}
+	switch m.Role {
+	case "system":
+		return openai.ChatCompletionMessageParamUnion{OfSystem: &openai.ChatCompletionSystemMessageParam{
+			Content: openai.ChatCompletionSystemMessageParamContentUnion{OfString: openai.String(m.Content)},
+			Name:    name,
+		}}, nil
+	case "developer":
+		return openai.ChatCompletionMessageParamUnion{OfDeveloper: &openai.ChatCompletionDeveloperMessageParam{
+			Content: openai.ChatCompletionDeveloperMessageParamContentUnion{OfString: openai.String(m.Content)},
+			Name:    name,
+		}}, nil
+	case "user":
+		return openai.ChatCompletionMessageParamUnion{OfUser: &openai.ChatCompletionUserMessageParam{
+			Content: openai.ChatCompletionUserMessageParamContentUnion{OfString: openai.String(m.Content)},
+			Name:    name,
+		}}, nil
+	case "assistant":
+		msg := &openai.ChatCompletionAssistantMessageParam{Name: name}
+		// content is optional when the message calls tools or refuses
+		if m.Content != "" || (len(m.ToolCalls) == 0 && m.Refusal == "") {
+			msg.Content = openai.ChatCompletionAssistantMessageParamContentUnion{OfString: openai.String(m.Content)}
+		}
+		if m.Refusal != "" {
+			msg.Refusal = openai.String(m.Refusal)
+		}
+		for _, call := range m.ToolCalls {
+			msg.ToolCalls = append(msg.ToolCalls, openai.ChatCompletionMessageToolCallParam{
+				ID: call.ID,
+				Function: openai.ChatCompletionMessageToolCallFunctionParam{
+					Name:      call.Function.Name,
+					Arguments: call.Function.Arguments,
+				},
+			})
+		}
+		return openai.ChatCompletionMessageParamUnion{OfAssistant: msg}, nil
+	case "tool":
+		return openai.ToolMessage(m.Content, m.ToolCallID), nil
+	default:
+		return openai.ChatCompletionMessageParamUnion{}, fmt.Errorf("unsupported message role %q", m.Role)
+	}
+}
+
+// fromOpenAIChunk maps an openai-go stream event onto the wrapper's chunk type.
+func fromOpenAIChunk(c openai.ChatCompletionChunk) ChatCompletionChunk {
+	chunk := ChatCompletionChunk{
+		ID:      c.ID,
+		Object:  string(c.Object),
+		Created: c.Created,
+		Model:   c.Model,
+		Choices: make([]ChatCompletionChoice, 0, len(c.Choices)),
+	}
+	if c.JSON.Usage.Valid() {
+		chunk.Usage = &Usage{
+			PromptTokens:     int(c.Usage.PromptTokens),
+			CompletionTokens: int(c.Usage.CompletionTokens),
+			TotalTokens:      int(c.Usage.TotalTokens),
+		}
+	}
+	for _, choice := range c.Choices {
+		out := ChatCompletionChoice{
+			Delta: Delta{
+				Role:    choice.Delta.Role,
+				Content: choice.Delta.Content,
+				Refusal: choice.Delta.Refusal,
+			},
+			Index: int(choice.Index),
+		}
+		for _, call := range choice.Delta.ToolCalls {
+			index := int(call.Index)
+			out.Delta.ToolCalls = append(out.Delta.ToolCalls, ToolCall{
+				Index:    &index,
+				ID:       call.ID,
+				Type:     call.Type,
+				Function: FunctionCall{Name: call.Function.Name, Arguments: call.Function.Arguments},
+			})
+		}
+		if choice.FinishReason != "" {
+			reason := choice.FinishReason
+			out.FinishReason = &reason
+		}
+		chunk.Choices = append(chunk.Choices, out)
+	}
+	return chunk
+}
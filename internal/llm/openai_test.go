@@ -0,0 +1,320 @@
+package llm_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/raja.aiml/llm-fast-wrapper/internal/llm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func newUpstream(t *testing.T, handler http.HandlerFunc) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func userRequest(content string) *llm.ChatCompletionRequest {
+	return &llm.ChatCompletionRequest{Messages: []llm.Message{{Role: "user", Content: content}}}
+}
+
+func writeSSE(w http.ResponseWriter, events ...string) {
+	w.Header().Set("Content-Type", "text/event-stream")
+	for _, e := range events {
+		fmt.Fprintf(w, "data: %s\n\n", e)
+	}
+	fmt.Fprint(w, "data: [DONE]\n\n")
+}
+
+func TestOpenAIStreamer_Stream(t *testing.T) {
+	srv := newUpstream(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/chat/completions", r.URL.Path)
+		assert.Equal(t, "Bearer test-key", r.Header.Get("Authorization"))
+		writeSSE(w,
+			`{"id":"chatcmpl-1","object":"chat.completion.chunk","created":1,"model":"gpt-test","choices":[{"index":0,"delta":{"content":"Hello"}}]}`,
+			`{"id":"chatcmpl-1","object":"chat.completion.chunk","created":1,"model":"gpt-test","choices":[{"index":0,"delta":{"content":" world"},"finish_reason":"stop"}]}`,
+		)
+	})
+
+	streamer := llm.NewOpenAIStreamer("test-key", srv.URL, "gpt-test")
+	ch, err := streamer.Stream(context.Background(), userRequest("hi"))
+	require.NoError(t, err)
+
+	var chunks []llm.ChatCompletionChunk
+	for c := range ch {
+		require.NoError(t, c.Err)
+		chunks = append(chunks, c)
+	}
+	require.Len(t, chunks, 2)
+	assert.Equal(t, "chatcmpl-1", chunks[0].ID)
+	assert.Equal(t, "chat.completion.chunk", chunks[0].Object)
+	assert.Equal(t, "gpt-test", chunks[0].Model)
+	assert.Equal(t, "Hello", chunks[0].Choices[0].Delta.Content)
+	assert.Nil(t, chunks[0].Choices[0].FinishReason)
+	require.NotNil(t, chunks[1].Choices[0].FinishReason)
+	assert.Equal(t, "stop", *chunks[1].Choices[0].FinishReason)
+}
+
+func TestOpenAIStreamer_ForwardsRequest(t *testing.T) {
+	var body map[string]any
+	srv := newUpstream(t, func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		writeSSE(w)
+	})
+
+	temperature, topP := 0.2, 0.9
+	maxTokens, seed, n := int64(64), int64(7), int64(2)
+	req := &llm.ChatCompletionRequest{
+		Model: "gpt-custom",
+		Messages: []llm.Message{
+			{Role: "system", Content: "be terse"},
+			{Role: "user", Content: "hi", Name: "alice"},
+			{Role: "assistant", Content: "hello"},
+			{Role: "user", Content: "again"},
+		},
+		Temperature: &temperature,
+		TopP:        &topP,
+		MaxTokens:   &maxTokens,
+		Stop:        llm.StopSequences{"END"},
+		Seed:        &seed,
+		User:        "user-1",
+		N:           &n,
+	}
+	ch, err := llm.NewOpenAIStreamer("test-key", srv.URL, "gpt-default").Stream(context.Background(), req)
+	require.NoError(t, err)
+	for range ch {
+	}
+
+	assert.Equal(t, "gpt-custom", body["model"])
+	assert.Equal(t, true, body["stream"])
+	assert.Equal(t, 0.2, body["temperature"])
+	assert.Equal(t, 0.9, body["top_p"])
+	assert.Equal(t, float64(64), body["max_tokens"])
+	assert.Equal(t, float64(7), body["seed"])
+	assert.Equal(t, float64(2), body["n"])
+	assert.Equal(t, "user-1", body["user"])
+	assert.Equal(t, []any{"END"}, body["stop"])
+	assert.Equal(t, []any{
+		map[string]any{"role": "system", "content": "be terse"},
+		map[string]any{"role": "user", "content": "hi", "name": "alice"},
+		map[string]any{"role": "assistant", "content": "hello"},
+		map[string]any{"role": "user", "content": "again"},
+	}, body["messages"])
+}
+
+func TestOpenAIStreamer_DefaultModel(t *testing.T) {
+	var body map[string]any
+	srv := newUpstream(t, func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		writeSSE(w)
+	})
+
+	ch, err := llm.NewOpenAIStreamer("test-key", srv.URL, "gpt-default").Stream(context.Background(), userRequest("hi"))
+	require.NoError(t, err)
+	for range ch {
+	}
+	assert.Equal(t, "gpt-default", body["model"])
+}
+
+func TestOpenAIStreamer_UnknownRole(t *testing.T) {
+	req := &llm.ChatCompletionRequest{Messages: []llm.Message{{Role: "narrator", Content: "x"}}}
+	_, err := llm.NewOpenAIStreamer("test-key", "http://127.0.0.1:0", "gpt-test").Stream(context.Background(), req)
+	assert.EqualError(t, err, `unsupported message role "narrator"`)
+}
+
+func TestOpenAIStreamer_UpstreamError(t *testing.T) {
+	srv := newUpstream(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, `{"error":{"message":"bad model","type":"invalid_request_error"}}`)
+	})
+
+	streamer := llm.NewOpenAIStreamer("test-key", srv.URL, "gpt-test")
+	_, err := streamer.Stream(context.Background(), userRequest("hi"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "bad model")
+}
+
+func TestOpenAIStreamer_MidStreamError(t *testing.T) {
+	srv := newUpstream(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, `data: {"id":"chatcmpl-1","object":"chat.completion.chunk","created":1,"model":"gpt-test","choices":[{"index":0,"delta":{"content":"Hel"}}]}`+"\n\n")
+		fmt.Fprint(w, `data: {"error":{"message":"overloaded"}}`+"\n\n")
+	})
+
+	streamer := llm.NewOpenAIStreamer("test-key", srv.URL, "gpt-test")
+	ch, err := streamer.Stream(context.Background(), userRequest("hi"))
+	require.NoError(t, err)
+
+	var last llm.ChatCompletionChunk
+	count := 0
+	for c := range ch {
+		last = c
+		count++
+	}
+	assert.Equal(t, 2, count)
+	require.Error(t, last.Err)
+	assert.Contains(t, last.Err.Error(), "overloaded")
+}
+
+func TestMockStreamer_Stream(t *testing.T) {
+	ch, err := llm.NewMockStreamer().Stream(context.Background(), userRequest("one two"))
+	require.NoError(t, err)
+
+	var content string
+	var finish *string
+	for c := range ch {
+		content += c.Choices[0].Delta.Content
+		finish = c.Choices[0].FinishReason
+	}
+	assert.Equal(t, "one two ", content)
+	require.NotNil(t, finish)
+	assert.Equal(t, "stop", *finish)
+}
+
+func TestMockStreamer_Choices(t *testing.T) {
+	req := userRequest("one two")
+	n := int64(2)
+	req.N = &n
+	ch, err := llm.NewMockStreamer().Stream(context.Background(), req)
+	require.NoError(t, err)
+
+	completion, err := llm.Collect(ch)
+	require.NoError(t, err)
+	require.Len(t, completion.Choices, 2)
+	for i, c := range completion.Choices {
+		assert.Equal(t, i, c.Index)
+		assert.Equal(t, "one two ", c.Message.Content)
+		assert.Equal(t, "stop", c.FinishReason)
+	}
+}
+
+func TestOpenAIStreamer_PropagatesTraceContext(t *testing.T) {
+	rec := tracetest.NewSpanRecorder()
+	prevProvider, prevPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(prevProvider)
+		otel.SetTextMapPropagator(prevPropagator)
+	})
+
+	traceparent := make(chan string, 1)
+	srv := newUpstream(t, func(w http.ResponseWriter, r *http.Request) {
+		traceparent <- r.Header.Get("traceparent")
+		writeSSE(w, `{"id":"chatcmpl-1","object":"chat.completion.chunk","created":1,"model":"gpt-test","choices":[{"index":0,"delta":{"content":"Hi"}}]}`)
+	})
+
+	// the context of an incoming request carrying a traceparent
+	ctx := propagation.TraceContext{}.Extract(context.Background(), propagation.MapCarrier{
+		"traceparent": "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+	})
+	ch, err := llm.NewOpenAIStreamer("test-key", srv.URL, "gpt-test").Stream(ctx, userRequest("hi"))
+	require.NoError(t, err)
+	for range ch {
+	}
+
+	spans := rec.Ended()
+	require.Len(t, spans, 1)
+	upstream := spans[0]
+	assert.Equal(t, "llm.upstream", upstream.Name())
+	assert.Equal(t, "00f067aa0ba902b7", upstream.Parent().SpanID().String())
+	require.NotEmpty(t, upstream.Events())
+	assert.Equal(t, "first_token", upstream.Events()[0].Name)
+	assert.Equal(t, "00-4bf92f3577b34da6a3ce929d0e0e4736-"+upstream.SpanContext().SpanID().String()+"-01", <-traceparent,
+		"the upstream sees the trace of the incoming request")
+}
+
+func TestOpenAIStreamer_ForwardsTools(t *testing.T) {
+	var body map[string]any
+	srv := newUpstream(t, func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		writeSSE(w)
+	})
+
+	parallel := false
+	req := &llm.ChatCompletionRequest{
+		Messages: []llm.Message{
+			{Role: "user", Content: "weather in Paris?"},
+			{Role: "assistant", ToolCalls: []llm.ToolCall{{ID: "call_1", Type: "function",
+				Function: llm.FunctionCall{Name: "get_weather", Arguments: `{"city":"Paris"}`}}}},
+			{Role: "tool", ToolCallID: "call_1", Content: "sunny"},
+		},
+		Tools: []llm.Tool{{Type: "function", Function: llm.FunctionDefinition{
+			Name: "get_weather", Description: "Current weather",
+			Parameters: json.RawMessage(`{"type":"object","properties":{"city":{"type":"string"}}}`),
+		}}},
+		ToolChoice:        &llm.ToolChoice{Function: "get_weather"},
+		ParallelToolCalls: &parallel,
+	}
+	ch, err := llm.NewOpenAIStreamer("k", srv.URL, "gpt-test").Stream(context.Background(), req)
+	require.NoError(t, err)
+	for range ch {
+	}
+
+	assert.Equal(t, []any{map[string]any{"type": "function", "function": map[string]any{
+		"name": "get_weather", "description": "Current weather",
+		"parameters": map[string]any{"type": "object", "properties": map[string]any{"city": map[string]any{"type": "string"}}},
+	}}}, body["tools"])
+	assert.Equal(t, map[string]any{"type": "function", "function": map[string]any{"name": "get_weather"}}, body["tool_choice"])
+	assert.Equal(t, false, body["parallel_tool_calls"])
+	messages := body["messages"].([]any)
+	assert.Equal(t, map[string]any{"role": "assistant", "tool_calls": []any{map[string]any{
+		"id": "call_1", "type": "function", "function": map[string]any{"name": "get_weather", "arguments": `{"city":"Paris"}`},
+	}}}, messages[1])
+	assert.Equal(t, map[string]any{"role": "tool", "tool_call_id": "call_1", "content": "sunny"}, messages[2])
+}
+
+func TestOpenAIStreamer_StreamsToolCalls(t *testing.T) {
+	srv := newUpstream(t, func(w http.ResponseWriter, r *http.Request) {
+		writeSSE(w,
+			`{"id":"c","object":"chat.completion.chunk","choices":[{"index":0,"delta":{"role":"assistant","tool_calls":[{"index":0,"id":"call_1","type":"function","function":{"name":"get_weather","arguments":""}}]}}]}`,
+			`{"id":"c","object":"chat.completion.chunk","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":"{\"city\":"}}]}}]}`,
+			`{"id":"c","object":"chat.completion.chunk","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":"\"Paris\"}"}}]},"finish_reason":"tool_calls"}]}`,
+		)
+	})
+
+	ch, err := llm.NewOpenAIStreamer("k", srv.URL, "gpt-test").Stream(context.Background(), userRequest("hi"))
+	require.NoError(t, err)
+	var chunks []llm.ChatCompletionChunk
+	for c := range ch {
+		require.NoError(t, c.Err)
+		chunks = append(chunks, c)
+	}
+	require.Len(t, chunks, 3)
+	first := chunks[0].Choices[0].Delta
+	assert.Equal(t, "assistant", first.Role)
+	require.Len(t, first.ToolCalls, 1)
+	assert.Equal(t, 0, *first.ToolCalls[0].Index)
+	assert.Equal(t, "call_1", first.ToolCalls[0].ID)
+	assert.Equal(t, "get_weather", first.ToolCalls[0].Function.Name)
+	assert.Equal(t, `{"city":`, chunks[1].Choices[0].Delta.ToolCalls[0].Function.Arguments)
+
+	completion, err := llm.Collect(chunkStream(chunks...))
+	require.NoError(t, err)
+	assert.Equal(t, "tool_calls", completion.Choices[0].FinishReason)
+	assert.Equal(t, []llm.ToolCall{{ID: "call_1", Type: "function",
+		Function: llm.FunctionCall{Name: "get_weather", Arguments: `{"city":"Paris"}`}}}, completion.Choices[0].Message.ToolCalls)
+}
+
+func TestOpenAIStreamer_StreamsRefusal(t *testing.T) {
+	srv := newUpstream(t, func(w http.ResponseWriter, r *http.Request) {
+		writeSSE(w, `{"id":"c","object":"chat.completion.chunk","choices":[{"index":0,"delta":{"role":"assistant","refusal":"I can't help with that."},"finish_reason":"stop"}]}`)
+	})
+	ch, err := llm.NewOpenAIStreamer("k", srv.URL, "gpt-test").Stream(context.Background(), userRequest("hi"))
+	require.NoError(t, err)
+	completion, err := llm.Collect(ch)
+	require.NoError(t, err)
+	assert.Equal(t, "I can't help with that.", completion.Choices[0].Message.Refusal)
+	assert.Empty(t, completion.Choices[0].Message.Content)
+}
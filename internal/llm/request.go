@@ -0,0 +1,166 @@
+package llm
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/raja.aiml/llm-fast-wrapper/internal/tokenizer"
+)
+
+// ChatCompletionRequest is the structured chat request forwarded unchanged
+// from the HTTP handlers to a Streamer.
+type ChatCompletionRequest struct {
+	Model         string         `json:"model"`
+	Messages      []Message      `json:"messages"`
+	Stream        bool           `json:"stream,omitempty"`
+	StreamOptions *StreamOptions `json:"stream_options,omitempty"`
+	Temperature   *float64       `json:"temperature,omitempty"`
+	TopP          *float64       `json:"top_p,omitempty"`
+	MaxTokens     *int64         `json:"max_tokens,omitempty"`
+	Stop          StopSequences  `json:"stop,omitempty"`
+	Seed          *int64         `json:"seed,omitempty"`
+	User          string         `json:"user,omitempty"`
+	// N asks for several choices, each generated independently.
+	N *int64 `json:"n,omitempty"`
+
+	Tools             []Tool      `json:"tools,omitempty"`
+	ToolChoice        *ToolChoice `json:"tool_choice,omitempty"`
+	ParallelToolCalls *bool       `json:"parallel_tool_calls,omitempty"`
+}
+
+// StreamOptions holds the `stream_options` parameter of streaming requests.
+type StreamOptions struct {
+	// IncludeUsage asks for a usage-only chunk just before the end of the stream.
+	IncludeUsage bool `json:"include_usage"`
+}
+
+// Message is a chat message exchanged with the model. Assistant messages
+// may carry tool calls or a refusal instead of content; "tool" messages
+// answer the call named by ToolCallID.
+type Message struct {
+	Role       string     `json:"role"`
+	Content    string     `json:"content"`
+	Name       string     `json:"name,omitempty"`
+	Refusal    string     `json:"refusal,omitempty"`
+	ToolCalls  []ToolCall `json:"tool_calls,omitempty"`
+	ToolCallID string     `json:"tool_call_id,omitempty"`
+
+	// contentErr records content that could not be used, reported by Validate
+	// under the message's path.
+	contentErr *ParamError
+}
+
+// contentPart is an element of the array form of `content`.
+type contentPart struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// UnmarshalJSON accepts content either as a string or, as OpenAI does, as an
+// array of content parts whose text parts are concatenated. Other part
+// types are not supported.
+func (m *Message) UnmarshalJSON(data []byte) error {
+	type message Message
+	var raw struct {
+		message
+		Content json.RawMessage `json:"content"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	*m = Message(raw.message)
+	m.Content, m.contentErr = decodeContent(raw.Content)
+	return nil
+}
+
+func decodeContent(data json.RawMessage) (string, *ParamError) {
+	if len(data) == 0 || string(data) == "null" {
+		return "", nil
+	}
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		return text, nil
+	}
+	var parts []contentPart
+	if err := json.Unmarshal(data, &parts); err != nil {
+		return "", paramErrorf("content", "content must be a string or an array of content parts")
+	}
+	var b strings.Builder
+	for i, p := range parts {
+		if p.Type != "text" {
+			return "", paramErrorf(fmt.Sprintf("content[%d].type", i),
+				"unsupported content part type %q: only text parts are supported", p.Type)
+		}
+		b.WriteString(p.Text)
+	}
+	return b.String(), nil
+}
+
+// PromptText joins the content of every message, one per line. It is used
+// wherever the request has to be treated as plain text, e.g. token counts.
+func (r *ChatCompletionRequest) PromptText() string {
+	parts := make([]string, 0, len(r.Messages))
+	for _, m := range r.Messages {
+		parts = append(parts, m.Content)
+	}
+	return strings.Join(parts, "\n")
+}
+
+// IncludeUsage reports whether a streamed response should end with a
+// usage-only chunk.
+func (r *ChatCompletionRequest) IncludeUsage() bool {
+	return r.StreamOptions != nil && r.StreamOptions.IncludeUsage
+}
+
+// Choices returns the number of choices requested: n, or 1 when unset.
+func (r *ChatCompletionRequest) Choices() int {
+	if r.N == nil {
+		return 1
+	}
+	return int(*r.N)
+}
+
+// LastUserMessage returns the content of the final message when it comes
+// from the user, the part of a conversation that classifiers look at.
+func (r *ChatCompletionRequest) LastUserMessage() (string, bool) {
+	n := len(r.Messages)
+	if n == 0 || r.Messages[n-1].Role != "user" || strings.TrimSpace(r.Messages[n-1].Content) == "" {
+		return "", false
+	}
+	return r.Messages[n-1].Content, true
+}
+
+// EstimatedTokens estimates the tokens the request may consume: the prompt
+// plus max_tokens for each choice when set, as OpenAI counts against token
+// rate limits.
+func (r *ChatCompletionRequest) EstimatedTokens() int {
+	n := tokenizer.CountTokens(r.Model, r.PromptText())
+	if r.MaxTokens != nil {
+		n += int(*r.MaxTokens) * r.Choices()
+	}
+	return n
+}
+
+// StopSequences holds the `stop` parameter, which OpenAI accepts either as a
+// single string or as an array of strings.
+type StopSequences []string
+
+// UnmarshalJSON accepts a string, an array of strings or null.
+func (s *StopSequences) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		*s = nil
+		return nil
+	}
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*s = StopSequences{single}
+		return nil
+	}
+	var many []string
+	if err := json.Unmarshal(data, &many); err != nil {
+		return fmt.Errorf("stop must be a string or an array of strings")
+	}
+	*s = many
+	return nil
+}
@@ -0,0 +1,64 @@
+package llm_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/raja.aiml/llm-fast-wrapper/internal/llm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStopSequences_UnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  llm.StopSequences
+	}{
+		{name: "string", input: `{"stop":"END"}`, want: llm.StopSequences{"END"}},
+		{name: "array", input: `{"stop":["a","b"]}`, want: llm.StopSequences{"a", "b"}},
+		{name: "null", input: `{"stop":null}`, want: nil},
+		{name: "absent", input: `{}`, want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var req llm.ChatCompletionRequest
+			require.NoError(t, json.Unmarshal([]byte(tt.input), &req))
+			assert.Equal(t, tt.want, req.Stop)
+		})
+	}
+
+	var req llm.ChatCompletionRequest
+	assert.Error(t, json.Unmarshal([]byte(`{"stop":42}`), &req))
+}
+
+func TestChatCompletionRequest_PromptText(t *testing.T) {
+	req := llm.ChatCompletionRequest{Messages: []llm.Message{
+		{Role: "system", Content: "be terse"},
+		{Role: "user", Content: "hi"},
+	}}
+	assert.Equal(t, "be terse\nhi", req.PromptText())
+}
+
+func TestMessage_UnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{name: "string", input: `{"role":"user","content":"hi"}`, want: "hi"},
+		{name: "text parts", input: `{"role":"user","content":[{"type":"text","text":"Hello"},{"type":"text","text":" world"}]}`, want: "Hello world"},
+		{name: "null", input: `{"role":"assistant","content":null}`, want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var m llm.Message
+			require.NoError(t, json.Unmarshal([]byte(tt.input), &m))
+			assert.Equal(t, tt.want, m.Content)
+		})
+	}
+
+	var m llm.Message
+	require.NoError(t, json.Unmarshal([]byte(`{"role":"user","name":"alice","tool_call_id":"c1","content":"hi"}`), &m))
+	assert.Equal(t, llm.Message{Role: "user", Name: "alice", ToolCallID: "c1", Content: "hi"}, m)
+}
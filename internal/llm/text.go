@@ -0,0 +1,231 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// DefaultTextMaxTokens is the max_tokens of legacy completion requests that
+// do not set it, as in the OpenAI API.
+const DefaultTextMaxTokens = 16
+
+// CompletionRequest is the body of a legacy POST /v1/completions request.
+type CompletionRequest struct {
+	Model         string         `json:"model"`
+	Prompt        Prompt         `json:"prompt"`
+	Stream        bool           `json:"stream,omitempty"`
+	StreamOptions *StreamOptions `json:"stream_options,omitempty"`
+	Echo          bool           `json:"echo,omitempty"`
+	Temperature   *float64       `json:"temperature,omitempty"`
+	TopP          *float64       `json:"top_p,omitempty"`
+	MaxTokens     *int64         `json:"max_tokens,omitempty"`
+	Stop          StopSequences  `json:"stop,omitempty"`
+	Seed          *int64         `json:"seed,omitempty"`
+	User          string         `json:"user,omitempty"`
+	N             *int64         `json:"n,omitempty"`
+}
+
+// Prompt holds the `prompt` parameter, which OpenAI accepts either as a
+// string or as an array of strings. Only single prompts are supported.
+type Prompt string
+
+// UnmarshalJSON accepts a string or an array holding one string.
+func (p *Prompt) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*p = Prompt(single)
+		return nil
+	}
+	var many []string
+	if err := json.Unmarshal(data, &many); err != nil {
+		return fmt.Errorf("prompt must be a string or an array of strings")
+	}
+	if len(many) != 1 {
+		return fmt.Errorf("prompt must hold exactly one string, got %d", len(many))
+	}
+	*p = Prompt(many[0])
+	return nil
+}
+
+// ChatRequest converts r into the chat request sent upstream: the prompt
+// becomes a single user message and max_tokens defaults to
+// DefaultTextMaxTokens.
+func (r *CompletionRequest) ChatRequest() *ChatCompletionRequest {
+	maxTokens := int64(DefaultTextMaxTokens)
+	if r.MaxTokens != nil {
+		maxTokens = *r.MaxTokens
+	}
+	return &ChatCompletionRequest{
+		Model:       r.Model,
+		Messages:    []Message{{Role: "user", Content: string(r.Prompt)}},
+		Stream:      r.Stream,
+		Temperature: r.Temperature,
+		TopP:        r.TopP,
+		MaxTokens:   &maxTokens,
+		Stop:        r.Stop,
+		Seed:        r.Seed,
+		User:        r.User,
+		N:           r.N,
+
+		StreamOptions: r.StreamOptions,
+	}
+}
+
+// TextCompletion is the legacy completion object, both as the non-streaming
+// response and as each streamed chunk.
+type TextCompletion struct {
+	ID      string       `json:"id"`
+	Object  string       `json:"object"`
+	Created int64        `json:"created"`
+	Model   string       `json:"model"`
+	Choices []TextChoice `json:"choices"`
+	Usage   *Usage       `json:"usage,omitempty"`
+}
+
+// TextChoice is a single choice of a TextCompletion. FinishReason is null
+// on streamed chunks until the choice finishes.
+type TextChoice struct {
+	Text         string  `json:"text"`
+	Index        int     `json:"index"`
+	Logprobs     *string `json:"logprobs"`
+	FinishReason *string `json:"finish_reason"`
+}
+
+// TextChunk converts a chat completion chunk into a streamed text_completion
+// object.
+func TextChunk(chunk ChatCompletionChunk) TextCompletion {
+	choices := make([]TextChoice, 0, len(chunk.Choices))
+	for _, c := range chunk.Choices {
+		choices = append(choices, TextChoice{Text: c.Delta.Content, Index: c.Index, FinishReason: c.FinishReason})
+	}
+	return TextCompletion{
+		ID:      chunk.ID,
+		Object:  "text_completion",
+		Created: chunk.Created,
+		Model:   chunk.Model,
+		Choices: choices,
+		Usage:   chunk.Usage,
+	}
+}
+
+// TextResponse converts a collected chat completion into the non-streaming
+// text_completion response.
+func TextResponse(completion *ChatCompletion) *TextCompletion {
+	choices := make([]TextChoice, 0, len(completion.Choices))
+	for _, c := range completion.Choices {
+		reason := c.FinishReason
+		choices = append(choices, TextChoice{Text: c.Message.Content, Index: c.Index, FinishReason: &reason})
+	}
+	usage := completion.Usage
+	return &TextCompletion{
+		ID:      completion.ID,
+		Object:  "text_completion",
+		Created: completion.Created,
+		Model:   completion.Model,
+		Choices: choices,
+		Usage:   &usage,
+	}
+}
+
+// StopAt forwards ch, cutting each choice short before the first of the stop
+// sequences, for backends that do not honour `stop` themselves. Content that
+// may be the start of a stop sequence is held back until it is known not to
+// be. A choice cut short finishes with "stop" and its later chunks are
+// dropped, while ch is still drained. Forwarding ends early when ctx does.
+func StopAt(ctx context.Context, ch <-chan ChatCompletionChunk, stop []string) <-chan ChatCompletionChunk {
+	if !slices.ContainsFunc(stop, func(s string) bool { return s != "" }) {
+		return ch
+	}
+	holdBack := 0
+	for _, s := range stop {
+		holdBack = max(holdBack, len(s)-1)
+	}
+
+	out := make(chan ChatCompletionChunk)
+	go func() {
+		defer close(out)
+		send := func(chunk ChatCompletionChunk) bool {
+			select {
+			case out <- chunk:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+		pending := make(map[int]string)
+		stopped := make(map[int]bool)
+		reason := "stop"
+		var last ChatCompletionChunk
+		for chunk := range ch {
+			if chunk.Err != nil {
+				send(chunk)
+				return
+			}
+			last = chunk
+			choices := chunk.Choices[:0:0]
+			for _, choice := range chunk.Choices {
+				if stopped[choice.Index] {
+					continue
+				}
+				text := pending[choice.Index] + choice.Delta.Content
+				delete(pending, choice.Index)
+				switch i := firstStop(text, stop); {
+				case i >= 0:
+					stopped[choice.Index] = true
+					choice.Delta.Content = text[:i]
+					choice.FinishReason = &reason
+				case choice.FinishReason != nil:
+					choice.Delta.Content = text
+				default:
+					// hold back a tail that could begin a stop sequence
+					cut := max(len(text)-holdBack, 0)
+					for cut > 0 && cut < len(text) && !utf8.RuneStart(text[cut]) {
+						cut--
+					}
+					choice.Delta.Content = text[:cut]
+					if cut < len(text) {
+						pending[choice.Index] = text[cut:]
+					}
+				}
+				choices = append(choices, choice)
+			}
+			if len(choices) == 0 && len(chunk.Choices) > 0 {
+				continue
+			}
+			chunk.Choices = choices
+			if !send(chunk) {
+				return
+			}
+		}
+		// the upstream ended without finishing some choices: release what
+		// was held back for them
+		if len(pending) > 0 {
+			last.Choices, last.Usage = last.Choices[:0:0], nil
+			for index, text := range pending {
+				last.Choices = append(last.Choices, ChatCompletionChoice{Index: index, Delta: Delta{Content: text}})
+			}
+			sort.Slice(last.Choices, func(i, j int) bool { return last.Choices[i].Index < last.Choices[j].Index })
+			send(last)
+		}
+	}()
+	return out
+}
+
+// firstStop returns the index of the earliest stop sequence in text, or -1.
+func firstStop(text string, stop []string) int {
+	first := -1
+	for _, s := range stop {
+		if s == "" {
+			continue
+		}
+		if i := strings.Index(text, s); i >= 0 && (first < 0 || i < first) {
+			first = i
+		}
+	}
+	return first
+}
@@ -0,0 +1,133 @@
+package llm_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/raja.aiml/llm-fast-wrapper/internal/llm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrompt_UnmarshalJSON(t *testing.T) {
+	var req llm.CompletionRequest
+	require.NoError(t, json.Unmarshal([]byte(`{"prompt":"Say hi"}`), &req))
+	assert.Equal(t, llm.Prompt("Say hi"), req.Prompt)
+
+	require.NoError(t, json.Unmarshal([]byte(`{"prompt":["Say hi"]}`), &req))
+	assert.Equal(t, llm.Prompt("Say hi"), req.Prompt)
+
+	assert.ErrorContains(t, json.Unmarshal([]byte(`{"prompt":["a","b"]}`), &req), "exactly one")
+	assert.ErrorContains(t, json.Unmarshal([]byte(`{"prompt":[1,2]}`), &req), "string or an array")
+}
+
+func TestCompletionRequest_ChatRequest(t *testing.T) {
+	req := llm.CompletionRequest{Model: "m", Prompt: "Once upon", Stop: llm.StopSequences{"\n"}, Stream: true}
+	chat := req.ChatRequest()
+	assert.Equal(t, "m", chat.Model)
+	assert.Equal(t, []llm.Message{{Role: "user", Content: "Once upon"}}, chat.Messages)
+	assert.Equal(t, llm.StopSequences{"\n"}, chat.Stop)
+	assert.True(t, chat.Stream)
+	require.NotNil(t, chat.MaxTokens)
+	assert.Equal(t, int64(llm.DefaultTextMaxTokens), *chat.MaxTokens)
+
+	limit := int64(100)
+	req.MaxTokens = &limit
+	assert.Equal(t, int64(100), *req.ChatRequest().MaxTokens)
+}
+
+func TestTextChunk(t *testing.T) {
+	text := llm.TextChunk(contentChunk(0, "Hello", "length"))
+	assert.Equal(t, "text_completion", text.Object)
+	assert.Equal(t, "chatcmpl-1", text.ID)
+	require.Len(t, text.Choices, 1)
+	assert.Equal(t, "Hello", text.Choices[0].Text)
+	assert.Equal(t, "length", *text.Choices[0].FinishReason)
+	assert.Nil(t, text.Usage)
+
+	data, err := json.Marshal(llm.TextChunk(contentChunk(0, "Hi", "")))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"logprobs":null,"finish_reason":null`)
+}
+
+func TestTextResponse(t *testing.T) {
+	completion, err := llm.Collect(chunkStream(contentChunk(0, "Hello", ""), contentChunk(0, " world", "")))
+	require.NoError(t, err)
+	completion.Usage = llm.Usage{PromptTokens: 1, CompletionTokens: 2, TotalTokens: 3}
+
+	text := llm.TextResponse(completion)
+	assert.Equal(t, "text_completion", text.Object)
+	require.Len(t, text.Choices, 1)
+	assert.Equal(t, "Hello world", text.Choices[0].Text)
+	assert.Equal(t, "stop", *text.Choices[0].FinishReason)
+	assert.Equal(t, &completion.Usage, text.Usage)
+}
+
+// drain joins the content of a stream and returns its last finish reason.
+func drain(ch <-chan llm.ChatCompletionChunk) (content, finish string, err error) {
+	for chunk := range ch {
+		if chunk.Err != nil {
+			return content, finish, chunk.Err
+		}
+		for _, choice := range chunk.Choices {
+			content += choice.Delta.Content
+			if choice.FinishReason != nil {
+				finish = *choice.FinishReason
+			}
+		}
+	}
+	return content, finish, nil
+}
+
+func TestStopAt(t *testing.T) {
+	ctx := context.Background()
+
+	// a stop sequence split across chunks
+	content, finish, err := drain(llm.StopAt(ctx, chunkStream(
+		contentChunk(0, "Hello EN", ""),
+		contentChunk(0, "D world", ""),
+		contentChunk(0, "", "length"),
+	), []string{"END"}))
+	require.NoError(t, err)
+	assert.Equal(t, "Hello ", content)
+	assert.Equal(t, "stop", finish)
+
+	// earliest of several stop sequences
+	content, _, err = drain(llm.StopAt(ctx, chunkStream(contentChunk(0, "a.b;c", "")), []string{";", "."}))
+	require.NoError(t, err)
+	assert.Equal(t, "a", content)
+
+	// held back text is released when no stop sequence follows
+	content, finish, err = drain(llm.StopAt(ctx, chunkStream(
+		contentChunk(0, "Hello E", ""),
+		contentChunk(0, "nd", ""),
+	), []string{"END"}))
+	require.NoError(t, err)
+	assert.Equal(t, "Hello End", content)
+	assert.Empty(t, finish)
+
+	// multi-byte characters are never split
+	out := llm.StopAt(ctx, chunkStream(contentChunk(0, "hé", ""), contentChunk(0, "", "stop")), []string{"xy"})
+	content = ""
+	for chunk := range out {
+		for _, choice := range chunk.Choices {
+			assert.True(t, utf8.ValidString(choice.Delta.Content), "%q", choice.Delta.Content)
+			content += choice.Delta.Content
+		}
+	}
+	assert.Equal(t, "hé", content)
+
+	// errors pass through
+	_, _, err = drain(llm.StopAt(ctx, chunkStream(
+		contentChunk(0, "partial", ""),
+		llm.ChatCompletionChunk{Err: errors.New("upstream reset")},
+	), []string{"END"}))
+	assert.EqualError(t, err, "upstream reset")
+
+	// without stop sequences the stream is returned as is
+	in := chunkStream(contentChunk(0, "x", ""))
+	assert.Equal(t, in, llm.StopAt(ctx, in, []string{""}))
+}
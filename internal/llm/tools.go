@@ -0,0 +1,93 @@
+package llm
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Tool is a tool the model may call. Only function tools exist.
+type Tool struct {
+	Type     string             `json:"type"`
+	Function FunctionDefinition `json:"function"`
+}
+
+// FunctionDefinition describes a callable function; Parameters is its JSON
+// Schema, passed through untouched.
+type FunctionDefinition struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	Parameters  json.RawMessage `json:"parameters,omitempty"`
+	Strict      *bool           `json:"strict,omitempty"`
+}
+
+// ToolChoice holds the `tool_choice` parameter, which OpenAI accepts either
+// as one of "none", "auto" and "required" or as an object naming the
+// function the model must call.
+type ToolChoice struct {
+	Mode     string
+	Function string
+}
+
+// MarshalJSON writes the string form unless a function is named.
+func (c ToolChoice) MarshalJSON() ([]byte, error) {
+	if c.Function != "" {
+		return json.Marshal(namedToolChoice{Type: "function", Function: struct {
+			Name string `json:"name"`
+		}{c.Function}})
+	}
+	return json.Marshal(c.Mode)
+}
+
+// UnmarshalJSON accepts a string or a {"type":"function"} object.
+func (c *ToolChoice) UnmarshalJSON(data []byte) error {
+	var mode string
+	if err := json.Unmarshal(data, &mode); err == nil {
+		*c = ToolChoice{Mode: mode}
+		return nil
+	}
+	var named namedToolChoice
+	if err := json.Unmarshal(data, &named); err != nil || named.Type != "function" || named.Function.Name == "" {
+		return fmt.Errorf(`tool_choice must be "none", "auto", "required" or a function to call`)
+	}
+	*c = ToolChoice{Function: named.Function.Name}
+	return nil
+}
+
+type namedToolChoice struct {
+	Type     string `json:"type"`
+	Function struct {
+		Name string `json:"name"`
+	} `json:"function"`
+}
+
+// ToolCall is a call the model made to a tool. In streamed deltas each call
+// arrives in fragments sharing an Index: the first carries the ID, type and
+// function name, the rest further pieces of the arguments.
+type ToolCall struct {
+	// Index is set on streamed fragments only.
+	Index    *int         `json:"index,omitempty"`
+	ID       string       `json:"id,omitempty"`
+	Type     string       `json:"type,omitempty"`
+	Function FunctionCall `json:"function"`
+}
+
+// FunctionCall names the function called and its JSON-encoded arguments.
+type FunctionCall struct {
+	Name      string `json:"name,omitempty"`
+	Arguments string `json:"arguments"`
+}
+
+// Text joins everything the model generated in d: content, refusal and tool
+// call arguments. It feeds token counts and logs.
+func (d Delta) Text() string {
+	text := d.Content + d.Refusal
+	for _, call := range d.ToolCalls {
+		text += call.Function.Name + call.Function.Arguments
+	}
+	return text
+}
+
+// Text is Delta.Text for a whole message.
+func (m Message) Text() string {
+	return Delta{Content: m.Content, Refusal: m.Refusal, ToolCalls: m.ToolCalls}.Text()
+}
@@ -0,0 +1,48 @@
+package llm_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/raja.aiml/llm-fast-wrapper/internal/llm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestToolChoice_JSON(t *testing.T) {
+	for _, tc := range []struct {
+		json   string
+		choice llm.ToolChoice
+	}{
+		{`"auto"`, llm.ToolChoice{Mode: "auto"}},
+		{`"none"`, llm.ToolChoice{Mode: "none"}},
+		{`{"type":"function","function":{"name":"get_weather"}}`, llm.ToolChoice{Function: "get_weather"}},
+	} {
+		var choice llm.ToolChoice
+		require.NoError(t, json.Unmarshal([]byte(tc.json), &choice))
+		assert.Equal(t, tc.choice, choice)
+		data, err := json.Marshal(choice)
+		require.NoError(t, err)
+		assert.JSONEq(t, tc.json, string(data))
+	}
+
+	var choice llm.ToolChoice
+	assert.ErrorContains(t, json.Unmarshal([]byte(`{"type":"function"}`), &choice), "tool_choice must be")
+	assert.ErrorContains(t, json.Unmarshal([]byte(`42`), &choice), "tool_choice must be")
+}
+
+func TestChatCompletionRequest_ToolsRoundTrip(t *testing.T) {
+	in := `{"model":"m","messages":[{"role":"user","content":"hi"},` +
+		`{"role":"assistant","content":"","tool_calls":[{"id":"call_1","type":"function","function":{"name":"f","arguments":"{}"}}]},` +
+		`{"role":"tool","content":"42","tool_call_id":"call_1"}],` +
+		`"tools":[{"type":"function","function":{"name":"f","parameters":{"type":"object"}}}],"tool_choice":"required"}`
+	var req llm.ChatCompletionRequest
+	require.NoError(t, json.Unmarshal([]byte(in), &req))
+	assert.Equal(t, "call_1", req.Messages[2].ToolCallID)
+	assert.Equal(t, "f", req.Messages[1].ToolCalls[0].Function.Name)
+	assert.Equal(t, &llm.ToolChoice{Mode: "required"}, req.ToolChoice)
+
+	out, err := json.Marshal(&req)
+	require.NoError(t, err)
+	assert.JSONEq(t, in, string(out))
+}
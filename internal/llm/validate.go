@@ -0,0 +1,101 @@
+package llm
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+)
+
+// MaxStopSequences is the most stop sequences OpenAI accepts.
+const MaxStopSequences = 4
+
+// MaxChoices is the largest n OpenAI accepts.
+const MaxChoices = 128
+
+// Roles lists the message roles a chat request may use.
+var Roles = []string{"system", "developer", "user", "assistant", "tool"}
+
+var functionName = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,64}$`)
+
+// ParamError reports an invalid request parameter. Param is its path in the
+// request body, e.g. "messages[2].role".
+type ParamError struct {
+	Param   string
+	Message string
+}
+
+func (e *ParamError) Error() string { return e.Message }
+
+func paramErrorf(param, format string, args ...any) *ParamError {
+	return &ParamError{Param: param, Message: fmt.Sprintf(format, args...)}
+}
+
+// Validate checks r against the constraints OpenAI places on chat requests,
+// returning a *ParamError for the first violation. The model may be empty:
+// Streamers fall back to their default model. Limits set by the server,
+// such as message counts, are left to the caller.
+func (r *ChatCompletionRequest) Validate() error {
+	if len(r.Messages) == 0 {
+		return paramErrorf("messages", "messages must contain at least one message")
+	}
+	for i, m := range r.Messages {
+		if err := m.validate(fmt.Sprintf("messages[%d]", i)); err != nil {
+			return err
+		}
+	}
+	if r.Temperature != nil && (*r.Temperature < 0 || *r.Temperature > 2) {
+		return paramErrorf("temperature", "temperature must be between 0 and 2, got %g", *r.Temperature)
+	}
+	if r.TopP != nil && (*r.TopP < 0 || *r.TopP > 1) {
+		return paramErrorf("top_p", "top_p must be between 0 and 1, got %g", *r.TopP)
+	}
+	if r.MaxTokens != nil && *r.MaxTokens < 1 {
+		return paramErrorf("max_tokens", "max_tokens must be at least 1, got %d", *r.MaxTokens)
+	}
+	if len(r.Stop) > MaxStopSequences {
+		return paramErrorf("stop", "stop accepts at most %d sequences, got %d", MaxStopSequences, len(r.Stop))
+	}
+	if r.N != nil && (*r.N < 1 || *r.N > MaxChoices) {
+		return paramErrorf("n", "n must be between 1 and %d, got %d", MaxChoices, *r.N)
+	}
+	if r.StreamOptions != nil && !r.Stream {
+		return paramErrorf("stream_options", "stream_options is only allowed when stream is true")
+	}
+	for i, tool := range r.Tools {
+		if tool.Type != "function" {
+			return paramErrorf(fmt.Sprintf("tools[%d].type", i), `tool type must be "function", got %q`, tool.Type)
+		}
+		if !functionName.MatchString(tool.Function.Name) {
+			return paramErrorf(fmt.Sprintf("tools[%d].function.name", i),
+				"function names must be 1 to 64 letters, digits, underscores or dashes, got %q", tool.Function.Name)
+		}
+	}
+	if c := r.ToolChoice; c != nil {
+		switch {
+		case c.Function != "" && !slices.ContainsFunc(r.Tools, func(t Tool) bool { return t.Function.Name == c.Function }):
+			return paramErrorf("tool_choice", "tool_choice names function %q, which is not in tools", c.Function)
+		case c.Function == "" && !slices.Contains([]string{"none", "auto", "required"}, c.Mode):
+			return paramErrorf("tool_choice", `tool_choice must be "none", "auto", "required" or a function to call`)
+		case c.Mode == "required" && len(r.Tools) == 0:
+			return paramErrorf("tool_choice", `tool_choice "required" needs tools`)
+		}
+	}
+	return nil
+}
+
+// validate checks a message found at param.
+func (m Message) validate(param string) error {
+	if !slices.Contains(Roles, m.Role) {
+		return paramErrorf(param+".role", "unknown role %q: must be one of system, developer, user, assistant or tool", m.Role)
+	}
+	if e := m.contentErr; e != nil {
+		return paramErrorf(param+"."+e.Param, "%s", e.Message)
+	}
+	if m.Role == "tool" && m.ToolCallID == "" {
+		return paramErrorf(param+".tool_call_id", "tool messages must set tool_call_id")
+	}
+	if m.Role != "assistant" && (len(m.ToolCalls) > 0 || m.Refusal != "") {
+		return paramErrorf(param, "only assistant messages may carry tool_calls or a refusal")
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package llm_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/raja.aiml/llm-fast-wrapper/internal/llm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChatCompletionRequest_Validate(t *testing.T) {
+	tests := []struct {
+		name  string
+		body  string
+		param string
+	}{
+		{name: "valid", body: `{"model":"m","messages":[{"role":"system","content":"s"},{"role":"user","content":"hi"}],"temperature":2,"top_p":0}`},
+		{name: "default model", body: `{"messages":[{"role":"user","content":"hi"}]}`},
+		{name: "tool round trip", body: `{"model":"m","messages":[{"role":"user","content":"hi"},` +
+			`{"role":"assistant","tool_calls":[{"id":"c1","type":"function","function":{"name":"f","arguments":"{}"}}]},` +
+			`{"role":"tool","tool_call_id":"c1","content":"42"}],` +
+			`"tools":[{"type":"function","function":{"name":"f"}}],"tool_choice":{"type":"function","function":{"name":"f"}}}`},
+		{name: "no messages", body: `{"model":"m","messages":[]}`, param: "messages"},
+		{name: "unknown role", body: `{"model":"m","messages":[{"role":"user","content":"a"},{"role":"robot","content":"b"}]}`, param: "messages[1].role"},
+		{name: "text parts", body: `{"model":"m","messages":[{"role":"user","content":[{"type":"text","text":"hi"}]}]}`},
+		{name: "image part", body: `{"model":"m","messages":[{"role":"user","content":[{"type":"text","text":"hi"},{"type":"image_url","image_url":{"url":"x"}}]}]}`, param: "messages[0].content[1].type"},
+		{name: "content type", body: `{"model":"m","messages":[{"role":"user","content":7}]}`, param: "messages[0].content"},
+		{name: "tool without call id", body: `{"model":"m","messages":[{"role":"tool","content":"42"}]}`, param: "messages[0].tool_call_id"},
+		{name: "user tool calls", body: `{"model":"m","messages":[{"role":"user","tool_calls":[{"id":"c1","function":{"name":"f","arguments":""}}]}]}`, param: "messages[0]"},
+		{name: "temperature", body: `{"model":"m","messages":[{"role":"user","content":"hi"}],"temperature":2.5}`, param: "temperature"},
+		{name: "top_p", body: `{"model":"m","messages":[{"role":"user","content":"hi"}],"top_p":-0.1}`, param: "top_p"},
+		{name: "max_tokens", body: `{"model":"m","messages":[{"role":"user","content":"hi"}],"max_tokens":0}`, param: "max_tokens"},
+		{name: "stop", body: `{"model":"m","messages":[{"role":"user","content":"hi"}],"stop":["a","b","c","d","e"]}`, param: "stop"},
+		{name: "n", body: `{"model":"m","messages":[{"role":"user","content":"hi"}],"n":0}`, param: "n"},
+		{name: "stream_options", body: `{"model":"m","messages":[{"role":"user","content":"hi"}],"stream_options":{"include_usage":true}}`, param: "stream_options"},
+		{name: "tool type", body: `{"model":"m","messages":[{"role":"user","content":"hi"}],"tools":[{"type":"web","function":{"name":"f"}}]}`, param: "tools[0].type"},
+		{name: "tool name", body: `{"model":"m","messages":[{"role":"user","content":"hi"}],"tools":[{"type":"function","function":{"name":"get weather"}}]}`, param: "tools[0].function.name"},
+		{name: "tool_choice mode", body: `{"model":"m","messages":[{"role":"user","content":"hi"}],"tool_choice":"always"}`, param: "tool_choice"},
+		{name: "tool_choice unknown function", body: `{"model":"m","messages":[{"role":"user","content":"hi"}],"tools":[{"type":"function","function":{"name":"f"}}],"tool_choice":{"type":"function","function":{"name":"g"}}}`, param: "tool_choice"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var req llm.ChatCompletionRequest
+			require.NoError(t, json.Unmarshal([]byte(tt.body), &req))
+			err := req.Validate()
+			if tt.param == "" {
+				assert.NoError(t, err)
+				return
+			}
+			var paramErr *llm.ParamError
+			require.ErrorAs(t, err, &paramErr)
+			assert.Equal(t, tt.param, paramErr.Param)
+			assert.NotEmpty(t, paramErr.Message)
+		})
+	}
+}
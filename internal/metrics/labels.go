@@ -0,0 +1,40 @@
+package metrics
+
+import "sync"
+
+// Other replaces label values beyond a LabelSet's capacity.
+const Other = "other"
+
+// LabelSet bounds the cardinality of a label fed from client input, such as
+// the requested model: the first Max distinct values are kept and later ones
+// are reported as Other.
+type LabelSet struct {
+	max  int
+	mu   sync.RWMutex
+	seen map[string]struct{}
+}
+
+// NewLabelSet creates a LabelSet admitting max distinct values.
+func NewLabelSet(max int) *LabelSet {
+	return &LabelSet{max: max, seen: make(map[string]struct{})}
+}
+
+// Value returns v if it is, or can still become, one of the admitted values.
+func (s *LabelSet) Value(v string) string {
+	s.mu.RLock()
+	_, ok := s.seen[v]
+	s.mu.RUnlock()
+	if ok {
+		return v
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.seen[v]; ok {
+		return v
+	}
+	if len(s.seen) >= s.max {
+		return Other
+	}
+	s.seen[v] = struct{}{}
+	return v
+}
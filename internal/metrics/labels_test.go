@@ -0,0 +1,16 @@
+package metrics_test
+
+import (
+	"testing"
+
+	"github.com/raja.aiml/llm-fast-wrapper/internal/metrics"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLabelSet(t *testing.T) {
+	s := metrics.NewLabelSet(2)
+	assert.Equal(t, "a", s.Value("a"))
+	assert.Equal(t, "b", s.Value("b"))
+	assert.Equal(t, metrics.Other, s.Value("c"), "beyond capacity")
+	assert.Equal(t, "a", s.Value("a"), "admitted values stay")
+}
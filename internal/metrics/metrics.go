@@ -0,0 +1,133 @@
+// Package metrics defines the Prometheus collectors exported by the API
+// servers on /metrics. Labels are kept to small, bounded value sets: model
+// names go through LabelSet and statuses are HTTP codes.
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "llm_fast_wrapper"
+
+// latencyBuckets cover sub-millisecond cache hits up to slow first tokens.
+var latencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30}
+
+var (
+	// Requests counts API requests by endpoint, model, backend and status.
+	// Cache hits are reported with backend "cache".
+	Requests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "requests_total",
+		Help:      "API requests by endpoint, model, backend and status code.",
+	}, []string{"endpoint", "model", "backend", "status"})
+
+	// TimeToFirstToken observes the delay between receiving a chat request
+	// and writing its first chunk.
+	TimeToFirstToken = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "time_to_first_token_seconds",
+		Help:      "Time from request to first streamed chunk.",
+		Buckets:   latencyBuckets,
+	}, []string{"model", "backend"})
+
+	// TokensPerSecond observes the completion token rate of each chat
+	// response after its first token.
+	TokensPerSecond = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "tokens_per_second",
+		Help:      "Completion tokens per second after the first token.",
+		Buckets:   []float64{5, 10, 20, 40, 60, 80, 100, 150, 200, 400, 1000},
+	}, []string{"model", "backend"})
+
+	// Tokens counts the tokens of chat responses by model, backend, tenant
+	// and type ("prompt" or "completion"), for charging usage back to
+	// tenants. Tenants are created by operators, so the label stays
+	// bounded; requests without one have an empty tenant.
+	Tokens = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "tokens_total",
+		Help:      "Tokens of chat responses by model, backend, tenant and type (prompt, completion).",
+	}, []string{"model", "backend", "tenant", "type"})
+
+	// StreamDuration observes how long streamed responses stay open.
+	StreamDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "stream_duration_seconds",
+		Help:      "Duration of streamed responses.",
+		Buckets:   latencyBuckets,
+	}, []string{"model", "backend"})
+
+	// ActiveStreams is the number of SSE responses currently being written.
+	ActiveStreams = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "active_streams",
+		Help:      "Streamed responses in progress.",
+	})
+
+	// UpstreamErrors counts failed upstream calls by backend and phase:
+	// "connect" before the first chunk, "stream" after it.
+	UpstreamErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "upstream_errors_total",
+		Help:      "Upstream failures by backend and phase (connect, stream).",
+	}, []string{"backend", "phase"})
+
+	// BackendFailures counts routed attempts that failed and counted against
+	// the backend's circuit breaker, including ones later retried.
+	BackendFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "backend_failures_total",
+		Help:      "Failed routing attempts by backend, including retried ones.",
+	}, []string{"backend"})
+
+	// BreakerState reports each backend's circuit: 0 closed, 1 open,
+	// 2 half-open.
+	BreakerState = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "circuit_breaker_state",
+		Help:      "Circuit breaker state by backend (0 closed, 1 open, 2 half-open).",
+	}, []string{"backend"})
+
+	// ResponseCacheLookups counts exact-match cache lookups by result: hit
+	// or miss.
+	ResponseCacheLookups = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "response_cache_lookups_total",
+		Help:      "Exact-match response cache lookups by result.",
+	}, []string{"result"})
+
+	// SemanticCacheLookups counts semantic cache lookups by result: hit,
+	// miss or error.
+	SemanticCacheLookups = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "semantic_cache_lookups_total",
+		Help:      "Semantic cache lookups by result.",
+	}, []string{"result"})
+
+	// SemanticCacheSimilarity observes the similarity of the closest cached
+	// prompt on each lookup that found a candidate, hit or not, so
+	// thresholds can be tuned against the real distribution.
+	SemanticCacheSimilarity = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "semantic_cache_similarity",
+		Help:      "Cosine similarity of the closest semantic cache candidate.",
+		Buckets:   []float64{0.5, 0.6, 0.7, 0.8, 0.85, 0.9, 0.925, 0.95, 0.975, 0.99, 1},
+	})
+
+	// EmbeddingLookups counts embeddings served by source: memory, store or
+	// provider. The hit ratio is (memory + store) / total.
+	EmbeddingLookups = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "embedding_lookups_total",
+		Help:      "Embeddings served by source (memory, store, provider).",
+	}, []string{"source"})
+)
+
+// Handler serves the collectors in the Prometheus text format.
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
@@ -0,0 +1,79 @@
+// Package models holds the registry of models the server advertises and
+// accepts, as configured in the serve command's YAML file.
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// Model describes a single model entry in the registry.
+type Model struct {
+	ID            string   `yaml:"id" json:"id"`
+	Backend       string   `yaml:"backend" json:"owned_by"`
+	ContextWindow int      `yaml:"context_window" json:"context_window,omitempty"`
+	Capabilities  []string `yaml:"capabilities" json:"capabilities,omitempty"`
+	Created       int64    `yaml:"created" json:"created"`
+}
+
+// Object is the OpenAI-compatible representation of a model.
+type Object struct {
+	Object string `json:"object"`
+	Model
+}
+
+// List is the OpenAI-compatible response of GET /v1/models.
+type List struct {
+	Object string   `json:"object"`
+	Data   []Object `json:"data"`
+}
+
+// Registry is an immutable, ordered set of models keyed by ID.
+type Registry struct {
+	models []Model
+	byID   map[string]Model
+}
+
+// NewRegistry validates models and builds a Registry preserving their order.
+// Entries without a creation timestamp are stamped with the current time.
+func NewRegistry(models []Model) (*Registry, error) {
+	r := &Registry{byID: make(map[string]Model, len(models))}
+	now := time.Now().Unix()
+	for i, m := range models {
+		if m.ID == "" {
+			return nil, fmt.Errorf("model #%d has no id", i+1)
+		}
+		if _, dup := r.byID[m.ID]; dup {
+			return nil, fmt.Errorf("duplicate model id %q", m.ID)
+		}
+		if m.Created == 0 {
+			m.Created = now
+		}
+		r.models = append(r.models, m)
+		r.byID[m.ID] = m
+	}
+	return r, nil
+}
+
+// Get returns the model with the given ID.
+func (r *Registry) Get(id string) (Model, bool) {
+	m, ok := r.byID[id]
+	return m, ok
+}
+
+// Len returns the number of registered models.
+func (r *Registry) Len() int { return len(r.models) }
+
+// Object returns the OpenAI model object for m.
+func (m Model) Object() Object {
+	return Object{Object: "model", Model: m}
+}
+
+// List returns every model as an OpenAI list response.
+func (r *Registry) List() List {
+	list := List{Object: "list", Data: make([]Object, 0, len(r.models))}
+	for _, m := range r.models {
+		list.Data = append(list.Data, m.Object())
+	}
+	return list
+}
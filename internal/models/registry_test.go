@@ -0,0 +1,40 @@
+package models_test
+
+import (
+	"testing"
+
+	"github.com/raja.aiml/llm-fast-wrapper/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistry(t *testing.T) {
+	reg, err := models.NewRegistry([]models.Model{
+		{ID: "b", Backend: "openai"},
+		{ID: "a", Backend: "local", Created: 42},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 2, reg.Len())
+
+	m, ok := reg.Get("a")
+	require.True(t, ok)
+	assert.Equal(t, int64(42), m.Created)
+	b, _ := reg.Get("b")
+	assert.Positive(t, b.Created)
+	_, ok = reg.Get("c")
+	assert.False(t, ok)
+
+	list := reg.List()
+	assert.Equal(t, "list", list.Object)
+	require.Len(t, list.Data, 2)
+	assert.Equal(t, "b", list.Data[0].ID, "configuration order is preserved")
+	assert.Equal(t, "model", list.Data[0].Object)
+}
+
+func TestRegistryRejectsInvalidEntries(t *testing.T) {
+	_, err := models.NewRegistry([]models.Model{{ID: "a"}, {ID: "a"}})
+	assert.EqualError(t, err, `duplicate model id "a"`)
+
+	_, err = models.NewRegistry([]models.Model{{Backend: "openai"}})
+	assert.EqualError(t, err, "model #1 has no id")
+}
@@ -0,0 +1,45 @@
+// Package ratelimit implements per-client token buckets for requests per
+// minute and estimated tokens per minute.
+package ratelimit
+
+import (
+	"math"
+	"time"
+)
+
+// bucket is a token bucket holding up to capacity tokens and refilling
+// continuously so that it refills completely in one minute.
+type bucket struct {
+	capacity float64
+	tokens   float64
+	last     time.Time
+}
+
+func newBucket(perMinute int, now time.Time) *bucket {
+	return &bucket{capacity: float64(perMinute), tokens: float64(perMinute), last: now}
+}
+
+// refill adds the tokens accrued since the last call. A changed limit takes
+// effect immediately, clamping the current balance.
+func (b *bucket) refill(perMinute int, now time.Time) {
+	b.capacity = float64(perMinute)
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens += elapsed.Minutes() * b.capacity
+		b.last = now
+	}
+	b.tokens = math.Min(b.tokens, b.capacity)
+}
+
+// wait returns how long until n tokens are available; zero means now.
+func (b *bucket) wait(n float64) time.Duration {
+	if b.tokens >= n {
+		return 0
+	}
+	missing := n - b.tokens
+	return time.Duration(math.Ceil(missing / b.capacity * float64(time.Minute)))
+}
+
+// untilFull returns how long until the bucket is full again.
+func (b *bucket) untilFull() time.Duration {
+	return b.wait(b.capacity)
+}
@@ -0,0 +1,129 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+
+	"github.com/raja.aiml/llm-fast-wrapper/internal/config"
+)
+
+// idleTTL is how long an untouched client's buckets are kept. After a minute
+// they are full again, so dropping them changes nothing.
+const idleTTL = 2 * time.Minute
+
+// Quota describes one dimension (requests or tokens) of a decision, in the
+// shape of the x-ratelimit-* response headers.
+type Quota struct {
+	Limit     int
+	Remaining int
+	Reset     time.Duration
+}
+
+// Decision is the outcome of Limiter.Allow.
+type Decision struct {
+	Allowed bool
+	// RetryAfter is how long to wait before the request would be admitted.
+	RetryAfter time.Duration
+	// TooLarge is set when the request needs more tokens than the limit
+	// allows per minute and can never be admitted.
+	TooLarge bool
+	// Requests and Tokens are nil for unlimited dimensions.
+	Requests *Quota
+	Tokens   *Quota
+}
+
+type client struct {
+	requests *bucket
+	tokens   *bucket
+	seen     time.Time
+}
+
+// Limiter keeps a pair of token buckets per client key.
+type Limiter struct {
+	mu      sync.Mutex
+	clients map[string]*client
+	now     func() time.Time
+	swept   time.Time
+}
+
+// New creates an empty Limiter.
+func New() *Limiter {
+	return &Limiter{clients: make(map[string]*client), now: time.Now}
+}
+
+// Allow charges one request and tokens estimated tokens to key under limit.
+// Nothing is charged unless both buckets can pay.
+func (l *Limiter) Allow(key string, limit config.LimitConfig, tokens int) Decision {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	l.sweep(now)
+
+	c := l.clients[key]
+	if c == nil {
+		c = &client{}
+		l.clients[key] = c
+	}
+	c.seen = now
+	if limit.RequestsPerMinute > 0 {
+		if c.requests == nil {
+			c.requests = newBucket(limit.RequestsPerMinute, now)
+		}
+		c.requests.refill(limit.RequestsPerMinute, now)
+	}
+	if limit.TokensPerMinute > 0 {
+		if c.tokens == nil {
+			c.tokens = newBucket(limit.TokensPerMinute, now)
+		}
+		c.tokens.refill(limit.TokensPerMinute, now)
+	}
+
+	d := Decision{Allowed: true}
+	if limit.RequestsPerMinute > 0 {
+		if w := c.requests.wait(1); w > 0 {
+			d.Allowed = false
+			d.RetryAfter = max(d.RetryAfter, w)
+		}
+	}
+	if limit.TokensPerMinute > 0 {
+		if tokens > limit.TokensPerMinute {
+			d.Allowed, d.TooLarge = false, true
+		} else if w := c.tokens.wait(float64(tokens)); w > 0 {
+			d.Allowed = false
+			d.RetryAfter = max(d.RetryAfter, w)
+		}
+	}
+	if d.Allowed {
+		if limit.RequestsPerMinute > 0 {
+			c.requests.tokens--
+		}
+		if limit.TokensPerMinute > 0 {
+			c.tokens.tokens -= float64(tokens)
+		}
+	}
+
+	if limit.RequestsPerMinute > 0 {
+		d.Requests = quota(c.requests, limit.RequestsPerMinute)
+	}
+	if limit.TokensPerMinute > 0 {
+		d.Tokens = quota(c.tokens, limit.TokensPerMinute)
+	}
+	return d
+}
+
+func quota(b *bucket, limit int) *Quota {
+	return &Quota{Limit: limit, Remaining: int(b.tokens), Reset: b.untilFull()}
+}
+
+// sweep drops idle clients at most once per idleTTL.
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.swept) < idleTTL {
+		return
+	}
+	l.swept = now
+	for key, c := range l.clients {
+		if now.Sub(c.seen) > idleTTL {
+			delete(l.clients, key)
+		}
+	}
+}
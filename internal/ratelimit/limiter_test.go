@@ -0,0 +1,92 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/raja.aiml/llm-fast-wrapper/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type clock struct{ t time.Time }
+
+func (c *clock) now() time.Time          { return c.t }
+func (c *clock) advance(d time.Duration) { c.t = c.t.Add(d) }
+func newTestLimiter() (*Limiter, *clock) {
+	c := &clock{t: time.Unix(1_700_000_000, 0)}
+	l := New()
+	l.now = c.now
+	return l, c
+}
+
+func TestRequestBucket(t *testing.T) {
+	l, c := newTestLimiter()
+	limit := config.LimitConfig{RequestsPerMinute: 2}
+
+	for i := range 2 {
+		d := l.Allow("a", limit, 0)
+		require.True(t, d.Allowed)
+		assert.Equal(t, 1-i, d.Requests.Remaining)
+		assert.Nil(t, d.Tokens)
+	}
+	d := l.Allow("a", limit, 0)
+	assert.False(t, d.Allowed)
+	assert.Equal(t, 30*time.Second, d.RetryAfter)
+	assert.Equal(t, time.Minute, d.Requests.Reset)
+
+	assert.True(t, l.Allow("b", limit, 0).Allowed, "clients have separate buckets")
+
+	c.advance(30 * time.Second)
+	assert.True(t, l.Allow("a", limit, 0).Allowed)
+}
+
+func TestTokenBucket(t *testing.T) {
+	l, c := newTestLimiter()
+	limit := config.LimitConfig{RequestsPerMinute: 100, TokensPerMinute: 1000}
+
+	require.True(t, l.Allow("a", limit, 800).Allowed)
+	d := l.Allow("a", limit, 400)
+	assert.False(t, d.Allowed)
+	assert.Equal(t, 12*time.Second, d.RetryAfter)
+	assert.Equal(t, 99, d.Requests.Remaining, "a denied request charges nothing")
+	assert.Equal(t, 200, d.Tokens.Remaining)
+
+	c.advance(12 * time.Second)
+	assert.True(t, l.Allow("a", limit, 400).Allowed)
+
+	d = l.Allow("b", limit, 1001)
+	assert.False(t, d.Allowed)
+	assert.True(t, d.TooLarge)
+}
+
+func TestUnlimited(t *testing.T) {
+	l, _ := newTestLimiter()
+	for range 100 {
+		d := l.Allow("a", config.LimitConfig{}, 1_000_000)
+		require.True(t, d.Allowed)
+		assert.Nil(t, d.Requests)
+	}
+}
+
+func TestIdleClientsAreSwept(t *testing.T) {
+	l, c := newTestLimiter()
+	limit := config.LimitConfig{RequestsPerMinute: 1}
+	l.Allow("a", limit, 0)
+	c.advance(3 * idleTTL)
+	l.Allow("b", limit, 0)
+	assert.NotContains(t, l.clients, "a")
+	assert.Contains(t, l.clients, "b")
+}
+
+func TestRateLimitConfigFor(t *testing.T) {
+	cfg := config.RateLimitConfig{
+		Default: config.LimitConfig{RequestsPerMinute: 10},
+		Tenants: map[string]config.LimitConfig{"acme": {RequestsPerMinute: 100}},
+	}
+	assert.Equal(t, 100, cfg.For("acme").RequestsPerMinute)
+	assert.Equal(t, 10, cfg.For("globex").RequestsPerMinute)
+	assert.Equal(t, 10, cfg.For("").RequestsPerMinute)
+	assert.True(t, cfg.Enabled())
+	assert.False(t, config.RateLimitConfig{}.Enabled())
+}
@@ -0,0 +1,112 @@
+// Package responsecache answers repeated chat requests from earlier
+// responses. Entries hold the upstream chunk sequence, so a hit can be
+// replayed as a stream or assembled into a completion.
+package responsecache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/raja.aiml/llm-fast-wrapper/internal/llm"
+	"github.com/raja.aiml/llm-fast-wrapper/internal/logging"
+	"github.com/raja.aiml/llm-fast-wrapper/internal/metrics"
+	"go.uber.org/zap"
+)
+
+// ErrMiss is returned by Store.Get when no live entry exists.
+var ErrMiss = errors.New("cache miss")
+
+// Store persists cached chunk sequences.
+type Store interface {
+	Get(ctx context.Context, key string) ([]llm.ChatCompletionChunk, error)
+	Set(ctx context.Context, key string, chunks []llm.ChatCompletionChunk, ttl time.Duration) error
+}
+
+// Cache is an exact-match response cache over a Store.
+type Cache struct {
+	store  Store
+	ttl    time.Duration
+	logger *zap.SugaredLogger
+}
+
+// New creates a Cache keeping entries for ttl.
+func New(store Store, ttl time.Duration) *Cache {
+	return &Cache{store: store, ttl: ttl, logger: logging.InitLogger("logs/response-cache.log")}
+}
+
+// Key is the canonical hash of everything that shapes the response: the
+// tenant (entries are never shared across tenants), model, messages and
+// sampling parameters. The stream flag and end-user id are ignored.
+func Key(tenant string, req *llm.ChatCompletionRequest) string {
+	canonical := *req
+	canonical.Stream = false
+	canonical.User = ""
+	data, _ := json.Marshal(struct {
+		Tenant  string                     `json:"tenant"`
+		Request *llm.ChatCompletionRequest `json:"request"`
+	}{tenant, &canonical})
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// Lookup returns the cached chunks for req. Store failures are logged and
+// reported as misses.
+func (c *Cache) Lookup(ctx context.Context, tenant string, req *llm.ChatCompletionRequest) ([]llm.ChatCompletionChunk, bool) {
+	chunks, err := c.store.Get(ctx, Key(tenant, req))
+	if err != nil {
+		if !errors.Is(err, ErrMiss) {
+			c.logger.Warnf("cache lookup failed: %v", err)
+		}
+		metrics.ResponseCacheLookups.WithLabelValues("miss").Inc()
+		return nil, false
+	}
+	metrics.ResponseCacheLookups.WithLabelValues("hit").Inc()
+	return chunks, true
+}
+
+// Record forwards ch and stores the chunks once the stream completes. Failed
+// or abandoned streams are not stored.
+func (c *Cache) Record(ctx context.Context, tenant string, req *llm.ChatCompletionRequest, ch <-chan llm.ChatCompletionChunk) <-chan llm.ChatCompletionChunk {
+	key := Key(tenant, req)
+	return tee(ctx, ch, func(ctx context.Context, chunks []llm.ChatCompletionChunk) {
+		if err := c.store.Set(ctx, key, chunks, c.ttl); err != nil {
+			c.logger.Warnf("cache store failed: %v", err)
+		}
+	})
+}
+
+// tee forwards ch and, once it completes without error or cancellation,
+// hands the collected chunks to save. save runs detached from the request
+// with a bounded timeout so stores never delay the stream.
+func tee(ctx context.Context, ch <-chan llm.ChatCompletionChunk, save func(context.Context, []llm.ChatCompletionChunk)) <-chan llm.ChatCompletionChunk {
+	out := make(chan llm.ChatCompletionChunk)
+	go func() {
+		var chunks []llm.ChatCompletionChunk
+		complete := func() bool {
+			defer close(out)
+			for chunk := range ch {
+				select {
+				case out <- chunk:
+				case <-ctx.Done():
+					return false
+				}
+				if chunk.Err != nil {
+					return false
+				}
+				chunks = append(chunks, chunk)
+			}
+			return ctx.Err() == nil && len(chunks) > 0
+		}()
+		if !complete {
+			return
+		}
+		saveCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+		defer cancel()
+		save(saveCtx, chunks)
+	}()
+	return out
+}
@@ -0,0 +1,126 @@
+package responsecache_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/raja.aiml/llm-fast-wrapper/internal/llm"
+	"github.com/raja.aiml/llm-fast-wrapper/internal/responsecache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func stores(t *testing.T) map[string]responsecache.Store {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Discard})
+	require.NoError(t, err)
+	pg, err := responsecache.NewStoreFromDB(db)
+	require.NoError(t, err)
+	return map[string]responsecache.Store{"postgres": pg, "memory": responsecache.NewMemoryStore(0)}
+}
+
+func chatRequest(content string) *llm.ChatCompletionRequest {
+	return &llm.ChatCompletionRequest{Model: "m", Messages: []llm.Message{{Role: "user", Content: content}}}
+}
+
+func chunks(parts ...string) []llm.ChatCompletionChunk {
+	var out []llm.ChatCompletionChunk
+	for _, p := range parts {
+		out = append(out, llm.ChatCompletionChunk{ID: "c", Object: "chat.completion.chunk", Model: "m",
+			Choices: []llm.ChatCompletionChoice{{Delta: llm.Delta{Content: p}}}})
+	}
+	return out
+}
+
+func feed(cs ...llm.ChatCompletionChunk) <-chan llm.ChatCompletionChunk {
+	ch := make(chan llm.ChatCompletionChunk, len(cs))
+	for _, c := range cs {
+		ch <- c
+	}
+	close(ch)
+	return ch
+}
+
+func TestKey(t *testing.T) {
+	base := chatRequest("hi")
+	streaming := chatRequest("hi")
+	streaming.Stream, streaming.User = true, "someone"
+	assert.Equal(t, responsecache.Key("acme", base), responsecache.Key("acme", streaming), "stream flag and user are ignored")
+
+	temp := 0.5
+	withTemp := chatRequest("hi")
+	withTemp.Temperature = &temp
+	assert.NotEqual(t, responsecache.Key("acme", base), responsecache.Key("acme", withTemp))
+	assert.NotEqual(t, responsecache.Key("acme", base), responsecache.Key("acme", chatRequest("hello")))
+	assert.NotEqual(t, responsecache.Key("acme", base), responsecache.Key("globex", base), "tenants never share entries")
+}
+
+func TestStores(t *testing.T) {
+	for name, store := range stores(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			_, err := store.Get(ctx, "k")
+			assert.ErrorIs(t, err, responsecache.ErrMiss)
+
+			require.NoError(t, store.Set(ctx, "k", chunks("a", "b"), time.Minute))
+			got, err := store.Get(ctx, "k")
+			require.NoError(t, err)
+			assert.Equal(t, chunks("a", "b"), got)
+
+			require.NoError(t, store.Set(ctx, "k", chunks("c"), time.Minute))
+			got, err = store.Get(ctx, "k")
+			require.NoError(t, err)
+			assert.Equal(t, chunks("c"), got, "set overwrites")
+
+			require.NoError(t, store.Set(ctx, "expired", chunks("x"), -time.Second))
+			_, err = store.Get(ctx, "expired")
+			assert.ErrorIs(t, err, responsecache.ErrMiss)
+		})
+	}
+}
+
+func TestMemoryStoreBound(t *testing.T) {
+	ctx := context.Background()
+	store := responsecache.NewMemoryStore(2)
+	require.NoError(t, store.Set(ctx, "a", chunks("a"), time.Minute))
+	require.NoError(t, store.Set(ctx, "b", chunks("b"), 2*time.Minute))
+	require.NoError(t, store.Set(ctx, "c", chunks("c"), 3*time.Minute))
+	_, err := store.Get(ctx, "a")
+	assert.ErrorIs(t, err, responsecache.ErrMiss, "entry expiring first is evicted")
+	_, err = store.Get(ctx, "c")
+	assert.NoError(t, err)
+}
+
+func TestRecordStoresCompleteStreams(t *testing.T) {
+	ctx := context.Background()
+	cache := responsecache.New(responsecache.NewMemoryStore(0), time.Minute)
+	req := chatRequest("hi")
+
+	var forwarded []llm.ChatCompletionChunk
+	for c := range cache.Record(ctx, "", req, feed(chunks("a", "b")...)) {
+		forwarded = append(forwarded, c)
+	}
+	assert.Equal(t, chunks("a", "b"), forwarded)
+	assert.Eventually(t, func() bool {
+		got, ok := cache.Lookup(ctx, "", req)
+		return ok && assert.ObjectsAreEqual(chunks("a", "b"), got)
+	}, time.Second, 5*time.Millisecond)
+}
+
+func TestRecordSkipsFailedStreams(t *testing.T) {
+	ctx := context.Background()
+	cache := responsecache.New(responsecache.NewMemoryStore(0), time.Minute)
+	req := chatRequest("hi")
+
+	failed := append(chunks("a"), llm.ChatCompletionChunk{Err: errors.New("boom")})
+	for range cache.Record(ctx, "", req, feed(failed...)) {
+	}
+	time.Sleep(20 * time.Millisecond)
+	_, ok := cache.Lookup(ctx, "", req)
+	assert.False(t, ok)
+}
@@ -0,0 +1,74 @@
+package responsecache
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/raja.aiml/llm-fast-wrapper/internal/llm"
+)
+
+type memoryEntry struct {
+	chunks  []llm.ChatCompletionChunk
+	expires time.Time
+}
+
+// MemoryStore keeps entries in process memory, evicting the entry closest to
+// expiry once maxEntries is reached.
+type MemoryStore struct {
+	mu         sync.Mutex
+	entries    map[string]memoryEntry
+	maxEntries int
+	now        func() time.Time
+}
+
+// NewMemoryStore creates a MemoryStore holding at most maxEntries entries;
+// zero means unbounded.
+func NewMemoryStore(maxEntries int) *MemoryStore {
+	return &MemoryStore{entries: make(map[string]memoryEntry), maxEntries: maxEntries, now: time.Now}
+}
+
+// Get returns a live entry.
+func (s *MemoryStore) Get(_ context.Context, key string) ([]llm.ChatCompletionChunk, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.entries[key]
+	if !ok {
+		return nil, ErrMiss
+	}
+	if !s.now().Before(e.expires) {
+		delete(s.entries, key)
+		return nil, ErrMiss
+	}
+	return e.chunks, nil
+}
+
+// Set stores chunks under key for ttl.
+func (s *MemoryStore) Set(_ context.Context, key string, chunks []llm.ChatCompletionChunk, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	if _, exists := s.entries[key]; !exists && s.maxEntries > 0 && len(s.entries) >= s.maxEntries {
+		s.evict(now)
+	}
+	s.entries[key] = memoryEntry{chunks: chunks, expires: now.Add(ttl)}
+	return nil
+}
+
+// evict drops expired entries, or the one expiring first if none are.
+func (s *MemoryStore) evict(now time.Time) {
+	var oldest string
+	var oldestExp time.Time
+	for k, e := range s.entries {
+		if !now.Before(e.expires) {
+			delete(s.entries, k)
+			continue
+		}
+		if oldest == "" || e.expires.Before(oldestExp) {
+			oldest, oldestExp = k, e.expires
+		}
+	}
+	if len(s.entries) >= s.maxEntries && oldest != "" {
+		delete(s.entries, oldest)
+	}
+}
@@ -0,0 +1,87 @@
+package responsecache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"time"
+
+	"github.com/raja.aiml/llm-fast-wrapper/internal/llm"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/logger"
+)
+
+// Entry is a row of the response_cache table.
+type Entry struct {
+	Key       string    `gorm:"primaryKey"`
+	Chunks    string    `gorm:"type:text"`
+	ExpiresAt time.Time `gorm:"index"`
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+}
+
+// TableName keeps the table name stable regardless of GORM naming rules.
+func (Entry) TableName() string { return "response_cache" }
+
+// PostgresStore keeps entries in Postgres using GORM so every replica shares
+// one cache.
+type PostgresStore struct {
+	DB *gorm.DB // Exported for test injection
+}
+
+// NewPostgresStore connects using GORM and ensures the response_cache table.
+func NewPostgresStore(dsn string) (*PostgresStore, error) {
+	cfg := &gorm.Config{}
+
+	if os.Getenv("GORM_LOG_LEVEL") == "silent" {
+		cfg.Logger = logger.Discard
+	}
+
+	db, err := gorm.Open(postgres.Open(dsn), cfg)
+	if err != nil {
+		return nil, err
+	}
+	return NewStoreFromDB(db)
+}
+
+// NewStoreFromDB migrates and wraps an existing GORM DB, e.g. SQLite in tests.
+func NewStoreFromDB(db *gorm.DB) (*PostgresStore, error) {
+	if err := db.AutoMigrate(&Entry{}); err != nil {
+		return nil, err
+	}
+	return &PostgresStore{DB: db}, nil
+}
+
+// Get returns a live entry.
+func (s *PostgresStore) Get(ctx context.Context, key string) ([]llm.ChatCompletionChunk, error) {
+	var e Entry
+	err := s.DB.WithContext(ctx).Where("key = ? AND expires_at > ?", key, time.Now()).First(&e).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrMiss
+	}
+	if err != nil {
+		return nil, err
+	}
+	var chunks []llm.ChatCompletionChunk
+	if err := json.Unmarshal([]byte(e.Chunks), &chunks); err != nil {
+		return nil, err
+	}
+	return chunks, nil
+}
+
+// Set upserts the entry for key and removes expired rows.
+func (s *PostgresStore) Set(ctx context.Context, key string, chunks []llm.ChatCompletionChunk, ttl time.Duration) error {
+	data, err := json.Marshal(chunks)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	db := s.DB.WithContext(ctx)
+	entry := &Entry{Key: key, Chunks: string(data), ExpiresAt: now.Add(ttl)}
+	if err := db.Clauses(clause.OnConflict{UpdateAll: true}).Create(entry).Error; err != nil {
+		return err
+	}
+	return db.Where("expires_at <= ?", now).Delete(&Entry{}).Error
+}
@@ -0,0 +1,149 @@
+package responsecache
+
+import (
+	"context"
+	"errors"
+
+	"github.com/raja.aiml/llm-fast-wrapper/internal/config"
+	"github.com/raja.aiml/llm-fast-wrapper/internal/embeddings/storage"
+	"github.com/raja.aiml/llm-fast-wrapper/internal/llm"
+	"github.com/raja.aiml/llm-fast-wrapper/internal/logging"
+	"github.com/raja.aiml/llm-fast-wrapper/internal/metrics"
+	"go.uber.org/zap"
+)
+
+// semanticPrefix namespaces semantic cache entries in shared vector and
+// response stores.
+const semanticPrefix = "semcache:"
+
+const defaultCandidates = 10
+
+// Embedder embeds prompt text. embeddings.Service satisfies it.
+type Embedder interface {
+	Get(ctx context.Context, text string) ([]float32, error)
+}
+
+// Index stores prompt embeddings by scope and finds the nearest ones within
+// a scope. Any storage.AdvancedVectorStore satisfies it, as does the
+// in-memory store.
+type Index interface {
+	StoreScoped(ctx context.Context, scope storage.Scope, text string, vec []float32) error
+	SearchScoped(ctx context.Context, scope storage.Scope, embedding []float32, k int) ([]storage.SimilarItem, error)
+}
+
+// Semantic is a response cache that matches requests by the embedding of
+// their last user message. Everything else in the request (tenant, model,
+// earlier messages, sampling parameters) must match exactly, so a hit only
+// ever paraphrases the final question.
+type Semantic struct {
+	embedder Embedder
+	index    Index
+	store    Store
+	cfg      config.SemanticCacheConfig
+	logger   *zap.SugaredLogger
+}
+
+// NewSemantic creates a Semantic cache that embeds prompts with embedder,
+// searches them in index and keeps responses in store for cfg.TTL.
+func NewSemantic(embedder Embedder, index Index, store Store, cfg config.SemanticCacheConfig) *Semantic {
+	if cfg.Candidates <= 0 {
+		cfg.Candidates = defaultCandidates
+	}
+	return &Semantic{
+		embedder: embedder,
+		index:    index,
+		store:    store,
+		cfg:      cfg,
+		logger:   logging.InitLogger("logs/semantic-cache.log"),
+	}
+}
+
+// semanticScope splits req into the prompt matched by similarity and the
+// scope every matching entry must share: the tenant, the model and the hash
+// of the rest of the request. Requests not ending in a user message are not
+// cached.
+func semanticScope(tenant string, req *llm.ChatCompletionRequest) (scope storage.Scope, prompt string, ok bool) {
+	prompt, ok = req.LastUserMessage()
+	if !ok {
+		return storage.Scope{}, "", false
+	}
+	rest := *req
+	rest.Messages = append([]llm.Message(nil), req.Messages...)
+	rest.Messages[len(rest.Messages)-1].Content = ""
+	return storage.Scope{Tenant: tenant, Model: req.Model, RequestHash: Key(tenant, &rest)}, prompt, true
+}
+
+// semanticKey is the key of a prompt's entry in the vector and response
+// stores.
+func semanticKey(scope storage.Scope, prompt string) string {
+	return semanticPrefix + scope.RequestHash + ":" + prompt
+}
+
+// Lookup returns the response of the closest earlier prompt in the same
+// scope when its similarity reaches the model's threshold. Failures are
+// logged and reported as misses.
+func (s *Semantic) Lookup(ctx context.Context, tenant string, req *llm.ChatCompletionRequest) ([]llm.ChatCompletionChunk, bool) {
+	scope, prompt, ok := semanticScope(tenant, req)
+	if !ok {
+		return nil, false
+	}
+	vec, err := s.embedder.Get(ctx, prompt)
+	if err != nil {
+		s.logger.Warnf("semantic cache embed failed: %v", err)
+		metrics.SemanticCacheLookups.WithLabelValues("error").Inc()
+		return nil, false
+	}
+	items, err := s.index.SearchScoped(ctx, scope, vec, s.cfg.Candidates)
+	if err != nil {
+		s.logger.Warnf("semantic cache search failed: %v", err)
+		metrics.SemanticCacheLookups.WithLabelValues("error").Inc()
+		return nil, false
+	}
+
+	if len(items) > 0 {
+		metrics.SemanticCacheSimilarity.Observe(float64(items[0].Similarity))
+	}
+	threshold := s.cfg.ThresholdFor(req.Model)
+	for _, item := range items {
+		if item.Similarity < threshold {
+			break
+		}
+		chunks, err := s.store.Get(ctx, item.Text)
+		if err != nil {
+			// expired entries keep their vectors; try the next candidate
+			if !errors.Is(err, ErrMiss) {
+				s.logger.Warnf("semantic cache lookup failed: %v", err)
+			}
+			continue
+		}
+		s.logger.Infof("semantic cache hit for model %s (similarity %.4f)", req.Model, item.Similarity)
+		metrics.SemanticCacheLookups.WithLabelValues("hit").Inc()
+		return chunks, true
+	}
+	metrics.SemanticCacheLookups.WithLabelValues("miss").Inc()
+	return nil, false
+}
+
+// Record forwards ch and, once the stream completes, stores the response and
+// indexes the prompt embedding.
+func (s *Semantic) Record(ctx context.Context, tenant string, req *llm.ChatCompletionRequest, ch <-chan llm.ChatCompletionChunk) <-chan llm.ChatCompletionChunk {
+	scope, prompt, ok := semanticScope(tenant, req)
+	if !ok {
+		return ch
+	}
+	key := semanticKey(scope, prompt)
+	return tee(ctx, ch, func(ctx context.Context, chunks []llm.ChatCompletionChunk) {
+		vec, err := s.embedder.Get(ctx, prompt)
+		if err != nil {
+			s.logger.Warnf("semantic cache embed failed: %v", err)
+			return
+		}
+		if err := s.store.Set(ctx, key, chunks, s.cfg.TTL); err != nil {
+			s.logger.Warnf("semantic cache store failed: %v", err)
+			return
+		}
+		if err := s.index.StoreScoped(ctx, scope, key, vec); err != nil {
+			s.logger.Warnf("semantic cache index failed: %v", err)
+		}
+	})
+}
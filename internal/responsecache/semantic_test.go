@@ -0,0 +1,123 @@
+package responsecache_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/raja.aiml/llm-fast-wrapper/internal/config"
+	"github.com/raja.aiml/llm-fast-wrapper/internal/embeddings/storage/memory"
+	"github.com/raja.aiml/llm-fast-wrapper/internal/llm"
+	"github.com/raja.aiml/llm-fast-wrapper/internal/metrics"
+	"github.com/raja.aiml/llm-fast-wrapper/internal/responsecache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// vectors embeds a fixed vocabulary; unknown text fails.
+type vectors map[string][]float32
+
+func (v vectors) Get(_ context.Context, text string) ([]float32, error) {
+	if vec, ok := v[text]; ok {
+		return vec, nil
+	}
+	return nil, errors.New("no vector for " + text)
+}
+
+var prompts = vectors{
+	"what is the capital of france": {1, 0, 0},
+	"capital of france?":            {0.99, 0.14, 0},
+	"how tall is everest":           {0, 1, 0},
+}
+
+func newSemantic(cfg config.SemanticCacheConfig) *responsecache.Semantic {
+	cfg.TTL = time.Minute
+	return responsecache.NewSemantic(prompts, memory.NewMemoryStore(), responsecache.NewMemoryStore(0), cfg)
+}
+
+func record(t *testing.T, c *responsecache.Semantic, tenant string, req *llm.ChatCompletionRequest) {
+	t.Helper()
+	for range c.Record(context.Background(), tenant, req, feed(chunks("Paris")...)) {
+	}
+	require.Eventually(t, func() bool {
+		_, ok := c.Lookup(context.Background(), tenant, req)
+		return ok
+	}, time.Second, 5*time.Millisecond)
+}
+
+func TestSemanticHitsParaphrase(t *testing.T) {
+	c := newSemantic(config.SemanticCacheConfig{Threshold: 0.9})
+	record(t, c, "acme", chatRequest("what is the capital of france"))
+
+	hits := testutil.ToFloat64(metrics.SemanticCacheLookups.WithLabelValues("hit"))
+	got, ok := c.Lookup(context.Background(), "acme", chatRequest("capital of france?"))
+	require.True(t, ok)
+	assert.Equal(t, chunks("Paris"), got)
+	assert.Equal(t, hits+1, testutil.ToFloat64(metrics.SemanticCacheLookups.WithLabelValues("hit")))
+
+	misses := testutil.ToFloat64(metrics.SemanticCacheLookups.WithLabelValues("miss"))
+	_, ok = c.Lookup(context.Background(), "acme", chatRequest("how tall is everest"))
+	assert.False(t, ok, "dissimilar prompt")
+	assert.Equal(t, misses+1, testutil.ToFloat64(metrics.SemanticCacheLookups.WithLabelValues("miss")))
+}
+
+func TestSemanticPerModelThreshold(t *testing.T) {
+	c := newSemantic(config.SemanticCacheConfig{Threshold: 0.9, Thresholds: map[string]float32{"strict": 0.999}})
+	strict := chatRequest("what is the capital of france")
+	strict.Model = "strict"
+	record(t, c, "", strict)
+
+	paraphrase := chatRequest("capital of france?")
+	paraphrase.Model = "strict"
+	_, ok := c.Lookup(context.Background(), "", paraphrase)
+	assert.False(t, ok, "similarity below the model's threshold")
+}
+
+func TestSemanticScope(t *testing.T) {
+	c := newSemantic(config.SemanticCacheConfig{Threshold: 0.9})
+	record(t, c, "acme", chatRequest("what is the capital of france"))
+
+	_, ok := c.Lookup(context.Background(), "globex", chatRequest("capital of france?"))
+	assert.False(t, ok, "tenants never share entries")
+
+	withSystem := chatRequest("capital of france?")
+	withSystem.Messages = append([]llm.Message{{Role: "system", Content: "answer in French"}}, withSystem.Messages...)
+	_, ok = c.Lookup(context.Background(), "acme", withSystem)
+	assert.False(t, ok, "earlier messages must match exactly")
+
+	temp := 0.7
+	withTemp := chatRequest("capital of france?")
+	withTemp.Temperature = &temp
+	_, ok = c.Lookup(context.Background(), "acme", withTemp)
+	assert.False(t, ok, "sampling parameters must match exactly")
+}
+
+func TestSemanticSearchesWithinScope(t *testing.T) {
+	c := newSemantic(config.SemanticCacheConfig{Threshold: 0.9, Candidates: 1})
+	record(t, c, "acme", chatRequest("what is the capital of france"))
+	record(t, c, "globex", chatRequest("capital of france?"))
+
+	got, ok := c.Lookup(context.Background(), "acme", chatRequest("capital of france?"))
+	require.True(t, ok, "a closer prompt of another tenant does not crowd out the candidates")
+	assert.Equal(t, chunks("Paris"), got)
+}
+
+func TestSemanticEntriesStayOutOfPlainSearches(t *testing.T) {
+	vectors := memory.NewMemoryStore()
+	c := responsecache.NewSemantic(prompts, vectors, responsecache.NewMemoryStore(0), config.SemanticCacheConfig{TTL: time.Minute})
+	record(t, c, "acme", chatRequest("what is the capital of france"))
+
+	items, err := vectors.SearchByEmbedding(context.Background(), []float32{1, 0, 0}, 10)
+	require.NoError(t, err)
+	assert.Empty(t, items)
+}
+
+func TestSemanticEmbedFailureIsMiss(t *testing.T) {
+	c := newSemantic(config.SemanticCacheConfig{})
+	errs := testutil.ToFloat64(metrics.SemanticCacheLookups.WithLabelValues("error"))
+	_, ok := c.Lookup(context.Background(), "", chatRequest("unknown prompt"))
+	assert.False(t, ok)
+	assert.Equal(t, errs+1, testutil.ToFloat64(metrics.SemanticCacheLookups.WithLabelValues("error")))
+}
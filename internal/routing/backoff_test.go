@@ -0,0 +1,34 @@
+package routing
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+
+	openai "github.com/openai/openai-go"
+	"github.com/raja.aiml/llm-fast-wrapper/internal/llm"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBackoffBounds(t *testing.T) {
+	for attempt := 1; attempt <= 10; attempt++ {
+		for range 50 {
+			d := backoff(attempt, 100*time.Millisecond, time.Second)
+			assert.GreaterOrEqual(t, d, time.Duration(0))
+			assert.LessOrEqual(t, d, min(time.Second, 100*time.Millisecond<<(attempt-1)))
+		}
+	}
+	assert.Zero(t, backoff(3, 0, time.Second))
+}
+
+func TestRetryable(t *testing.T) {
+	assert.True(t, retryable(&openai.Error{StatusCode: 503}))
+	assert.True(t, retryable(&openai.Error{StatusCode: 429}))
+	assert.False(t, retryable(&openai.Error{StatusCode: 400}))
+	assert.True(t, retryable(&url.Error{Op: "Post", URL: "http://x", Err: errors.New("connection refused")}))
+	assert.False(t, retryable(context.Canceled))
+	assert.False(t, retryable(llm.ErrModelNotFound))
+	assert.False(t, retryable(errors.New(`unsupported message role "narrator"`)))
+}
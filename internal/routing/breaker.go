@@ -0,0 +1,116 @@
+package routing
+
+import (
+	"sync"
+	"time"
+)
+
+// State is the state of a circuit breaker.
+type State int
+
+const (
+	// StateClosed lets every request through.
+	StateClosed State = iota
+	// StateOpen rejects requests until the open timeout elapses.
+	StateOpen
+	// StateHalfOpen lets a single probe request through.
+	StateHalfOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half-open"
+	default:
+		return "closed"
+	}
+}
+
+// breaker is a consecutive-failure circuit breaker for one backend.
+type breaker struct {
+	threshold   int
+	openTimeout time.Duration
+	onChange    func(from, to State)
+
+	mu       sync.Mutex
+	state    State
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+func newBreaker(threshold int, openTimeout time.Duration, onChange func(from, to State)) *breaker {
+	return &breaker{threshold: threshold, openTimeout: openTimeout, onChange: onChange}
+}
+
+// Allow reports whether a request may be sent to the backend. After the
+// open timeout it moves to half-open and admits one probe at a time.
+func (b *breaker) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.state {
+	case StateOpen:
+		if time.Since(b.openedAt) < b.openTimeout {
+			return false
+		}
+		b.setState(StateHalfOpen)
+		b.probing = true
+		return true
+	case StateHalfOpen:
+		if b.probing {
+			return false
+		}
+		b.probing = true
+		return true
+	default:
+		return true
+	}
+}
+
+// Success records a request that produced its first token.
+func (b *breaker) Success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures = 0
+	b.probing = false
+	if b.state != StateClosed {
+		b.setState(StateClosed)
+	}
+}
+
+// Failure records a request that failed before its first token.
+func (b *breaker) Failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+	b.failures++
+	if b.state == StateHalfOpen || (b.state == StateClosed && b.failures >= b.threshold) {
+		b.openedAt = time.Now()
+		b.setState(StateOpen)
+	}
+}
+
+// Release ends a request that was abandoned by its caller without recording
+// an outcome, so a half-open breaker can admit another probe.
+func (b *breaker) Release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+}
+
+// State returns the current state.
+func (b *breaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+func (b *breaker) setState(to State) {
+	from := b.state
+	b.state = to
+	if b.onChange != nil && from != to {
+		b.onChange(from, to)
+	}
+}
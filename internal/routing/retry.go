@@ -0,0 +1,63 @@
+package routing
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"net"
+	"net/url"
+	"time"
+
+	openai "github.com/openai/openai-go"
+	"github.com/raja.aiml/llm-fast-wrapper/internal/llm"
+)
+
+// errEarlyDrop marks a stream that failed before producing its first chunk.
+var errEarlyDrop = errors.New("stream dropped before first token")
+
+// backoff returns the full-jitter delay before retry number attempt (1-based):
+// a random duration up to min(max, base*2^(attempt-1)).
+func backoff(attempt int, base, max time.Duration) time.Duration {
+	if base <= 0 {
+		return 0
+	}
+	d := base << (attempt - 1)
+	if d > max || d <= 0 {
+		d = max
+	}
+	return rand.N(d + 1)
+}
+
+// sleep waits for d or until ctx is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// retryable reports whether err, returned before the stream started, is worth
+// another attempt: upstream 5xx and 429 responses, transport failures and
+// streams that dropped before their first token.
+func retryable(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) || errors.Is(err, llm.ErrModelNotFound) {
+		return false
+	}
+	if errors.Is(err, errEarlyDrop) {
+		return true
+	}
+	var apiErr *openai.Error
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode == 429 || apiErr.StatusCode >= 500
+	}
+	var urlErr *url.Error
+	var netErr net.Error
+	return errors.As(err, &urlErr) || errors.As(err, &netErr)
+}
@@ -0,0 +1,247 @@
+package routing_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/openai/openai-go/option"
+	"github.com/raja.aiml/llm-fast-wrapper/internal/config"
+	"github.com/raja.aiml/llm-fast-wrapper/internal/llm"
+	"github.com/raja.aiml/llm-fast-wrapper/internal/routing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const okChunk = `{"id":"x","object":"chat.completion.chunk","created":1,"model":"m","choices":[{"index":0,"delta":{"content":"ok"},"finish_reason":"stop"}]}`
+
+// scripted serves the i-th request (0-based) with script(i).
+func scripted(t *testing.T, script func(i int, w http.ResponseWriter)) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		script(int(calls.Add(1))-1, w)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &calls
+}
+
+func status(w http.ResponseWriter, code int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	fmt.Fprintf(w, `{"error":{"message":"status %d"}}`, code)
+}
+
+func sse(w http.ResponseWriter, events ...string) {
+	w.Header().Set("Content-Type", "text/event-stream")
+	for _, e := range events {
+		fmt.Fprintf(w, "data: %s\n\n", e)
+	}
+}
+
+func retryConfig(backends ...config.BackendConfig) *config.ServerConfig {
+	return &config.ServerConfig{
+		Backends:       backends,
+		Routes:         []config.RouteConfig{{Glob: "*", Backend: backends[0].Name}},
+		Retry:          config.RetryConfig{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond},
+		CircuitBreaker: config.BreakerConfig{FailureThreshold: 100, OpenTimeout: time.Minute},
+	}
+}
+
+func TestRetryOnServerErrors(t *testing.T) {
+	srv, calls := scripted(t, func(i int, w http.ResponseWriter) {
+		switch i {
+		case 0:
+			status(w, http.StatusServiceUnavailable)
+		case 1:
+			status(w, http.StatusTooManyRequests)
+		default:
+			sse(w, okChunk, "[DONE]")
+		}
+	})
+	r, err := routing.FromConfig(retryConfig(config.BackendConfig{Name: "a", BaseURL: srv.URL}))
+	require.NoError(t, err)
+
+	completion, err := stream(t, r, "m")
+	require.NoError(t, err)
+	assert.Equal(t, "ok", completion.Choices[0].Message.Content)
+	assert.Equal(t, int32(3), calls.Load())
+}
+
+func TestNoRetryOnClientErrors(t *testing.T) {
+	srv, calls := scripted(t, func(i int, w http.ResponseWriter) { status(w, http.StatusBadRequest) })
+	r, err := routing.FromConfig(retryConfig(config.BackendConfig{Name: "a", BaseURL: srv.URL}))
+	require.NoError(t, err)
+
+	_, err = stream(t, r, "m")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "status 400")
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestRetryWhenStreamDropsBeforeFirstToken(t *testing.T) {
+	srv, calls := scripted(t, func(i int, w http.ResponseWriter) {
+		if i == 0 {
+			sse(w, `{"error":{"message":"overloaded"}}`)
+			return
+		}
+		sse(w, okChunk, "[DONE]")
+	})
+	r, err := routing.FromConfig(retryConfig(config.BackendConfig{Name: "a", BaseURL: srv.URL}))
+	require.NoError(t, err)
+
+	completion, err := stream(t, r, "m")
+	require.NoError(t, err)
+	assert.Equal(t, "ok", completion.Choices[0].Message.Content)
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestNoRetryAfterFirstToken(t *testing.T) {
+	srv, calls := scripted(t, func(i int, w http.ResponseWriter) {
+		sse(w, `{"id":"x","object":"chat.completion.chunk","created":1,"model":"m","choices":[{"index":0,"delta":{"content":"par"}}]}`, `{"error":{"message":"overloaded"}}`)
+	})
+	r, err := routing.FromConfig(retryConfig(config.BackendConfig{Name: "a", BaseURL: srv.URL}))
+	require.NoError(t, err)
+
+	_, err = stream(t, r, "m")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "overloaded")
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestFailoverInOrder(t *testing.T) {
+	primary, primaryCalls := scripted(t, func(i int, w http.ResponseWriter) { status(w, http.StatusBadGateway) })
+	secondary, secondaryCalls := scripted(t, func(i int, w http.ResponseWriter) { status(w, http.StatusInternalServerError) })
+	tertiary, tertiaryCalls := scripted(t, func(i int, w http.ResponseWriter) { sse(w, okChunk, "[DONE]") })
+	cfg := retryConfig(
+		config.BackendConfig{Name: "primary", BaseURL: primary.URL},
+		config.BackendConfig{Name: "secondary", BaseURL: secondary.URL},
+		config.BackendConfig{Name: "tertiary", BaseURL: tertiary.URL},
+	)
+	cfg.Routes[0].Fallbacks = []string{"secondary", "tertiary"}
+	r, err := routing.FromConfig(cfg)
+	require.NoError(t, err)
+
+	completion, err := stream(t, r, "m")
+	require.NoError(t, err)
+	assert.Equal(t, "ok", completion.Choices[0].Message.Content)
+	assert.Equal(t, int32(3), primaryCalls.Load())
+	assert.Equal(t, int32(3), secondaryCalls.Load())
+	assert.Equal(t, int32(1), tertiaryCalls.Load())
+}
+
+func TestAllAttemptsFail(t *testing.T) {
+	srv, _ := scripted(t, func(i int, w http.ResponseWriter) { status(w, http.StatusServiceUnavailable) })
+	r, err := routing.FromConfig(retryConfig(config.BackendConfig{Name: "a", BaseURL: srv.URL}))
+	require.NoError(t, err)
+
+	_, err = stream(t, r, "m")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "status 503")
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	var healthy atomic.Bool
+	srv, calls := scripted(t, func(i int, w http.ResponseWriter) {
+		if healthy.Load() {
+			sse(w, okChunk, "[DONE]")
+			return
+		}
+		status(w, http.StatusInternalServerError)
+	})
+	var mu sync.Mutex
+	var transitions []string
+	r, err := routing.New(
+		map[string]llm.Streamer{"a": llm.NewOpenAIStreamer("k", srv.URL, "", noRetries())},
+		[]config.RouteConfig{{Glob: "*", Backend: "a"}}, "",
+		routing.WithRetry(config.RetryConfig{MaxAttempts: 1}),
+		routing.WithCircuitBreaker(config.BreakerConfig{FailureThreshold: 2, OpenTimeout: 50 * time.Millisecond}),
+		routing.WithStateChange(func(backend string, from, to routing.State) {
+			mu.Lock()
+			defer mu.Unlock()
+			transitions = append(transitions, fmt.Sprintf("%s:%s->%s", backend, from, to))
+		}),
+	)
+	require.NoError(t, err)
+
+	for range 2 {
+		_, err = stream(t, r, "m")
+		require.Error(t, err)
+	}
+	assert.Equal(t, routing.StateOpen, r.BreakerState("a"))
+
+	_, err = stream(t, r, "m")
+	assert.ErrorIs(t, err, routing.ErrCircuitOpen)
+	assert.Equal(t, int32(2), calls.Load(), "open circuit does not reach the backend")
+
+	healthy.Store(true)
+	time.Sleep(60 * time.Millisecond)
+	_, err = stream(t, r, "m")
+	require.NoError(t, err)
+	assert.Equal(t, routing.StateClosed, r.BreakerState("a"))
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []string{"a:closed->open", "a:open->half-open", "a:half-open->closed"}, transitions)
+}
+
+func TestCanceledProbeReleasesBreaker(t *testing.T) {
+	var phase atomic.Int32
+	srv, calls := scripted(t, func(i int, w http.ResponseWriter) {
+		switch phase.Load() {
+		case 0:
+			status(w, http.StatusInternalServerError)
+		case 1:
+			time.Sleep(100 * time.Millisecond)
+			status(w, http.StatusInternalServerError)
+		default:
+			sse(w, okChunk, "[DONE]")
+		}
+	})
+	r, err := routing.New(
+		map[string]llm.Streamer{"a": llm.NewOpenAIStreamer("k", srv.URL, "", noRetries())},
+		[]config.RouteConfig{{Glob: "*", Backend: "a"}}, "",
+		routing.WithRetry(config.RetryConfig{MaxAttempts: 1}),
+		routing.WithCircuitBreaker(config.BreakerConfig{FailureThreshold: 1, OpenTimeout: 20 * time.Millisecond}),
+	)
+	require.NoError(t, err)
+
+	_, err = stream(t, r, "m")
+	require.Error(t, err)
+	require.Equal(t, routing.StateOpen, r.BreakerState("a"))
+
+	phase.Store(1)
+	time.Sleep(30 * time.Millisecond)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err = r.Stream(ctx, &llm.ChatCompletionRequest{Model: "m", Messages: []llm.Message{{Role: "user", Content: "hi"}}})
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, routing.StateHalfOpen, r.BreakerState("a"), "a canceled probe is not a backend failure")
+
+	phase.Store(2)
+	_, err = stream(t, r, "m")
+	require.NoError(t, err, "the next request is admitted as the probe")
+	assert.Equal(t, routing.StateClosed, r.BreakerState("a"))
+	assert.Equal(t, int32(3), calls.Load())
+}
+
+func TestCanceledContextStopsRetries(t *testing.T) {
+	srv, calls := scripted(t, func(i int, w http.ResponseWriter) { status(w, http.StatusServiceUnavailable) })
+	cfg := retryConfig(config.BackendConfig{Name: "a", BaseURL: srv.URL})
+	cfg.Retry = config.RetryConfig{MaxAttempts: 5, BaseDelay: time.Hour, MaxDelay: time.Hour}
+	r, err := routing.FromConfig(cfg)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = r.Stream(ctx, &llm.ChatCompletionRequest{Model: "m", Messages: []llm.Message{{Role: "user", Content: "hi"}}})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.LessOrEqual(t, calls.Load(), int32(2))
+}
+
+func noRetries() option.RequestOption { return option.WithMaxRetries(0) }
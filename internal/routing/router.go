@@ -0,0 +1,338 @@
+// Package routing picks an upstream backend for each chat request from its
+// model name, so one server can front several OpenAI-compatible providers.
+package routing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/openai/openai-go/option"
+	"github.com/raja.aiml/llm-fast-wrapper/internal/config"
+	"github.com/raja.aiml/llm-fast-wrapper/internal/llm"
+	"github.com/raja.aiml/llm-fast-wrapper/internal/metrics"
+	"github.com/raja.aiml/llm-fast-wrapper/internal/telemetry"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ErrCircuitOpen is returned when every candidate backend has an open circuit.
+var ErrCircuitOpen = errors.New("circuit breaker open")
+
+// Router is an llm.Streamer that dispatches each request to the backend
+// whose route matches the request model. Exact routes win over prefix
+// routes (longest first), which win over globs (in configuration order).
+//
+// Requests that fail before their first token are retried with jittered
+// backoff and then failed over to the route's fallbacks in order. Each
+// backend has a circuit breaker; backends with an open circuit are skipped.
+type Router struct {
+	backends map[string]llm.Streamer
+	exact    map[string]config.RouteConfig
+	prefixes []config.RouteConfig
+	globs    []config.RouteConfig
+	fallback string
+
+	retry    config.RetryConfig
+	breaker  config.BreakerConfig
+	onChange func(backend string, from, to State)
+	breakers map[string]*breaker
+}
+
+// Option configures optional Router behaviour.
+type Option func(*Router)
+
+// WithRetry overrides the default retry policy (2 attempts per backend,
+// 200ms base delay, 2s max delay).
+func WithRetry(cfg config.RetryConfig) Option {
+	return func(r *Router) {
+		if cfg.MaxAttempts > 0 {
+			r.retry.MaxAttempts = cfg.MaxAttempts
+		}
+		if cfg.BaseDelay > 0 {
+			r.retry.BaseDelay = cfg.BaseDelay
+		}
+		if cfg.MaxDelay > 0 {
+			r.retry.MaxDelay = cfg.MaxDelay
+		}
+	}
+}
+
+// WithCircuitBreaker overrides the default breaker settings (open after 5
+// consecutive failures, probe again after 30s).
+func WithCircuitBreaker(cfg config.BreakerConfig) Option {
+	return func(r *Router) {
+		if cfg.FailureThreshold > 0 {
+			r.breaker.FailureThreshold = cfg.FailureThreshold
+		}
+		if cfg.OpenTimeout > 0 {
+			r.breaker.OpenTimeout = cfg.OpenTimeout
+		}
+	}
+}
+
+// WithStateChange registers fn to observe circuit breaker transitions in
+// addition to the log line the Router writes for each one.
+func WithStateChange(fn func(backend string, from, to State)) Option {
+	return func(r *Router) { r.onChange = fn }
+}
+
+// New builds a Router over named backends. fallback names the backend for
+// requests no route matches; empty rejects them with llm.ErrModelNotFound.
+func New(backends map[string]llm.Streamer, routes []config.RouteConfig, fallback string, opts ...Option) (*Router, error) {
+	r := &Router{
+		backends: backends,
+		exact:    make(map[string]config.RouteConfig),
+		fallback: fallback,
+		retry:    config.RetryConfig{MaxAttempts: 2, BaseDelay: 200 * time.Millisecond, MaxDelay: 2 * time.Second},
+		breaker:  config.BreakerConfig{FailureThreshold: 5, OpenTimeout: 30 * time.Second},
+		breakers: make(map[string]*breaker, len(backends)),
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	for name := range backends {
+		name := name
+		r.breakers[name] = newBreaker(r.breaker.FailureThreshold, r.breaker.OpenTimeout, func(from, to State) {
+			log.Printf("[WARN] circuit breaker for backend %q: %s -> %s", name, from, to)
+			if r.onChange != nil {
+				r.onChange(name, from, to)
+			}
+		})
+	}
+	if fallback != "" && backends[fallback] == nil {
+		return nil, fmt.Errorf("default backend %q is not defined", fallback)
+	}
+	for i, route := range routes {
+		if backends[route.Backend] == nil {
+			return nil, fmt.Errorf("route #%d: unknown backend %q", i+1, route.Backend)
+		}
+		for _, fb := range route.Fallbacks {
+			if backends[fb] == nil {
+				return nil, fmt.Errorf("route #%d: unknown fallback backend %q", i+1, fb)
+			}
+		}
+		switch {
+		case route.Model != "" && route.Prefix == "" && route.Glob == "":
+			if _, dup := r.exact[route.Model]; dup {
+				return nil, fmt.Errorf("route #%d: duplicate model %q", i+1, route.Model)
+			}
+			r.exact[route.Model] = route
+		case route.Prefix != "" && route.Model == "" && route.Glob == "":
+			r.prefixes = append(r.prefixes, route)
+		case route.Glob != "" && route.Model == "" && route.Prefix == "":
+			if _, err := path.Match(route.Glob, ""); err != nil {
+				return nil, fmt.Errorf("route #%d: bad glob %q: %w", i+1, route.Glob, err)
+			}
+			r.globs = append(r.globs, route)
+		default:
+			return nil, fmt.Errorf("route #%d: set exactly one of model, prefix or glob", i+1)
+		}
+		if route.StripPrefix && route.Prefix == "" {
+			return nil, fmt.Errorf("route #%d: strip_prefix requires prefix", i+1)
+		}
+	}
+	// longest prefix first so "llama3-" beats "llama"
+	sort.SliceStable(r.prefixes, func(i, j int) bool {
+		return len(r.prefixes[i].Prefix) > len(r.prefixes[j].Prefix)
+	})
+	return r, nil
+}
+
+// FromConfig builds the backends and routes described in cfg. Models in the
+// registry whose backend names a configured backend get an implicit exact
+// route unless one is configured explicitly. opts are applied after the
+// retry and breaker settings from cfg.
+func FromConfig(cfg *config.ServerConfig, opts ...Option) (*Router, error) {
+	backends := make(map[string]llm.Streamer, len(cfg.Backends))
+	for i, b := range cfg.Backends {
+		if b.Name == "" {
+			return nil, fmt.Errorf("backend #%d has no name", i+1)
+		}
+		if backends[b.Name] != nil {
+			return nil, fmt.Errorf("duplicate backend %q", b.Name)
+		}
+		switch b.Type {
+		case "", "openai":
+			// the Router owns retries, so the client must not retry on its own
+			backends[b.Name] = llm.NewOpenAIStreamer(b.Key(), b.BaseURL, "", option.WithMaxRetries(0))
+		case "mock":
+			backends[b.Name] = llm.NewMockStreamer()
+		default:
+			return nil, fmt.Errorf("backend %q: unknown type %q", b.Name, b.Type)
+		}
+		if b.FanOut {
+			backends[b.Name] = llm.NewFanOutStreamer(backends[b.Name])
+		}
+	}
+
+	routes := append([]config.RouteConfig(nil), cfg.Routes...)
+	explicit := make(map[string]bool)
+	for _, route := range routes {
+		explicit[route.Model] = true
+	}
+	for _, m := range cfg.Models {
+		if backends[m.Backend] != nil && !explicit[m.ID] {
+			routes = append(routes, config.RouteConfig{Model: m.ID, Backend: m.Backend})
+		}
+	}
+	opts = append([]Option{WithRetry(cfg.Retry), WithCircuitBreaker(cfg.CircuitBreaker)}, opts...)
+	return New(backends, routes, cfg.DefaultBackend, opts...)
+}
+
+// Stream implements llm.Streamer. The request is copied before its model is
+// rewritten, so callers' requests are never modified. Stream returns once a
+// backend has produced its first chunk, so failures up to that point are
+// retried transparently and surface as a pre-stream error only when every
+// attempt failed.
+func (r *Router) Stream(ctx context.Context, req *llm.ChatCompletionRequest) (<-chan llm.ChatCompletionChunk, error) {
+	route, err := r.match(req.Model)
+	if err != nil {
+		return nil, err
+	}
+	upstream := *req
+	upstream.Model = rewrite(route, req.Model)
+
+	var lastErr error
+	for _, name := range append([]string{route.Backend}, route.Fallbacks...) {
+		b := r.breakers[name]
+		for attempt := 1; attempt <= r.retry.MaxAttempts; attempt++ {
+			if !b.Allow() {
+				lastErr = fmt.Errorf("backend %q: %w", name, ErrCircuitOpen)
+				break
+			}
+			// the span ends with the first chunk; the upstream span below it
+			// covers the whole stream
+			attemptCtx, span := telemetry.Tracer().Start(ctx, "routing.attempt", trace.WithAttributes(
+				attribute.String("llm.backend", name), attribute.Int("routing.attempt", attempt)))
+			ch, err := r.attempt(attemptCtx, name, &upstream)
+			telemetry.End(span, err)
+			if err == nil {
+				b.Success()
+				return ch, nil
+			}
+			if ctx.Err() != nil {
+				// the caller gave up; that says nothing about the backend
+				b.Release()
+				return nil, ctx.Err()
+			}
+			if !retryable(err) {
+				// the backend answered; a bad request is not its failure
+				b.Success()
+				return nil, err
+			}
+			b.Failure()
+			metrics.BackendFailures.WithLabelValues(name).Inc()
+			lastErr = err
+			log.Printf("[WARN] backend %q attempt %d/%d failed: %v", name, attempt, r.retry.MaxAttempts, err)
+			if attempt < r.retry.MaxAttempts {
+				if err := sleep(ctx, backoff(attempt, r.retry.BaseDelay, r.retry.MaxDelay)); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	return nil, lastErr
+}
+
+// attempt starts a stream on backend and waits for its first chunk. A stream
+// that fails before producing one is reported as an error so it can be
+// retried; otherwise the returned channel replays the first chunk.
+func (r *Router) attempt(ctx context.Context, backend string, req *llm.ChatCompletionRequest) (<-chan llm.ChatCompletionChunk, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	ch, err := r.backends[backend].Stream(ctx, req)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	var first llm.ChatCompletionChunk
+	var ok bool
+	select {
+	case first, ok = <-ch:
+	case <-ctx.Done():
+		cancel()
+		return nil, ctx.Err()
+	}
+	if ok && first.Err != nil {
+		cancel()
+		return nil, fmt.Errorf("%w: %w", errEarlyDrop, first.Err)
+	}
+
+	out := make(chan llm.ChatCompletionChunk)
+	go func() {
+		defer close(out)
+		defer cancel()
+		if !ok {
+			return
+		}
+		select {
+		case out <- first:
+		case <-ctx.Done():
+			return
+		}
+		for chunk := range ch {
+			select {
+			case out <- chunk:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out, nil
+}
+
+// Resolve returns the primary backend name and upstream model name for model.
+func (r *Router) Resolve(model string) (backend, upstream string, err error) {
+	route, err := r.match(model)
+	if err != nil {
+		return "", "", err
+	}
+	return route.Backend, rewrite(route, model), nil
+}
+
+// BreakerState returns the circuit breaker state of backend.
+func (r *Router) BreakerState(backend string) State {
+	if b := r.breakers[backend]; b != nil {
+		return b.State()
+	}
+	return StateClosed
+}
+
+// match finds the route for model, falling back to the default backend.
+func (r *Router) match(model string) (config.RouteConfig, error) {
+	if route, ok := r.exact[model]; ok {
+		return route, nil
+	}
+	for _, route := range r.prefixes {
+		if strings.HasPrefix(model, route.Prefix) {
+			return route, nil
+		}
+	}
+	for _, route := range r.globs {
+		if ok, _ := path.Match(route.Glob, model); ok {
+			return route, nil
+		}
+	}
+	if r.fallback != "" {
+		return config.RouteConfig{Backend: r.fallback}, nil
+	}
+	return config.RouteConfig{}, fmt.Errorf("%w: no route for model %q", llm.ErrModelNotFound, model)
+}
+
+func rewrite(route config.RouteConfig, model string) string {
+	switch {
+	case route.Rewrite != "":
+		return route.Rewrite
+	case route.StripPrefix:
+		return strings.TrimPrefix(model, route.Prefix)
+	default:
+		return model
+	}
+}
+
+var _ llm.Streamer = (*Router)(nil)
@@ -0,0 +1,210 @@
+package routing_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/raja.aiml/llm-fast-wrapper/internal/config"
+	"github.com/raja.aiml/llm-fast-wrapper/internal/llm"
+	"github.com/raja.aiml/llm-fast-wrapper/internal/models"
+	"github.com/raja.aiml/llm-fast-wrapper/internal/routing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// upstream is an httptest stand-in for one backend that records the model
+// and API key of every request it receives.
+type upstream struct {
+	*httptest.Server
+	mu     sync.Mutex
+	models []string
+	keys   []string
+}
+
+func newUpstream(t *testing.T) *upstream {
+	t.Helper()
+	u := &upstream{}
+	u.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Model string `json:"model"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		u.mu.Lock()
+		u.models = append(u.models, body.Model)
+		u.keys = append(u.keys, r.Header.Get("Authorization"))
+		u.mu.Unlock()
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprintf(w, `data: {"id":"x","object":"chat.completion.chunk","created":1,"model":%q,"choices":[{"index":0,"delta":{"content":"ok"},"finish_reason":"stop"}]}`+"\n\n", body.Model)
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	t.Cleanup(u.Close)
+	return u
+}
+
+func (u *upstream) lastModel() string {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if len(u.models) == 0 {
+		return ""
+	}
+	return u.models[len(u.models)-1]
+}
+
+func stream(t *testing.T, r *routing.Router, model string) (*llm.ChatCompletion, error) {
+	t.Helper()
+	ch, err := r.Stream(context.Background(), &llm.ChatCompletionRequest{
+		Model:    model,
+		Messages: []llm.Message{{Role: "user", Content: "hi"}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return llm.Collect(ch)
+}
+
+func TestRouterFromConfig(t *testing.T) {
+	openai, vllm, ollama := newUpstream(t), newUpstream(t), newUpstream(t)
+	t.Setenv("TEST_VLLM_KEY", "vllm-key")
+	cfg := &config.ServerConfig{
+		Models: []models.Model{{ID: "gpt-4o", Backend: "openai"}},
+		Backends: []config.BackendConfig{
+			{Name: "openai", BaseURL: openai.URL, APIKey: "openai-key"},
+			{Name: "vllm", BaseURL: vllm.URL, APIKeyEnv: "TEST_VLLM_KEY"},
+			{Name: "ollama", BaseURL: ollama.URL},
+		},
+		Routes: []config.RouteConfig{
+			{Model: "llama-big", Backend: "vllm", Rewrite: "meta-llama/Meta-Llama-3-70B-Instruct"},
+			{Prefix: "ollama/", Backend: "ollama", StripPrefix: true},
+			{Prefix: "ollama/vllm-", Backend: "vllm"},
+			{Glob: "mistral-*", Backend: "vllm"},
+			{Glob: "*", Backend: "openai"},
+		},
+	}
+	r, err := routing.FromConfig(cfg)
+	require.NoError(t, err)
+
+	cases := []struct {
+		model    string
+		backend  *upstream
+		upstream string
+	}{
+		{"gpt-4o", openai, "gpt-4o"},
+		{"llama-big", vllm, "meta-llama/Meta-Llama-3-70B-Instruct"},
+		{"ollama/llama3", ollama, "llama3"},
+		{"ollama/vllm-x", vllm, "ollama/vllm-x"},
+		{"mistral-7b", vllm, "mistral-7b"},
+		{"anything-else", openai, "anything-else"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.model, func(t *testing.T) {
+			completion, err := stream(t, r, tc.model)
+			require.NoError(t, err)
+			assert.Equal(t, tc.upstream, tc.backend.lastModel())
+			assert.Equal(t, "ok", completion.Choices[0].Message.Content)
+		})
+	}
+	assert.Equal(t, "Bearer openai-key", openai.keys[0])
+	assert.Equal(t, "Bearer vllm-key", vllm.keys[0])
+}
+
+func TestRouterFanOut(t *testing.T) {
+	direct, fanned := newUpstream(t), newUpstream(t)
+	cfg := &config.ServerConfig{
+		Backends: []config.BackendConfig{
+			{Name: "direct", BaseURL: direct.URL},
+			{Name: "fanned", BaseURL: fanned.URL, FanOut: true},
+		},
+		Routes:         []config.RouteConfig{{Prefix: "fanned/", Backend: "fanned"}},
+		DefaultBackend: "direct",
+	}
+	r, err := routing.FromConfig(cfg)
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		model    string
+		backend  *upstream
+		requests int
+	}{
+		{"gpt-4o", direct, 1},
+		{"fanned/llama3", fanned, 3},
+	} {
+		n := int64(3)
+		ch, err := r.Stream(context.Background(), &llm.ChatCompletionRequest{
+			Model:    tc.model,
+			Messages: []llm.Message{{Role: "user", Content: "hi"}},
+			N:        &n,
+		})
+		require.NoError(t, err)
+		completion, err := llm.Collect(ch)
+		require.NoError(t, err)
+		assert.Len(t, completion.Choices, tc.requests, tc.model)
+		assert.Len(t, tc.backend.models, tc.requests, tc.model)
+	}
+}
+
+func TestRouterDoesNotMutateRequest(t *testing.T) {
+	vllm := newUpstream(t)
+	r, err := routing.FromConfig(&config.ServerConfig{
+		Backends: []config.BackendConfig{{Name: "vllm", BaseURL: vllm.URL}},
+		Routes:   []config.RouteConfig{{Model: "small", Backend: "vllm", Rewrite: "upstream-small"}},
+	})
+	require.NoError(t, err)
+
+	req := &llm.ChatCompletionRequest{Model: "small", Messages: []llm.Message{{Role: "user", Content: "hi"}}}
+	ch, err := r.Stream(context.Background(), req)
+	require.NoError(t, err)
+	for range ch {
+	}
+	assert.Equal(t, "small", req.Model)
+	assert.Equal(t, "upstream-small", vllm.lastModel())
+}
+
+func TestRouterFallbackAndNoRoute(t *testing.T) {
+	backends := map[string]llm.Streamer{"mock": llm.NewMockStreamer()}
+
+	strict, err := routing.New(backends, []config.RouteConfig{{Model: "known", Backend: "mock"}}, "")
+	require.NoError(t, err)
+	_, err = strict.Stream(context.Background(), &llm.ChatCompletionRequest{Model: "unknown"})
+	assert.ErrorIs(t, err, llm.ErrModelNotFound)
+
+	lenient, err := routing.New(backends, nil, "mock")
+	require.NoError(t, err)
+	backend, model, err := lenient.Resolve("unknown")
+	require.NoError(t, err)
+	assert.Equal(t, "mock", backend)
+	assert.Equal(t, "unknown", model)
+}
+
+func TestRouterConfigErrors(t *testing.T) {
+	backends := map[string]llm.Streamer{"a": llm.NewMockStreamer()}
+	cases := []struct {
+		name     string
+		routes   []config.RouteConfig
+		fallback string
+		err      string
+	}{
+		{"unknown backend", []config.RouteConfig{{Model: "m", Backend: "b"}}, "", `route #1: unknown backend "b"`},
+		{"no matcher", []config.RouteConfig{{Backend: "a"}}, "", "route #1: set exactly one of model, prefix or glob"},
+		{"two matchers", []config.RouteConfig{{Model: "m", Prefix: "p", Backend: "a"}}, "", "route #1: set exactly one of model, prefix or glob"},
+		{"duplicate", []config.RouteConfig{{Model: "m", Backend: "a"}, {Model: "m", Backend: "a"}}, "", `route #2: duplicate model "m"`},
+		{"bad glob", []config.RouteConfig{{Glob: "[", Backend: "a"}}, "", `route #1: bad glob "[": syntax error in pattern`},
+		{"strip without prefix", []config.RouteConfig{{Model: "m", Backend: "a", StripPrefix: true}}, "", "route #1: strip_prefix requires prefix"},
+		{"unknown default", nil, "b", `default backend "b" is not defined`},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := routing.New(backends, tc.routes, tc.fallback)
+			assert.EqualError(t, err, tc.err)
+		})
+	}
+
+	_, err := routing.FromConfig(&config.ServerConfig{Backends: []config.BackendConfig{{Name: "a", Type: "grpc"}}})
+	assert.EqualError(t, err, `backend "a": unknown type "grpc"`)
+	_, err = routing.FromConfig(&config.ServerConfig{Backends: []config.BackendConfig{{Name: "a"}, {Name: "a"}}})
+	assert.EqualError(t, err, `duplicate backend "a"`)
+}
@@ -1,35 +1,73 @@
+// Package telemetry sets up OpenTelemetry tracing for the servers and CLIs.
+// Code that creates spans uses Tracer, which is a no-op until Init installs
+// an exporting provider.
 package telemetry
 
 import (
 	"context"
+	"fmt"
 	"log"
 
+	"github.com/raja.aiml/llm-fast-wrapper/internal/config"
 	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
 	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
 	"go.opentelemetry.io/otel/sdk/resource"
 	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
 	"go.opentelemetry.io/otel/trace"
 )
 
-var tracer trace.Tracer
+// instrumentation names the tracer of this module.
+const instrumentation = "github.com/raja.aiml/llm-fast-wrapper"
 
-func Init(service string) (func(context.Context) error, error) {
-	exp, err := otlptracehttp.New(context.Background())
+// Init installs the W3C trace context propagator and, when cfg enables
+// tracing, a provider exporting the spans of service over OTLP/HTTP. The
+// propagator is installed either way so incoming traceparent headers reach
+// the upstream. The returned function flushes pending spans.
+func Init(ctx context.Context, service string, cfg config.TracingConfig) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	if !cfg.Enabled {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	var opts []otlptracehttp.Option
+	if cfg.Endpoint != "" {
+		opts = append(opts, otlptracehttp.WithEndpointURL(cfg.Endpoint))
+	}
+	exp, err := otlptracehttp.New(ctx, opts...)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("otlp exporter: %w", err)
+	}
+	if cfg.ServiceName != "" {
+		service = cfg.ServiceName
+	}
+	ratio := cfg.SampleRatio
+	if ratio <= 0 {
+		ratio = 1
 	}
 	tp := sdktrace.NewTracerProvider(
 		sdktrace.WithBatcher(exp),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio))),
 		sdktrace.WithResource(resource.NewWithAttributes(
 			semconv.SchemaURL,
 			semconv.ServiceName(service),
 		)),
 	)
 	otel.SetTracerProvider(tp)
-	tracer = tp.Tracer(service)
-	log.Println("otel initialized")
+	log.Printf("[INFO] Tracing enabled for %q (sample ratio %g)", service, ratio)
 	return tp.Shutdown, nil
 }
 
-func Tracer() trace.Tracer { return tracer }
+// Tracer returns the tracer of the globally installed provider.
+func Tracer() trace.Tracer { return otel.Tracer(instrumentation) }
+
+// End marks span as failed when err is set and ends it.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
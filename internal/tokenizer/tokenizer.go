@@ -3,6 +3,11 @@ package tokenizer
 import (
 	"regexp"
 	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/pkoukk/tiktoken-go"
+	tiktoken_loader "github.com/pkoukk/tiktoken-go-loader"
 )
 
 var (
@@ -46,3 +51,60 @@ func SimpleTokenize(text string) []string {
 
 	return result
 }
+
+// CountTokens counts the BPE tokens of text with the encoding of model, as
+// listed by tiktoken: o200k_base for the gpt-4o family and later,
+// cl100k_base for gpt-4, gpt-3.5 and the text-embedding models. Models of
+// other families (Llama, Mistral, ...) ship their own tokenizers and are
+// counted with o200k_base, which is close but not exact for them.
+func CountTokens(model, text string) int {
+	if text == "" {
+		return 0
+	}
+	enc, err := encodingFor(model)
+	if err != nil {
+		// the encodings are embedded, so this only happens on a broken build
+		return (utf8.RuneCountInString(text) + 3) / 4
+	}
+	return len(enc.EncodeOrdinary(text))
+}
+
+var (
+	encodingsMu sync.Mutex
+	encodings   = map[string]*tiktoken.Tiktoken{}
+)
+
+func init() {
+	// never download encodings at runtime
+	tiktoken.SetBpeLoader(tiktoken_loader.NewOfflineLoader())
+}
+
+// encodingFor returns the encoding of model, built once per encoding.
+func encodingFor(model string) (*tiktoken.Tiktoken, error) {
+	name := encodingName(model)
+	encodingsMu.Lock()
+	defer encodingsMu.Unlock()
+	if enc, ok := encodings[name]; ok {
+		return enc, nil
+	}
+	enc, err := tiktoken.GetEncoding(name)
+	if err != nil {
+		return nil, err
+	}
+	encodings[name] = enc
+	return enc, nil
+}
+
+func encodingName(model string) string {
+	if name, ok := tiktoken.MODEL_TO_ENCODING[model]; ok {
+		return name
+	}
+	// the longest prefix wins, so the result does not depend on map order
+	name, longest := tiktoken.MODEL_O200K_BASE, 0
+	for prefix, enc := range tiktoken.MODEL_PREFIX_TO_ENCODING {
+		if len(prefix) > longest && strings.HasPrefix(model, prefix) {
+			name, longest = enc, len(prefix)
+		}
+	}
+	return name
+}
@@ -61,3 +61,15 @@ func TestSimpleTokenize(t *testing.T) {
 		})
 	}
 }
+
+func TestCountTokens(t *testing.T) {
+	assert.Equal(t, 0, CountTokens("gpt-4o", ""))
+	assert.Equal(t, 4, CountTokens("gpt-4o", "Hello, world!"))
+	// o200k_base and cl100k_base split non-Latin text differently.
+	assert.Equal(t, 2, CountTokens("gpt-4o", "こんにちは世界"))
+	assert.Equal(t, 4, CountTokens("gpt-4", "こんにちは世界"))
+	assert.Equal(t, 4, CountTokens("gpt-4-0613", "こんにちは世界"))
+	assert.Equal(t, 4, CountTokens("text-embedding-3-small", "こんにちは世界"))
+	// Unknown families fall back to o200k_base.
+	assert.Equal(t, 2, CountTokens("llama3", "こんにちは世界"))
+}
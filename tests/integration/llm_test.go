@@ -11,9 +11,11 @@ import (
 	"github.com/raja.aiml/llm-fast-wrapper/internal/llm"
 )
 
-func TestOpenAIStreamer(t *testing.T) {
-	streamer := llm.NewOpenAIStreamer()
-	ch, err := streamer.Stream(context.Background(), "hello world")
+func TestMockStreamer(t *testing.T) {
+	streamer := llm.NewMockStreamer()
+	ch, err := streamer.Stream(context.Background(), &llm.ChatCompletionRequest{
+		Messages: []llm.Message{{Role: "user", Content: "hello world"}},
+	})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}